@@ -14,6 +14,11 @@ import (
 	"github.com/go-chi/chi/v5"
 	"github.com/go-playground/validator/v10"
 	"github.com/ivanovaleksey/lendo/api/config"
+	"github.com/ivanovaleksey/lendo/pkg/envelope"
+	"github.com/ivanovaleksey/lendo/pkg/health"
+	"github.com/ivanovaleksey/lendo/pkg/logging"
+	"github.com/ivanovaleksey/lendo/pkg/metrics"
+	"github.com/ivanovaleksey/lendo/pkg/tracing"
 	"net/http"
 )
 
@@ -23,6 +28,7 @@ type API struct {
 	validator *validator.Validate
 
 	applicationsSrv ApplicationsService
+	health          *health.Health
 }
 
 func New(cfg config.Config, opts ...Option) *API {
@@ -39,18 +45,30 @@ func New(cfg config.Config, opts ...Option) *API {
 
 func (api *API) initRouter() {
 	router := chi.NewRouter()
+	router.Use(metrics.Middleware("api"))
+	router.Use(tracing.Middleware("api"))
+	router.Use(envelope.Middleware)
+	router.Use(logging.Middleware("api"))
+
+	router.Handle("/metrics", metrics.Handler())
+	if api.health != nil {
+		router.Get("/healthz", api.health.LivenessHandler())
+		router.Get("/readyz", api.health.ReadinessHandler())
+	}
 
 	router.Route("/docs", func(r chi.Router) {
 		r.Handle("/*", http.StripPrefix("/docs", http.FileServer(http.Dir("api/docs"))))
 	})
 
-	router.Route("/api", func(r chi.Router) {
-		r.Route("/applications", func(r chi.Router) {
-			r.Get("/", api.GetApplications())
-			r.Get("/{id}", api.GetApplication())
-			r.Post("/", api.CreateApplication())
+	if api.applicationsSrv != nil {
+		router.Route("/api", func(r chi.Router) {
+			r.Route("/applications", func(r chi.Router) {
+				r.Get("/", api.GetApplications())
+				r.Get("/{id}", api.GetApplication())
+				r.Post("/", api.CreateApplication())
+			})
 		})
-	})
+	}
 	api.router = router
 }
 
@@ -2,6 +2,7 @@ package app
 
 import (
 	"context"
+	"crypto/subtle"
 	"encoding/json"
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/render"
@@ -9,15 +10,17 @@ import (
 	"github.com/ivanovaleksey/lendo/api/responses"
 	"github.com/ivanovaleksey/lendo/api/services/applications"
 	"github.com/ivanovaleksey/lendo/pkg/models"
+	"github.com/pkg/errors"
 	uuid "github.com/satori/go.uuid"
 	"net/http"
 	"strconv"
+	"strings"
 )
 
 type ApplicationsService interface {
 	GetList(ctx context.Context, params applicationsSrv.GetListParams) ([]models.Application, int, error)
 	GetByID(ctx context.Context, id uuid.UUID) (models.Application, error)
-	Create(ctx context.Context, item models.NewApplication) (uuid.UUID, error)
+	Create(ctx context.Context, item models.NewApplication, idempotencyKey string) (uuid.UUID, error)
 }
 
 // swagger:parameters getApplications
@@ -102,6 +105,14 @@ func (api *API) GetApplication() http.HandlerFunc {
 		}
 
 		application, err := api.applicationsSrv.GetByID(ctx, id)
+		switch {
+		case err == applicationsSrv.ErrApplicationNotFound:
+			render.Render(w, r, responses.ErrNotFound(err))
+			return
+		case err != nil:
+			render.Render(w, r, responses.ErrInternal(err))
+			return
+		}
 
 		resp := responses.GetApplicationResponse{Application: application}
 		render.Render(w, r, resp)
@@ -111,6 +122,17 @@ func (api *API) GetApplication() http.HandlerFunc {
 
 // swagger:parameters createApplication
 type CreateApplicationParams struct {
+	// Channel or partner submitting the application, jobs are scheduled fairly between sources.
+	// It's ignored unless the request carries a trusted token in the Authorization header.
+	// in: header
+	Source string `json:"Lendo-Source"`
+	// Overrides the priority configured for the source, it's ignored unless the request carries a trusted token.
+	// in: header
+	Priority int `json:"Lendo-Priority"`
+	// Makes the request safe to retry: a request with the key of a created application returns its ID,
+	// the key reused with another application is rejected with 422.
+	// in: header
+	IdempotencyKey string `json:"Idempotency-Key"`
 	// in: body
 	Body models.NewApplication
 }
@@ -128,8 +150,17 @@ func (api *API) CreateApplication() http.HandlerFunc {
 
 		ctx := r.Context()
 
-		var params CreateApplicationParams
-		err := json.NewDecoder(r.Body).Decode(&params.Body)
+		md, err := api.extractMetadata(r)
+		if err != nil {
+			render.Render(w, r, responses.ErrBadRequest(err))
+			return
+		}
+		ctx = models.ContextWithMetadata(ctx, md)
+
+		params := CreateApplicationParams{
+			IdempotencyKey: r.Header.Get(apiModels.IdempotencyKeyHeader),
+		}
+		err = json.NewDecoder(r.Body).Decode(&params.Body)
 		if err != nil {
 			render.Render(w, r, responses.ErrBadRequest(err))
 			return
@@ -141,10 +172,44 @@ func (api *API) CreateApplication() http.HandlerFunc {
 			return
 		}
 
-		id, err := api.applicationsSrv.Create(ctx, params.Body)
+		id, err := api.applicationsSrv.Create(ctx, params.Body, params.IdempotencyKey)
+		switch {
+		case errors.Cause(err) == applicationsSrv.ErrIdempotencyKeyReused:
+			render.Render(w, r, responses.ErrUnprocessableEntity(err))
+			return
+		case err != nil:
+			render.Render(w, r, responses.ErrInternal(err))
+			return
+		}
 
 		resp := responses.CreateApplicationResponse{ID: id}
 		render.Render(w, r, resp)
 		return
 	}
 }
+
+// extractMetadata reads the source and the priority of trusted callers only, so that a public caller
+// can't jump the queue.
+func (api *API) extractMetadata(r *http.Request) (models.Metadata, error) {
+	if !api.isTrusted(r) {
+		return models.Metadata{}, nil
+	}
+	return models.ExtractMetadata(r.Header)
+}
+
+// isTrusted tells whether the request carries one of the trusted tokens as "Authorization: Bearer <token>".
+func (api *API) isTrusted(r *http.Request) bool {
+	const prefix = "Bearer "
+
+	header := r.Header.Get("Authorization")
+	token := strings.TrimPrefix(header, prefix)
+	if header == token {
+		return false
+	}
+	for _, trusted := range api.cfg.TrustedTokens {
+		if subtle.ConstantTimeCompare([]byte(token), []byte(trusted)) == 1 {
+			return true
+		}
+	}
+	return false
+}
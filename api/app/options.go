@@ -1,5 +1,9 @@
 package app
 
+import (
+	"github.com/ivanovaleksey/lendo/pkg/health"
+)
+
 type Option func(*API)
 
 func WithApplicationsSrv(srv ApplicationsService) Option {
@@ -7,3 +11,9 @@ func WithApplicationsSrv(srv ApplicationsService) Option {
 		api.applicationsSrv = srv
 	}
 }
+
+func WithHealth(h *health.Health) Option {
+	return func(api *API) {
+		api.health = h
+	}
+}
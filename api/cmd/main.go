@@ -4,132 +4,149 @@ package main
 
 import (
 	"context"
-	"github.com/ivanovaleksey/lendo/api/app"
 	"github.com/ivanovaleksey/lendo/api/config"
-	"github.com/ivanovaleksey/lendo/api/pubsub/applications"
-	"github.com/ivanovaleksey/lendo/api/repos/applications"
-	"github.com/ivanovaleksey/lendo/api/services/applications"
+	"github.com/ivanovaleksey/lendo/api/migrations"
+	"github.com/ivanovaleksey/lendo/api/server"
+	"github.com/ivanovaleksey/lendo/pkg/cli"
 	"github.com/ivanovaleksey/lendo/pkg/closer"
 	"github.com/ivanovaleksey/lendo/pkg/component"
 	"github.com/ivanovaleksey/lendo/pkg/db"
+	"github.com/ivanovaleksey/lendo/pkg/health"
+	"github.com/ivanovaleksey/lendo/pkg/logging"
+	"github.com/ivanovaleksey/lendo/pkg/migrate"
 	"github.com/ivanovaleksey/lendo/pkg/nats"
+	"github.com/ivanovaleksey/lendo/pkg/tracing"
 	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
 	log "github.com/sirupsen/logrus"
-	"net/http"
+	"github.com/spf13/cobra"
+	"os"
 	"syscall"
-	"time"
-)
-
-const (
-	defaultReadTimeout  = 5 * time.Second
-	defaultWriteTimeout = 5 * time.Second
 )
 
 func main() {
-	log.SetLevel(log.DebugLevel)
-
-	ctx := context.Background()
-
-	cfg, err := config.New()
-	if err != nil {
+	if err := newRootCmd().ExecuteContext(context.Background()); err != nil {
 		log.Fatal(err)
 	}
+}
+
+func newRootCmd() *cobra.Command {
+	var cfg config.Config
+	serve := func(role server.Role) func(cmd *cobra.Command) error {
+		return func(cmd *cobra.Command) error {
+			if err := cli.LoadConfig(cmd, &cfg); err != nil {
+				return err
+			}
+			return runApps(cmd.Context(), cfg, role)
+		}
+	}
 
-	if err := runApps(ctx, cfg); err != nil {
-		log.Error(err)
+	root := cli.NewRoot("api", &cfg, serve(server.RoleAll))
+	root.AddCommand(
+		cli.NewServeCommand("serve", "Serve the API and consume status changes", &cfg, serve(server.RoleAll)),
+		cli.NewServeCommand("consume-only", "Consume status changes without serving the API", &cfg, serve(server.RoleConsumer)),
+		newMigrateCmd(),
+		cli.NewConfigCommand(&cfg),
+		cli.NewVersionCommand(),
+	)
+	return root
+}
+
+func newMigrateCmd() *cobra.Command {
+	var cfg config.MigrateConfig
+	cmd := &cobra.Command{
+		Use:   migrate.Usage,
+		Short: "Apply or roll back the schema migrations",
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := cli.LoadConfig(cmd, &cfg); err != nil {
+				return err
+			}
+			return runMigrate(cmd.Context(), cfg, args)
+		},
 	}
+	cli.BindFlags(cmd.Flags(), &cfg)
+	return cmd
 }
 
-func runApps(ctx context.Context, cfg config.Config) error {
+func runApps(ctx context.Context, cfg config.Config, role server.Role) error {
+	logging.Init(cfg.Log)
+
 	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
 
+	tracer, err := tracing.Init(ctx, "api", cfg.Tracing)
+	if err != nil {
+		return errors.Wrap(err, "can't init tracing")
+	}
+
 	db, err := db.New(cfg.DB)
 	if err != nil {
 		return errors.Wrap(err, "can't create db")
 	}
 
+	prometheus.MustRegister(db.Collector("api"))
+
 	natsClient, err := nats.New(cfg.NATS)
 	if err != nil {
 		return errors.Wrap(err, "can't create nats client")
 	}
 
-	repo := applicationsRepo.New(db)
+	h := health.New()
+	h.AddLiveness(natsClient)
+	h.AddReadiness(db)
 
-	var opts []app.Option
-	{
-		pub := applicationsPubSub.NewPub(natsClient)
-		srv := applicationsSrv.New(repo, pub)
-		opts = append(opts, app.WithApplicationsSrv(srv))
-	}
-
-	srv := http.Server{
-		Addr:         cfg.Addr,
-		Handler:      app.New(cfg, opts...),
-		ReadTimeout:  defaultReadTimeout,
-		WriteTimeout: defaultWriteTimeout,
-	}
-
-	appCloser := closer.New(syscall.SIGTERM, syscall.SIGINT)
-	appCloser.Add(func() error {
+	appCloser := closer.New(closer.WithSignals(syscall.SIGTERM, syscall.SIGINT))
+	appCloser.Add(closer.PhaseIngress, func() error {
+		h.Shutdown()
+		return nil
+	})
+	appCloser.Add(closer.PhaseWorkers, func() error {
 		cancel()
 		return nil
 	})
 
-	{
-		handler := applicationsPubSub.NewApplicationStatusChangedHandler(repo)
+	supervisor := component.NewSupervisor(appCloser)
+	h.AddLiveness(supervisor)
 
-		opts := []nats.ConsumerOption{
-			nats.WithClient(natsClient),
-			nats.WithQueue("api"),
-			nats.WithSubject("applications.changed"),
-			nats.WithHandler(handler),
-			nats.WithComponentName("consumer.applications.changed"),
-		}
-		closure := component.Run(ctx, nats.NewConsumer(opts...))
-		appCloser.Add(closure)
+	err = server.Start(ctx, cfg, server.Infra{
+		DB:         db,
+		Bus:        nats.NewBus(natsClient),
+		Closer:     appCloser,
+		Supervisor: supervisor,
+		Health:     h,
+	}, server.WithRole(role))
+	if err != nil {
+		return err
 	}
 
-	appCloser.Add(func() error {
-		return closeSrv(&srv)
+	appCloser.Add(closer.PhasePublishers, func() error {
+		return component.Close(natsClient, 0)
 	})
-	appCloser.Add(func() error {
-		return component.Close(natsClient, component.CloseDelay)
+	appCloser.Add(closer.PhaseInfra, func() error {
+		return component.Close(db, 0)
 	})
-	appCloser.Add(func() error {
-		return component.Close(db, component.CloseDelay)
+	appCloser.Add(closer.PhaseInfra, func() error {
+		return component.Close(tracer, 0)
 	})
 
-	go func() {
-		log.Debugf("starting server on %s", cfg.Addr)
-		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			log.Errorf("server error: %v", err)
-			appCloser.CloseAll()
-		}
-	}()
-
 	appCloser.Wait()
 	return nil
 }
 
-func closeSrv(srv *http.Server) error {
-	const (
-		gracefulDelay   = 3 * time.Second
-		gracefulTimeout = 5 * time.Second
-	)
-
-	ctx, cancel := context.WithTimeout(context.Background(), gracefulTimeout)
-	defer cancel()
+// runMigrate runs the migrate subcommand, it only needs the DB settings.
+func runMigrate(ctx context.Context, cfg config.MigrateConfig, args []string) error {
+	logging.Init(cfg.Log)
 
-	// waiting for k8s to stop traffic
-	log.Info("waiting for graceful delay")
-	time.Sleep(gracefulDelay)
+	database, err := db.New(cfg.DB)
+	if err != nil {
+		return errors.Wrap(err, "can't create db")
+	}
+	defer database.Close()
 
-	log.Info("shutting down")
-	srv.SetKeepAlivesEnabled(false)
-	if err := srv.Shutdown(ctx); err != nil {
-		return errors.Wrap(err, "shutdown error")
+	migrator, err := migrations.New(database, cfg.Migrate)
+	if err != nil {
+		return errors.Wrap(err, "can't create migrator")
 	}
-	log.Info("shutdown gracefully")
-	return nil
+	return migrate.Command(ctx, migrator, args, os.Stdout)
 }
@@ -2,14 +2,25 @@ package config
 
 import (
 	"github.com/ivanovaleksey/lendo/pkg/db"
+	"github.com/ivanovaleksey/lendo/pkg/envelope"
+	"github.com/ivanovaleksey/lendo/pkg/logging"
+	"github.com/ivanovaleksey/lendo/pkg/migrate"
 	"github.com/ivanovaleksey/lendo/pkg/nats"
+	"github.com/ivanovaleksey/lendo/pkg/tracing"
 	"github.com/kelseyhightower/envconfig"
 )
 
 type Config struct {
-	Addr string      `required:"true"`
-	DB   db.Config   `envconfig:"db"`
-	NATS nats.Config `envconfig:"nats"`
+	Addr    string          `required:"true"`
+	DB      db.Config       `envconfig:"db"`
+	Events  envelope.Config `envconfig:"events"`
+	Log     logging.Config  `envconfig:"log"`
+	Migrate migrate.Config  `envconfig:"migrate"`
+	NATS    nats.Config     `envconfig:"nats"`
+	Tracing tracing.Config  `envconfig:"tracing"`
+	// TrustedTokens authenticate callers, e.g. partner gateways and operators, which may set the source
+	// and the priority of applications. Lendo-Source and Lendo-Priority headers of other callers are ignored.
+	TrustedTokens []string `envconfig:"trusted_tokens"`
 }
 
 func New() (Config, error) {
@@ -20,3 +31,10 @@ func New() (Config, error) {
 	}
 	return cfg, err
 }
+
+// MigrateConfig is what the migrate subcommand needs, so that it runs without the rest of the settings.
+type MigrateConfig struct {
+	DB      db.Config      `envconfig:"db"`
+	Log     logging.Config `envconfig:"log"`
+	Migrate migrate.Config `envconfig:"migrate"`
+}
@@ -0,0 +1,4 @@
+DROP INDEX applications_idempotency_key_key;
+
+ALTER TABLE applications DROP COLUMN idempotency_hash;
+ALTER TABLE applications DROP COLUMN idempotency_key;
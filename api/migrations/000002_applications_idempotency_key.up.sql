@@ -0,0 +1,5 @@
+ALTER TABLE applications ADD COLUMN idempotency_key TEXT;
+-- a hash of the request which created the application, a key reused with another request is rejected
+ALTER TABLE applications ADD COLUMN idempotency_hash TEXT;
+
+CREATE UNIQUE INDEX applications_idempotency_key_key ON applications USING btree (idempotency_key);
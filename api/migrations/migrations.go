@@ -0,0 +1,16 @@
+// Package migrations embeds the api schema migrations into the binary.
+package migrations
+
+import (
+	"embed"
+	"github.com/ivanovaleksey/lendo/pkg/db"
+	"github.com/ivanovaleksey/lendo/pkg/migrate"
+)
+
+//go:embed *.sql shared/*.sql
+var FS embed.FS
+
+// New returns a migrator of the api schema.
+func New(database *db.DB, cfg migrate.Config) (*migrate.Migrator, error) {
+	return migrate.New(database, FS, migrate.WithTable(cfg.Table), migrate.WithShared(cfg.Shared))
+}
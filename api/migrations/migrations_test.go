@@ -0,0 +1,24 @@
+package migrations
+
+import (
+	"github.com/ivanovaleksey/lendo/pkg/migrate"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestFS(t *testing.T) {
+	migrations, err := migrate.Parse(FS)
+	require.NoError(t, err)
+	require.NotEmpty(t, migrations)
+
+	for i, m := range migrations {
+		assert.EqualValues(t, i+1, m.Version, "versions must go one by one")
+		assert.NotEmpty(t, m.Down, "migration %d_%s has no down file", m.Version, m.Name)
+	}
+}
+
+func TestNew(t *testing.T) {
+	_, err := New(nil, migrate.Config{Shared: true})
+	assert.NoError(t, err, "shared migrations must match the migrations")
+}
@@ -0,0 +1,4 @@
+package models
+
+// IdempotencyKeyHeader identifies a create request, so that it may be retried without creating a duplicate.
+const IdempotencyKeyHeader = "Idempotency-Key"
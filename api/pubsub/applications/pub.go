@@ -2,21 +2,28 @@ package applicationsPubSub
 
 import (
 	"context"
-	"encoding/json"
+	"github.com/ivanovaleksey/lendo/pkg/bus"
+	"github.com/ivanovaleksey/lendo/pkg/envelope"
 	"github.com/ivanovaleksey/lendo/pkg/models"
+	"github.com/ivanovaleksey/lendo/pkg/pb"
+	"github.com/ivanovaleksey/lendo/pkg/tracing"
+	"net/http"
 )
 
 type Pub struct {
-	client PubClient
+	client  PubClient
+	encoder *envelope.Encoder
 }
 
 type PubClient interface {
-	Publish(subj string, data []byte) error
+	PublishMsg(msg *bus.Msg) error
 }
 
-func NewPub(client PubClient) *Pub {
+// NewPub returns a publisher of events in the JSON format unless another format is given.
+func NewPub(client PubClient, opts ...envelope.EncoderOption) *Pub {
 	pub := &Pub{
-		client: client,
+		client:  client,
+		encoder: envelope.NewEncoder("api", opts...),
 	}
 	return pub
 }
@@ -24,10 +31,27 @@ func NewPub(client PubClient) *Pub {
 func (p *Pub) NewApplication(ctx context.Context, application models.Application) error {
 	const subject = "applications.new"
 
-	data, err := json.Marshal(application)
+	header := make(http.Header)
+	data, err := p.encoder.Encode(ctx, header, models.EventApplicationNew, models.EventApplicationNewVersion, pb.FromApplication(application))
 	if err != nil {
 		return err
 	}
 
-	return p.client.Publish(subject, data)
+	return p.publish(ctx, subject, header, data)
+}
+
+// publish sends data carrying the trace context and the application metadata of ctx in message headers.
+func (p *Pub) publish(ctx context.Context, subject string, header http.Header, data []byte) error {
+	msg := &bus.Msg{
+		Subject: subject,
+		Data:    data,
+		Header:  header,
+	}
+	if md, ok := models.MetadataFromContext(ctx); ok {
+		md.Inject(msg.Header)
+	}
+	_, span := tracing.StartPublish(ctx, msg.Subject, msg.Header)
+	err := p.client.PublishMsg(msg)
+	tracing.End(span, err)
+	return err
 }
@@ -6,9 +6,15 @@ import (
 	"errors"
 	"github.com/brianvoe/gofakeit"
 	"github.com/ivanovaleksey/lendo/api/pubsub/applications/mocks"
+	"github.com/ivanovaleksey/lendo/pkg/bus"
+	"github.com/ivanovaleksey/lendo/pkg/envelope"
 	"github.com/ivanovaleksey/lendo/pkg/models"
+	"github.com/ivanovaleksey/lendo/pkg/tracing"
 	uuid "github.com/satori/go.uuid"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"net/http"
 	"testing"
 )
 
@@ -21,13 +27,12 @@ func TestPub_NewApplication(t *testing.T) {
 			LastName:  gofakeit.LastName(),
 		},
 	}
-	data, _ := json.Marshal(application)
 
 	t.Run("without error", func(t *testing.T) {
 		fx := newFixture(t)
 		defer fx.Finish()
 
-		fx.nats.On("Publish", "applications.new", data).Return(nil)
+		fx.nats.On("PublishMsg", message("applications.new", application)).Return(nil)
 
 		err := fx.pub.NewApplication(fx.ctx, application)
 
@@ -39,12 +44,61 @@ func TestPub_NewApplication(t *testing.T) {
 		defer fx.Finish()
 
 		clientErr := errors.New(gofakeit.Sentence(3))
-		fx.nats.On("Publish", "applications.new", data).Return(clientErr)
+		fx.nats.On("PublishMsg", message("applications.new", application)).Return(clientErr)
 
 		err := fx.pub.NewApplication(fx.ctx, application)
 
 		assert.Equal(t, clientErr, err)
 	})
+
+	t.Run("should propagate trace context", func(t *testing.T) {
+		fx := newFixture(t)
+		defer fx.Finish()
+
+		exporter := tracing.NewTestProvider()
+		ctx, span := tracing.Tracer().Start(fx.ctx, "test")
+		defer span.End()
+
+		var header string
+		fx.nats.On("PublishMsg", message("applications.new", application)).
+			Run(func(args mock.Arguments) {
+				header = args.Get(0).(*bus.Msg).Header.Get("traceparent")
+			}).
+			Return(nil)
+
+		err := fx.pub.NewApplication(ctx, application)
+
+		assert.NoError(t, err)
+		assert.Contains(t, header, span.SpanContext().TraceID().String())
+		spans := exporter.GetSpans()
+		if assert.Len(t, spans, 1) {
+			assert.Equal(t, "applications.new send", spans[0].Name)
+			assert.Equal(t, span.SpanContext().TraceID(), spans[0].SpanContext.TraceID())
+		}
+	})
+
+	t.Run("should pass metadata", func(t *testing.T) {
+		fx := newFixture(t)
+		defer fx.Finish()
+
+		priority := 10
+		md := models.Metadata{Source: gofakeit.Word(), Priority: &priority}
+		ctx := models.ContextWithMetadata(fx.ctx, md)
+
+		var header http.Header
+		fx.nats.On("PublishMsg", message("applications.new", application)).
+			Run(func(args mock.Arguments) {
+				header = args.Get(0).(*bus.Msg).Header
+			}).
+			Return(nil)
+
+		err := fx.pub.NewApplication(ctx, application)
+
+		require.NoError(t, err)
+		got, err := models.ExtractMetadata(header)
+		require.NoError(t, err)
+		assert.Equal(t, md, got)
+	})
 }
 
 type fixture struct {
@@ -68,3 +122,20 @@ func newFixture(t *testing.T) *fixture {
 func (fx *fixture) Finish() {
 	fx.nats.AssertExpectations(fx.t)
 }
+
+// message matches an enveloped message of the subject carrying the data.
+func message(subject string, data interface{}) interface{} {
+	expected, _ := json.Marshal(data)
+	return mock.MatchedBy(func(msg *bus.Msg) bool {
+		var env envelope.Envelope
+		if err := json.Unmarshal(msg.Data, &env); err != nil {
+			return false
+		}
+		var got, want interface{}
+		_ = json.Unmarshal(env.Data, &got)
+		_ = json.Unmarshal(expected, &want)
+		return msg.Subject == subject &&
+			msg.Header.Get("Content-Type") == envelope.ContentType &&
+			assert.ObjectsAreEqual(want, got)
+	})
+}
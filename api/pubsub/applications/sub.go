@@ -2,16 +2,18 @@ package applicationsPubSub
 
 import (
 	"context"
-	"encoding/json"
+	"github.com/ivanovaleksey/lendo/pkg/bus"
+	"github.com/ivanovaleksey/lendo/pkg/envelope"
 	commonModels "github.com/ivanovaleksey/lendo/pkg/models"
-	"github.com/nats-io/nats.go"
+	"github.com/ivanovaleksey/lendo/pkg/pb"
 	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
 )
 
 type ApplicationStatusChangedHandler struct {
-	repo   Repo
-	logger log.FieldLogger
+	repo    Repo
+	decoder *envelope.Decoder
+	logger  log.FieldLogger
 }
 
 type Repo interface {
@@ -20,29 +22,33 @@ type Repo interface {
 
 func NewApplicationStatusChangedHandler(repo Repo) *ApplicationStatusChangedHandler {
 	h := &ApplicationStatusChangedHandler{
-		repo:   repo,
-		logger: log.WithField("handler", "applications-changed"),
+		repo:    repo,
+		decoder: envelope.NewDecoder(commonModels.EventApplicationStatusChanged, commonModels.EventApplicationStatusChangedVersion),
+		logger:  log.WithField("handler", "applications-changed"),
 	}
 	return h
 }
 
-func (h *ApplicationStatusChangedHandler) Handle(msg *nats.Msg) {
-	h.logger.Debugf("status changed %s", string(msg.Data))
-
-	var change commonModels.StatusChange
-	err := json.Unmarshal(msg.Data, &change)
+func (h *ApplicationStatusChangedHandler) Handle(ctx context.Context, msg *bus.Msg) error {
+	var data pb.StatusChange
+	env, err := h.decoder.Decode(msg.Header, msg.Data, &data)
+	if err != nil {
+		return errors.Wrap(err, "can't parse message")
+	}
+	change, err := data.ToModel()
 	if err != nil {
-		err = errors.Wrap(err, "can't parse message")
-		h.logger.Error(err)
-		return
+		return errors.Wrap(err, "can't parse message")
 	}
 
-	err = h.repo.UpdateStatus(context.Background(), change)
+	err = h.repo.UpdateStatus(ctx, change)
 	if err != nil {
-		err = errors.Wrap(err, "can't update status")
-		h.logger.Error(err)
-		return
+		return errors.Wrap(err, "can't update status")
 	}
 
-	h.logger.Debugf("status changed %s", change.ID.String())
+	h.logger.WithFields(log.Fields{
+		"correlation_id": env.CorrelationID,
+		"application_id": change.ID.String(),
+		"status":         change.Status,
+	}).Debug("status changed")
+	return nil
 }
@@ -9,6 +9,7 @@ import (
 	"github.com/ivanovaleksey/lendo/pkg/models"
 	"github.com/pkg/errors"
 	uuid "github.com/satori/go.uuid"
+	"time"
 )
 
 const (
@@ -17,6 +18,9 @@ const (
 
 var (
 	ErrNotFound = errors.New("application not found")
+	// ErrIdempotencyKeyReused is returned when an idempotency key is passed along with another request
+	// than the one which created the application.
+	ErrIdempotencyKeyReused = errors.New("idempotency key is reused with another request")
 )
 
 type Repo struct {
@@ -80,6 +84,27 @@ func (impl *Repo) GetList(ctx context.Context, params GetListParams) ([]models.A
 	return items, total, nil
 }
 
+// GetUnfinished returns applications which are not completed or rejected and were created before the given time.
+// Applications are ordered by ID, so the next batch is requested with the last ID of the previous one.
+func (impl *Repo) GetUnfinished(ctx context.Context, createdBefore time.Time, after uuid.UUID, limit int) ([]models.Application, error) {
+	const query = `
+		SELECT id, first_name, last_name, status
+		FROM ` + tableName + `
+		WHERE status IN ($1, $2) AND created_at < $3 AND id > $4
+		ORDER BY id
+		LIMIT $5
+	`
+
+	items := make([]models.Application, 0)
+	err := impl.db.SelectContext(ctx, &items, query,
+		models.ApplicationStatusNew, models.ApplicationStatusPending, createdBefore, after, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 func (impl *Repo) GetByID(ctx context.Context, id uuid.UUID) (models.Application, error) {
 	const query = `
 		SELECT row_to_json(t)
@@ -98,20 +123,40 @@ func (impl *Repo) GetByID(ctx context.Context, id uuid.UUID) (models.Application
 	return item, nil
 }
 
-func (impl *Repo) Create(ctx context.Context, item models.Application) (uuid.UUID, error) {
+// Create creates the application. An application created with the same non-empty idempotency key is not
+// created again: its ID is returned and created is false, unless requestHash differs from the hash of the request
+// which created it, then it's ErrIdempotencyKeyReused.
+func (impl *Repo) Create(ctx context.Context, item models.Application, idempotencyKey, requestHash string) (id uuid.UUID, created bool, err error) {
 	const query = `
-		INSERT INTO ` + tableName + ` (first_name, last_name, status)
-		VALUES ($1, $2, $3)
-		RETURNING ID
+		WITH inserted AS (
+			INSERT INTO ` + tableName + ` (first_name, last_name, status, idempotency_key, idempotency_hash)
+			VALUES ($1, $2, $3, NULLIF($4, ''), NULLIF($5, ''))
+			ON CONFLICT (idempotency_key) DO NOTHING
+			RETURNING id, idempotency_hash
+		)
+		SELECT id, true AS created, coalesce(idempotency_hash, '') AS idempotency_hash FROM inserted
+		UNION ALL
+		SELECT id, false AS created, coalesce(idempotency_hash, '') AS idempotency_hash FROM ` + tableName + `
+		WHERE idempotency_key = $4 AND NOT EXISTS (SELECT FROM inserted)
 	`
 
-	var id uuid.UUID
-	err := impl.db.GetContext(ctx, &id, query, item.FirstName, item.LastName, item.Status)
+	var row struct {
+		ID      uuid.UUID `db:"id"`
+		Created bool      `db:"created"`
+		Hash    string    `db:"idempotency_hash"`
+	}
+	err = impl.db.GetContext(ctx, &row, query, item.FirstName, item.LastName, item.Status, idempotencyKey, requestHash)
+	if err == sql.ErrNoRows {
+		// the conflicting application is committed after the statement has started, it's seen on a retry
+		return uuid.Nil, false, errors.New("application is being created concurrently")
+	}
 	if err != nil {
-		return uuid.Nil, err
+		return uuid.Nil, false, err
 	}
-
-	return id, nil
+	if !row.Created && row.Hash != requestHash {
+		return uuid.Nil, false, ErrIdempotencyKeyReused
+	}
+	return row.ID, row.Created, nil
 }
 
 func (impl *Repo) UpdateStatus(ctx context.Context, change models.StatusChange) error {
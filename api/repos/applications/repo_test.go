@@ -12,6 +12,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"math/rand"
+	"sort"
 	"testing"
 	"time"
 )
@@ -130,6 +131,58 @@ func TestImpl_GetByID(t *testing.T) {
 	})
 }
 
+func TestImpl_GetUnfinished(t *testing.T) {
+	t.Run("should return new and pending applications", func(t *testing.T) {
+		fx := newFixture(t)
+		defer fx.Finish()
+
+		applications := []models.Application{
+			fx.createApplicationWithStatus(models.ApplicationStatusNew),
+			fx.createApplicationWithStatus(models.ApplicationStatusPending),
+		}
+		fx.createApplicationWithStatus(models.ApplicationStatusCompleted)
+		fx.createApplicationWithStatus(models.ApplicationStatusRejected)
+		sort.Slice(applications, func(i, j int) bool {
+			return applications[i].ID.String() < applications[j].ID.String()
+		})
+
+		list, err := fx.repo.GetUnfinished(fx.ctx, time.Now().Add(time.Minute), uuid.Nil, 10)
+
+		require.NoError(t, err)
+		assert.Equal(t, applications, list)
+	})
+
+	t.Run("should return next batch", func(t *testing.T) {
+		fx := newFixture(t)
+		defer fx.Finish()
+
+		applications := []models.Application{
+			fx.createApplicationWithStatus(models.ApplicationStatusNew),
+			fx.createApplicationWithStatus(models.ApplicationStatusNew),
+		}
+		sort.Slice(applications, func(i, j int) bool {
+			return applications[i].ID.String() < applications[j].ID.String()
+		})
+
+		list, err := fx.repo.GetUnfinished(fx.ctx, time.Now().Add(time.Minute), applications[0].ID, 10)
+
+		require.NoError(t, err)
+		assert.Equal(t, applications[1:], list)
+	})
+
+	t.Run("should skip recent applications", func(t *testing.T) {
+		fx := newFixture(t)
+		defer fx.Finish()
+
+		fx.createApplicationWithStatus(models.ApplicationStatusNew)
+
+		list, err := fx.repo.GetUnfinished(fx.ctx, time.Now().Add(-time.Minute), uuid.Nil, 10)
+
+		require.NoError(t, err)
+		assert.Empty(t, list)
+	})
+}
+
 func TestImpl_Create(t *testing.T) {
 	fx := newFixture(t)
 	defer fx.Finish()
@@ -142,14 +195,81 @@ func TestImpl_Create(t *testing.T) {
 		Status: randomStatus(),
 	}
 
-	id, err := fx.repo.Create(fx.ctx, item)
+	id, created, err := fx.repo.Create(fx.ctx, item, "", "")
 
 	require.NoError(t, err)
+	assert.True(t, created)
 	item.ID = id
 	application := fx.getApplication(id)
 	assert.Equal(t, item, application)
 }
 
+func TestImpl_Create_IdempotencyKey(t *testing.T) {
+	t.Run("should not create application with the same key", func(t *testing.T) {
+		fx := newFixture(t)
+		defer fx.Finish()
+
+		item := models.Application{
+			NewApplication: models.NewApplication{
+				FirstName: gofakeit.FirstName(),
+				LastName:  gofakeit.LastName(),
+			},
+			Status: randomStatus(),
+		}
+		key := uuid.NewV4().String()
+
+		id, created, err := fx.repo.Create(fx.ctx, item, key, "hash")
+		require.NoError(t, err)
+		assert.True(t, created)
+
+		dupID, created, err := fx.repo.Create(fx.ctx, item, key, "hash")
+		require.NoError(t, err)
+		assert.False(t, created)
+		assert.Equal(t, id, dupID)
+	})
+
+	t.Run("should reject the key reused with another request", func(t *testing.T) {
+		fx := newFixture(t)
+		defer fx.Finish()
+
+		item := models.Application{
+			NewApplication: models.NewApplication{
+				FirstName: gofakeit.FirstName(),
+				LastName:  gofakeit.LastName(),
+			},
+			Status: randomStatus(),
+		}
+		key := uuid.NewV4().String()
+
+		_, _, err := fx.repo.Create(fx.ctx, item, key, "hash")
+		require.NoError(t, err)
+
+		_, created, err := fx.repo.Create(fx.ctx, item, key, "other hash")
+		assert.Equal(t, ErrIdempotencyKeyReused, err)
+		assert.False(t, created)
+	})
+
+	t.Run("should create applications without key", func(t *testing.T) {
+		fx := newFixture(t)
+		defer fx.Finish()
+
+		item := models.Application{
+			NewApplication: models.NewApplication{
+				FirstName: gofakeit.FirstName(),
+				LastName:  gofakeit.LastName(),
+			},
+			Status: randomStatus(),
+		}
+
+		id, _, err := fx.repo.Create(fx.ctx, item, "", "")
+		require.NoError(t, err)
+		otherID, created, err := fx.repo.Create(fx.ctx, item, "", "")
+		require.NoError(t, err)
+		assert.True(t, created)
+		assert.NotEqual(t, id, otherID)
+	})
+}
+
 type fixture struct {
 	t   *testing.T
 	ctx context.Context
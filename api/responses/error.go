@@ -38,3 +38,19 @@ func ErrBadRequest(err error) render.Renderer {
 		Debug:    err.Error(),
 	}
 }
+
+func ErrNotFound(err error) render.Renderer {
+	return ErrorResponse{
+		HTTPCode: http.StatusNotFound,
+		Error:    "not found",
+		Debug:    err.Error(),
+	}
+}
+
+func ErrUnprocessableEntity(err error) render.Renderer {
+	return ErrorResponse{
+		HTTPCode: http.StatusUnprocessableEntity,
+		Error:    "unprocessable entity",
+		Debug:    err.Error(),
+	}
+}
@@ -0,0 +1,24 @@
+package server
+
+// Role selects the components a process runs, so that the consumer scales apart from the HTTP API.
+// The HTTP server with the health checks and the metrics runs in every role.
+type Role int
+
+const (
+	// RoleAll runs every component.
+	RoleAll Role = iota
+	// RoleConsumer runs the consumer of status changes without the API routes.
+	RoleConsumer
+)
+
+type Option func(*settings)
+
+type settings struct {
+	role Role
+}
+
+func WithRole(role Role) Option {
+	return func(s *settings) {
+		s.role = role
+	}
+}
@@ -0,0 +1,104 @@
+// Package server starts the api components on an infrastructure owned by the caller,
+// so that the api runs both as a service of its own and together with the registry in one process.
+package server
+
+import (
+	"context"
+	"github.com/ivanovaleksey/lendo/api/app"
+	"github.com/ivanovaleksey/lendo/api/config"
+	"github.com/ivanovaleksey/lendo/api/migrations"
+	"github.com/ivanovaleksey/lendo/api/pubsub/applications"
+	"github.com/ivanovaleksey/lendo/api/repos/applications"
+	"github.com/ivanovaleksey/lendo/api/services/applications"
+	"github.com/ivanovaleksey/lendo/pkg/bus"
+	"github.com/ivanovaleksey/lendo/pkg/closer"
+	"github.com/ivanovaleksey/lendo/pkg/component"
+	"github.com/ivanovaleksey/lendo/pkg/db"
+	"github.com/ivanovaleksey/lendo/pkg/envelope"
+	"github.com/ivanovaleksey/lendo/pkg/health"
+	"github.com/ivanovaleksey/lendo/pkg/migrate"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"net/http"
+	"time"
+)
+
+const (
+	defaultReadTimeout  = 5 * time.Second
+	defaultWriteTimeout = 5 * time.Second
+)
+
+// Infra is what the api components run on. Its closing is up to the caller.
+type Infra struct {
+	DB         *db.DB
+	Bus        bus.Bus
+	Closer     *closer.Closer
+	Supervisor *component.Supervisor
+	Health     *health.Health
+}
+
+// Start starts the HTTP server and the consumer of status changes, the closer stops them in the ingress phase.
+// The API routes are served unless the role is RoleConsumer. Nothing is started unless the schema is of the version
+// the api expects.
+func Start(ctx context.Context, cfg config.Config, infra Infra, opts ...Option) error {
+	var settings settings
+	for _, opt := range opts {
+		opt(&settings)
+	}
+
+	migrator, err := migrations.New(infra.DB, cfg.Migrate)
+	if err != nil {
+		return errors.Wrap(err, "can't create migrator")
+	}
+	if err := migrate.Ensure(ctx, migrator, cfg.Migrate); err != nil {
+		return err
+	}
+
+	repo := applicationsRepo.New(infra.DB)
+
+	appOpts := []app.Option{
+		app.WithHealth(infra.Health),
+	}
+	if settings.role != RoleConsumer {
+		pub := applicationsPubSub.NewPub(infra.Bus, envelope.WithFormat(cfg.Events.Format))
+		srv := applicationsSrv.New(repo, pub)
+		appOpts = append(appOpts, app.WithApplicationsSrv(srv))
+	}
+
+	srv := http.Server{
+		Addr:         cfg.Addr,
+		Handler:      app.New(cfg, appOpts...),
+		ReadTimeout:  defaultReadTimeout,
+		WriteTimeout: defaultWriteTimeout,
+	}
+
+	{
+		handler := applicationsPubSub.NewApplicationStatusChangedHandler(repo)
+
+		opts := []bus.ConsumerOption{
+			bus.WithBus(infra.Bus),
+			bus.WithQueue("api"),
+			bus.WithSubject("applications.changed"),
+			bus.WithHandler(handler),
+			bus.WithComponentName("consumer.applications.changed"),
+		}
+		consumer := bus.NewConsumer(opts...)
+		infra.Health.AddLiveness(consumer)
+
+		closure := infra.Supervisor.Run(ctx, consumer, component.WithRestartPolicy(component.RestartOnFailure))
+		infra.Closer.Add(closer.PhaseIngress, closure)
+	}
+
+	infra.Closer.Add(closer.PhaseIngress, func() error {
+		return component.ShutdownServer(&srv)
+	})
+
+	go func() {
+		log.Debugf("starting api server on %s", cfg.Addr)
+		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Errorf("api server error: %v", err)
+			infra.Closer.CloseAll()
+		}
+	}()
+	return nil
+}
@@ -2,7 +2,11 @@ package applicationsSrv
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	applicationsRepo "github.com/ivanovaleksey/lendo/api/repos/applications"
+	"github.com/ivanovaleksey/lendo/pkg/logging"
 	"github.com/ivanovaleksey/lendo/pkg/models"
 	"github.com/pkg/errors"
 	uuid "github.com/satori/go.uuid"
@@ -11,6 +15,11 @@ import (
 
 type GetListParams = applicationsRepo.GetListParams
 
+var (
+	ErrApplicationNotFound  = applicationsRepo.ErrNotFound
+	ErrIdempotencyKeyReused = applicationsRepo.ErrIdempotencyKeyReused
+)
+
 type Service struct {
 	repo     Repo
 	notifier Notifier
@@ -19,7 +28,7 @@ type Service struct {
 type Repo interface {
 	GetList(ctx context.Context, params GetListParams) ([]models.Application, int, error)
 	GetByID(ctx context.Context, id uuid.UUID) (models.Application, error)
-	Create(ctx context.Context, item models.Application) (uuid.UUID, error)
+	Create(ctx context.Context, item models.Application, idempotencyKey, requestHash string) (uuid.UUID, bool, error)
 }
 
 type Notifier interface {
@@ -42,22 +51,52 @@ func (srv *Service) GetByID(ctx context.Context, id uuid.UUID) (models.Applicati
 	return srv.repo.GetByID(ctx, id)
 }
 
-func (srv *Service) Create(ctx context.Context, item models.NewApplication) (uuid.UUID, error) {
+// Create creates the application and notifies the registry. A retried request with the same idempotency key
+// gets the ID of the application created by the first one, the registry is not notified again. The key reused
+// with another application or metadata is ErrIdempotencyKeyReused.
+func (srv *Service) Create(ctx context.Context, item models.NewApplication, idempotencyKey string) (uuid.UUID, error) {
 	application := models.Application{
 		NewApplication: item,
 		Status:         models.ApplicationStatusNew,
 	}
-	id, err := srv.repo.Create(ctx, application)
+	var requestHash string
+	if idempotencyKey != "" {
+		md, _ := models.MetadataFromContext(ctx)
+		hash, err := RequestHash(item, md)
+		if err != nil {
+			return uuid.Nil, err
+		}
+		requestHash = hash
+	}
+	id, created, err := srv.repo.Create(ctx, application, idempotencyKey, requestHash)
 	if err != nil {
 		return uuid.Nil, errors.Wrap(err, "can't create application")
 	}
+	if !created {
+		return id, nil
+	}
 
 	application.ID = id
-	log.Debugf("notify applications.new: %+v", application)
+	logger := logging.WithContext(ctx, log.StandardLogger()).WithFields(logging.ApplicationFields(application))
+	logger.Debug("notify applications.new")
 	err = srv.notifier.NewApplication(ctx, application)
 	if err != nil {
-		log.Error(errors.Wrap(err, "can't notify"))
+		logger.Error(errors.Wrap(err, "can't notify"))
 	}
 
 	return id, nil
 }
+
+// RequestHash identifies a create request by the application and its metadata.
+func RequestHash(item models.NewApplication, md models.Metadata) (string, error) {
+	data, err := json.Marshal(struct {
+		Application models.NewApplication `json:"application"`
+		Source      string                `json:"source"`
+		Priority    *int                  `json:"priority"`
+	}{item, md.Source, md.Priority})
+	if err != nil {
+		return "", errors.Wrap(err, "can't encode request")
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
@@ -1 +1,92 @@
 package applicationsSrv
+
+import (
+	"context"
+	"github.com/brianvoe/gofakeit"
+	"github.com/ivanovaleksey/lendo/pkg/models"
+	uuid "github.com/satori/go.uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestService_Create(t *testing.T) {
+	item := models.NewApplication{
+		FirstName: gofakeit.FirstName(),
+		LastName:  gofakeit.LastName(),
+	}
+
+	t.Run("should pass request hash with idempotency key", func(t *testing.T) {
+		repo := &fakeRepo{}
+		notifier := &fakeNotifier{}
+		srv := New(repo, notifier)
+
+		priority := 5
+		ctx := models.ContextWithMetadata(context.Background(), models.Metadata{Source: "web", Priority: &priority})
+		_, err := srv.Create(ctx, item, "key")
+		require.NoError(t, err)
+
+		expected, err := RequestHash(item, models.Metadata{Source: "web", Priority: &priority})
+		require.NoError(t, err)
+		assert.Equal(t, "key", repo.idempotencyKey)
+		assert.Equal(t, expected, repo.requestHash)
+		assert.Len(t, notifier.applications, 1)
+	})
+
+	t.Run("should not notify when application exists", func(t *testing.T) {
+		repo := &fakeRepo{exists: true}
+		notifier := &fakeNotifier{}
+		srv := New(repo, notifier)
+
+		_, err := srv.Create(context.Background(), item, "key")
+
+		require.NoError(t, err)
+		assert.Empty(t, notifier.applications)
+	})
+}
+
+func TestRequestHash(t *testing.T) {
+	item := models.NewApplication{FirstName: "John", LastName: "Doe"}
+	priority := 1
+
+	hash, err := RequestHash(item, models.Metadata{Source: "web"})
+	require.NoError(t, err)
+
+	same, err := RequestHash(item, models.Metadata{Source: "web"})
+	require.NoError(t, err)
+	assert.Equal(t, hash, same)
+
+	for _, c := range []struct {
+		item models.NewApplication
+		md   models.Metadata
+	}{
+		{models.NewApplication{FirstName: "John", LastName: "Smith"}, models.Metadata{Source: "web"}},
+		{item, models.Metadata{Source: "partner"}},
+		{item, models.Metadata{Source: "web", Priority: &priority}},
+	} {
+		other, err := RequestHash(c.item, c.md)
+		require.NoError(t, err)
+		assert.NotEqual(t, hash, other)
+	}
+}
+
+type fakeRepo struct {
+	Repo
+	exists         bool
+	idempotencyKey string
+	requestHash    string
+}
+
+func (repo *fakeRepo) Create(ctx context.Context, item models.Application, idempotencyKey, requestHash string) (uuid.UUID, bool, error) {
+	repo.idempotencyKey, repo.requestHash = idempotencyKey, requestHash
+	return uuid.NewV4(), !repo.exists, nil
+}
+
+type fakeNotifier struct {
+	applications []models.Application
+}
+
+func (n *fakeNotifier) NewApplication(ctx context.Context, application models.Application) error {
+	n.applications = append(n.applications, application)
+	return nil
+}
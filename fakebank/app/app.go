@@ -0,0 +1,130 @@
+// Package app implements an in-process fake of the bank partner API.
+//
+// It serves the same /api/applications and /api/jobs endpoints as the
+// lendoab/interview-service image. Applications stay pending for a delay
+// between Config.MinDelay and Config.MaxDelay and then become completed or
+// rejected. Both the delay and the outcome are derived from the applicant name
+// and Config.Seed, so runs are reproducible.
+package app
+
+import (
+	"github.com/go-chi/chi/v5"
+	"github.com/ivanovaleksey/lendo/fakebank/config"
+	"github.com/ivanovaleksey/lendo/pkg/models"
+	uuid "github.com/satori/go.uuid"
+	"hash/fnv"
+	"math/rand"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+type App struct {
+	cfg    config.Config
+	router chi.Router
+	now    func() time.Time
+
+	mu           sync.Mutex
+	applications map[uuid.UUID]*application
+	outcomes     map[string]models.ApplicationStatus
+	faults       []*fault
+	rnd          *rand.Rand
+}
+
+type application struct {
+	ID        uuid.UUID
+	JobID     uuid.UUID
+	FirstName string
+	LastName  string
+	Outcome   models.ApplicationStatus
+	ReadyAt   time.Time
+}
+
+type fault struct {
+	path  string
+	code  int
+	times int
+}
+
+func New(cfg config.Config, opts ...Option) *App {
+	app := &App{
+		cfg:          cfg,
+		now:          time.Now,
+		applications: make(map[uuid.UUID]*application),
+		outcomes:     make(map[string]models.ApplicationStatus),
+		rnd:          rand.New(rand.NewSource(cfg.Seed)),
+	}
+	for _, opt := range opts {
+		opt(app)
+	}
+	app.initRouter()
+	return app
+}
+
+func (app *App) initRouter() {
+	router := chi.NewRouter()
+	router.Use(app.faultsMiddleware)
+
+	router.Route("/api", func(r chi.Router) {
+		r.Post("/applications", app.CreateApplication())
+		r.Get("/jobs", app.GetJob())
+	})
+	app.router = router
+}
+
+func (app *App) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	app.router.ServeHTTP(w, r)
+}
+
+// SetOutcome forces the final status of applications with the given first or last name.
+func (app *App) SetOutcome(name string, status models.ApplicationStatus) {
+	app.mu.Lock()
+	defer app.mu.Unlock()
+	app.outcomes[strings.ToLower(name)] = status
+}
+
+// InjectError makes the next times requests to the path respond with the code.
+func (app *App) InjectError(path string, code int, times int) {
+	app.mu.Lock()
+	defer app.mu.Unlock()
+	app.faults = append(app.faults, &fault{path: path, code: code, times: times})
+}
+
+func (app *App) newApplication(id uuid.UUID, firstName, lastName string) *application {
+	h := fnv.New64a()
+	h.Write([]byte(strings.ToLower(firstName + " " + lastName)))
+	sum := h.Sum64() ^ uint64(app.cfg.Seed)
+
+	outcome := models.ApplicationStatus(models.ApplicationStatusCompleted)
+	if sum%2 == 1 {
+		outcome = models.ApplicationStatusRejected
+	}
+	if status, ok := app.outcomes[strings.ToLower(firstName)]; ok {
+		outcome = status
+	}
+	if status, ok := app.outcomes[strings.ToLower(lastName)]; ok {
+		outcome = status
+	}
+
+	delay := app.cfg.MinDelay
+	if spread := app.cfg.MaxDelay - app.cfg.MinDelay; spread > 0 {
+		delay += time.Duration(sum>>1) % spread
+	}
+
+	return &application{
+		ID:        id,
+		JobID:     uuid.NewV4(),
+		FirstName: firstName,
+		LastName:  lastName,
+		Outcome:   outcome,
+		ReadyAt:   app.now().Add(delay),
+	}
+}
+
+func (app *App) status(item *application) models.ApplicationStatus {
+	if app.now().Before(item.ReadyAt) {
+		return models.ApplicationStatusPending
+	}
+	return item.Outcome
+}
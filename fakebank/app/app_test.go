@@ -0,0 +1,175 @@
+package app
+
+import (
+	"context"
+	"github.com/brianvoe/gofakeit"
+	"github.com/ivanovaleksey/lendo/fakebank/config"
+	"github.com/ivanovaleksey/lendo/pkg/models"
+	"github.com/ivanovaleksey/lendo/registry/bank"
+	uuid "github.com/satori/go.uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestApp_CreateApplication(t *testing.T) {
+	t.Run("should create pending application", func(t *testing.T) {
+		fx := newFixture(t, config.Config{})
+		defer fx.Finish()
+
+		status, err := fx.client.CreateApplication(fx.ctx, fx.buildApplication())
+
+		require.NoError(t, err)
+		assert.EqualValues(t, models.ApplicationStatusPending, status)
+	})
+
+	t.Run("when application already exists", func(t *testing.T) {
+		fx := newFixture(t, config.Config{})
+		defer fx.Finish()
+
+		application := fx.buildApplication()
+		_, err := fx.client.CreateApplication(fx.ctx, application)
+		require.NoError(t, err)
+
+		_, err = fx.client.CreateApplication(fx.ctx, application)
+
+		require.Equal(t, bank.Error{Code: http.StatusBadRequest, Message: "application already exists"}, err)
+	})
+}
+
+func TestApp_GetJob(t *testing.T) {
+	t.Run("when application does not exist", func(t *testing.T) {
+		fx := newFixture(t, config.Config{})
+		defer fx.Finish()
+
+		_, err := fx.client.GetApplicationStatus(fx.ctx, uuid.NewV4())
+
+		require.Equal(t, bank.Error{Code: http.StatusNotFound, Message: "application not found"}, err)
+	})
+
+	t.Run("should stay pending until assessed", func(t *testing.T) {
+		cfg := config.Config{
+			MinDelay: 5 * time.Second,
+			MaxDelay: 20 * time.Second,
+		}
+		fx := newFixture(t, cfg)
+		defer fx.Finish()
+
+		application := fx.buildApplication()
+		fx.app.SetOutcome(application.LastName, models.ApplicationStatusRejected)
+		_, err := fx.client.CreateApplication(fx.ctx, application)
+		require.NoError(t, err)
+
+		fx.advance(cfg.MinDelay - time.Second)
+		status, err := fx.client.GetApplicationStatus(fx.ctx, application.ID)
+		require.NoError(t, err)
+		assert.EqualValues(t, models.ApplicationStatusPending, status)
+
+		fx.advance(cfg.MaxDelay)
+		status, err = fx.client.GetApplicationStatus(fx.ctx, application.ID)
+		require.NoError(t, err)
+		assert.EqualValues(t, models.ApplicationStatusRejected, status)
+	})
+
+	t.Run("should be deterministic by seed", func(t *testing.T) {
+		cfg := config.Config{Seed: gofakeit.Int64()}
+		application := (&fixture{}).buildApplication()
+
+		var statuses []models.ApplicationStatus
+		for i := 0; i < 2; i++ {
+			fx := newFixture(t, cfg)
+			_, err := fx.client.CreateApplication(fx.ctx, application)
+			require.NoError(t, err)
+
+			status, err := fx.client.GetApplicationStatus(fx.ctx, application.ID)
+			require.NoError(t, err)
+			statuses = append(statuses, status)
+			fx.Finish()
+		}
+
+		assert.Equal(t, statuses[0], statuses[1])
+		assert.Contains(t, []models.ApplicationStatus{models.ApplicationStatusCompleted, models.ApplicationStatusRejected}, statuses[0])
+	})
+}
+
+func TestApp_InjectError(t *testing.T) {
+	fx := newFixture(t, config.Config{})
+	defer fx.Finish()
+
+	fx.app.InjectError("/api/applications", http.StatusServiceUnavailable, 1)
+
+	application := fx.buildApplication()
+	_, err := fx.client.CreateApplication(fx.ctx, application)
+	require.Equal(t, bank.Error{Code: http.StatusServiceUnavailable}, err)
+
+	_, err = fx.client.CreateApplication(fx.ctx, application)
+	require.NoError(t, err)
+}
+
+func TestApp_Transport(t *testing.T) {
+	app := New(config.Config{MinDelay: time.Minute, MaxDelay: time.Minute})
+	client := bank.NewClient(bank.Config{URL: "http://fakebank"}, bank.WithTransport(app.Transport()))
+	application := (&fixture{}).buildApplication()
+
+	status, err := client.CreateApplication(context.Background(), application)
+	require.NoError(t, err)
+	assert.EqualValues(t, models.ApplicationStatusPending, status)
+
+	status, err = client.GetApplicationStatus(context.Background(), application.ID)
+	require.NoError(t, err)
+	assert.EqualValues(t, models.ApplicationStatusPending, status)
+}
+
+type fixture struct {
+	t   *testing.T
+	ctx context.Context
+
+	mu  sync.Mutex
+	now time.Time
+
+	app    *App
+	srv    *httptest.Server
+	client *bank.Client
+}
+
+func newFixture(t *testing.T, cfg config.Config) *fixture {
+	fx := &fixture{
+		t:   t,
+		ctx: context.Background(),
+		now: time.Now(),
+	}
+	fx.app = New(cfg, WithClock(fx.clock))
+	fx.srv = httptest.NewServer(fx.app)
+	fx.client = bank.NewClient(bank.Config{URL: fx.srv.URL})
+	return fx
+}
+
+func (fx *fixture) Finish() {
+	fx.srv.Close()
+}
+
+func (fx *fixture) clock() time.Time {
+	fx.mu.Lock()
+	defer fx.mu.Unlock()
+	return fx.now
+}
+
+func (fx *fixture) advance(d time.Duration) {
+	fx.mu.Lock()
+	defer fx.mu.Unlock()
+	fx.now = fx.now.Add(d)
+}
+
+func (fx *fixture) buildApplication() models.Application {
+	return models.Application{
+		NewApplication: models.NewApplication{
+			FirstName: gofakeit.FirstName(),
+			LastName:  gofakeit.LastName(),
+		},
+		ID: uuid.NewV4(),
+	}
+}
@@ -0,0 +1,142 @@
+package app
+
+import (
+	"encoding/json"
+	"github.com/go-chi/render"
+	"github.com/ivanovaleksey/lendo/pkg/models"
+	uuid "github.com/satori/go.uuid"
+	"net/http"
+	"time"
+)
+
+type createApplicationRequest struct {
+	ID        uuid.UUID `json:"id"`
+	FirstName string    `json:"first_name"`
+	LastName  string    `json:"last_name"`
+}
+
+type applicationResponse struct {
+	ID        uuid.UUID                `json:"id"`
+	FirstName string                   `json:"first_name"`
+	LastName  string                   `json:"last_name"`
+	Status    models.ApplicationStatus `json:"status"`
+}
+
+type jobResponse struct {
+	ID            uuid.UUID                `json:"id"`
+	ApplicationID uuid.UUID                `json:"application_id"`
+	Status        models.ApplicationStatus `json:"status"`
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+func (app *App) CreateApplication() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		defer r.Body.Close()
+
+		var body createApplicationRequest
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			renderError(w, r, http.StatusBadRequest, err.Error())
+			return
+		}
+		if body.ID == uuid.Nil || body.FirstName == "" || body.LastName == "" {
+			renderError(w, r, http.StatusBadRequest, "id, first_name and last_name are required")
+			return
+		}
+
+		app.mu.Lock()
+		if _, ok := app.applications[body.ID]; ok {
+			app.mu.Unlock()
+			renderError(w, r, http.StatusBadRequest, "application already exists")
+			return
+		}
+		item := app.newApplication(body.ID, body.FirstName, body.LastName)
+		app.applications[item.ID] = item
+		app.mu.Unlock()
+
+		render.Status(r, http.StatusCreated)
+		render.JSON(w, r, applicationResponse{
+			ID:        item.ID,
+			FirstName: item.FirstName,
+			LastName:  item.LastName,
+			Status:    models.ApplicationStatusPending,
+		})
+	}
+}
+
+func (app *App) GetJob() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := uuid.FromString(r.URL.Query().Get("application_id"))
+		if err != nil {
+			renderError(w, r, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		app.mu.Lock()
+		item, ok := app.applications[id]
+		var status models.ApplicationStatus
+		if ok {
+			status = app.status(item)
+		}
+		app.mu.Unlock()
+
+		if !ok {
+			renderError(w, r, http.StatusNotFound, "application not found")
+			return
+		}
+
+		render.JSON(w, r, jobResponse{
+			ID:            item.JobID,
+			ApplicationID: item.ID,
+			Status:        status,
+		})
+	}
+}
+
+// faultsMiddleware applies the configured latency and injected or random errors.
+func (app *App) faultsMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if app.cfg.Latency > 0 {
+			select {
+			case <-time.After(app.cfg.Latency):
+			case <-r.Context().Done():
+				return
+			}
+		}
+
+		if code := app.nextFault(r.URL.Path); code != 0 {
+			renderError(w, r, code, http.StatusText(code))
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (app *App) nextFault(path string) int {
+	app.mu.Lock()
+	defer app.mu.Unlock()
+
+	for i, f := range app.faults {
+		if f.path != path {
+			continue
+		}
+		f.times--
+		if f.times <= 0 {
+			app.faults = append(app.faults[:i], app.faults[i+1:]...)
+		}
+		return f.code
+	}
+
+	if app.cfg.ErrorRate > 0 && app.rnd.Float64() < app.cfg.ErrorRate {
+		return http.StatusInternalServerError
+	}
+	return 0
+}
+
+func renderError(w http.ResponseWriter, r *http.Request, code int, msg string) {
+	render.Status(r, code)
+	render.JSON(w, r, errorResponse{Error: msg})
+}
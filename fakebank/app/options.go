@@ -0,0 +1,12 @@
+package app
+
+import "time"
+
+type Option func(*App)
+
+// WithClock replaces the time source, so tests do not have to wait for assessments.
+func WithClock(now func() time.Time) Option {
+	return func(app *App) {
+		app.now = now
+	}
+}
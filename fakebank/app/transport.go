@@ -0,0 +1,28 @@
+package app
+
+import (
+	"net/http"
+	"net/http/httptest"
+)
+
+// Transport serves bank client requests by the app within the process, so that no fake bank has to listen.
+// Requests go to the app whatever their host is.
+func (app *App) Transport() http.RoundTripper {
+	return transport{handler: app}
+}
+
+type transport struct {
+	handler http.Handler
+}
+
+func (t transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body == nil {
+		req.Body = http.NoBody
+	}
+	rec := httptest.NewRecorder()
+	t.handler.ServeHTTP(rec, req)
+
+	resp := rec.Result()
+	resp.Request = req
+	return resp, nil
+}
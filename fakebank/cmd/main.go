@@ -0,0 +1,45 @@
+package main
+
+import (
+	"context"
+	"github.com/ivanovaleksey/lendo/fakebank/app"
+	"github.com/ivanovaleksey/lendo/fakebank/config"
+	"github.com/ivanovaleksey/lendo/pkg/closer"
+	log "github.com/sirupsen/logrus"
+	"net/http"
+	"syscall"
+	"time"
+)
+
+const shutdownTimeout = 5 * time.Second
+
+func main() {
+	log.SetLevel(log.DebugLevel)
+
+	cfg, err := config.New()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	srv := http.Server{
+		Addr:    cfg.Addr,
+		Handler: app.New(cfg),
+	}
+
+	appCloser := closer.New(closer.WithSignals(syscall.SIGTERM, syscall.SIGINT))
+	appCloser.Add(closer.PhaseIngress, func() error {
+		ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		return srv.Shutdown(ctx)
+	})
+
+	go func() {
+		log.Debugf("starting fake bank on %s", cfg.Addr)
+		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Errorf("server error: %v", err)
+			appCloser.CloseAll()
+		}
+	}()
+
+	appCloser.Wait()
+}
@@ -0,0 +1,24 @@
+package config
+
+import (
+	"github.com/kelseyhightower/envconfig"
+	"time"
+)
+
+type Config struct {
+	Addr      string        `default:":8000"`
+	MinDelay  time.Duration `split_words:"true" default:"5s"`
+	MaxDelay  time.Duration `split_words:"true" default:"20s"`
+	Seed      int64
+	ErrorRate float64 `split_words:"true"`
+	Latency   time.Duration
+}
+
+func New() (Config, error) {
+	var cfg Config
+	err := envconfig.Process("fakebank", &cfg)
+	if err != nil {
+		return Config{}, err
+	}
+	return cfg, err
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"context"
+	apiServer "github.com/ivanovaleksey/lendo/api/server"
+	fakebank "github.com/ivanovaleksey/lendo/fakebank/app"
+	"github.com/ivanovaleksey/lendo/lendo/config"
+	"github.com/ivanovaleksey/lendo/pkg/bus"
+	"github.com/ivanovaleksey/lendo/pkg/closer"
+	"github.com/ivanovaleksey/lendo/pkg/component"
+	"github.com/ivanovaleksey/lendo/pkg/db"
+	"github.com/ivanovaleksey/lendo/pkg/health"
+	"github.com/ivanovaleksey/lendo/pkg/logging"
+	"github.com/ivanovaleksey/lendo/pkg/tracing"
+	"github.com/ivanovaleksey/lendo/registry/poller"
+	"github.com/ivanovaleksey/lendo/registry/repos/jobs"
+	registryServer "github.com/ivanovaleksey/lendo/registry/server"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
+	"syscall"
+)
+
+func main() {
+	ctx := context.Background()
+
+	cfg, err := config.New()
+	if err != nil {
+		log.Fatal(err)
+	}
+	logging.Init(cfg.Log)
+
+	if err := runApps(ctx, cfg); err != nil {
+		log.Error(err)
+	}
+}
+
+// runApps runs the api and the registry over an in-process bus and the fake bank, they share the DB,
+// the health checks and the shutdown.
+func runApps(ctx context.Context, cfg config.Config) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	tracer, err := tracing.Init(ctx, "lendo", cfg.Tracing)
+	if err != nil {
+		return errors.Wrap(err, "can't init tracing")
+	}
+
+	database, err := db.New(cfg.DB)
+	if err != nil {
+		return errors.Wrap(err, "can't create db")
+	}
+
+	prometheus.MustRegister(
+		database.Collector("lendo"),
+		poller.NewJobsCollector(jobsRepo.New(database)),
+	)
+
+	messageBus := bus.NewMemory()
+	bank := fakebank.New(cfg.FakeBank)
+
+	h := health.New()
+	h.AddLiveness(messageBus)
+	h.AddReadiness(database)
+
+	// the api servers are closed along with the registry ones, the registry deadline covers them
+	appCloser := closer.New(
+		closer.WithSignals(syscall.SIGTERM, syscall.SIGINT),
+		closer.WithDeadline(registryServer.ShutdownDeadline(cfg.Registry())),
+	)
+	appCloser.Add(closer.PhaseIngress, func() error {
+		h.Shutdown()
+		return nil
+	})
+	appCloser.Add(closer.PhaseWorkers, func() error {
+		cancel()
+		return nil
+	})
+
+	supervisor := component.NewSupervisor(appCloser)
+	h.AddLiveness(supervisor)
+
+	err = registryServer.Start(ctx, cfg.Registry(), registryServer.Infra{
+		DB:            database,
+		Bus:           messageBus,
+		Closer:        appCloser,
+		Supervisor:    supervisor,
+		Health:        h,
+		BankTransport: bank.Transport(),
+	})
+	if err != nil {
+		return err
+	}
+	err = apiServer.Start(ctx, cfg.API(), apiServer.Infra{
+		DB:         database,
+		Bus:        messageBus,
+		Closer:     appCloser,
+		Supervisor: supervisor,
+		Health:     h,
+	})
+	if err != nil {
+		return err
+	}
+
+	appCloser.Add(closer.PhasePublishers, func() error {
+		return component.Close(messageBus, 0)
+	})
+	appCloser.Add(closer.PhaseInfra, func() error {
+		return component.Close(database, 0)
+	})
+	appCloser.Add(closer.PhaseInfra, func() error {
+		return component.Close(tracer, 0)
+	})
+
+	appCloser.Wait()
+	return nil
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"context"
+	apiMigrations "github.com/ivanovaleksey/lendo/api/migrations"
+	"github.com/ivanovaleksey/lendo/lendo/config"
+	"github.com/ivanovaleksey/lendo/pkg/db"
+	"github.com/ivanovaleksey/lendo/pkg/migrate"
+	"github.com/ivanovaleksey/lendo/pkg/test"
+	registryConfig "github.com/ivanovaleksey/lendo/registry/config"
+	registryMigrations "github.com/ivanovaleksey/lendo/registry/migrations"
+	"github.com/lib/pq"
+	uuid "github.com/satori/go.uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestMigrations(t *testing.T) {
+	t.Run("both services should migrate one database", func(t *testing.T) {
+		ctx := context.Background()
+		database := newSchemaDB(t)
+		cfg := config.Config{MigrateAuto: true}
+
+		api, err := apiMigrations.New(database, cfg.API().Migrate)
+		require.NoError(t, err)
+		registry, err := registryMigrations.New(database, cfg.Registry().Migrate)
+		require.NoError(t, err)
+
+		_, err = api.Up(ctx)
+		require.NoError(t, err)
+		_, err = registry.Up(ctx)
+		require.NoError(t, err)
+
+		require.NoError(t, api.Check(ctx))
+		require.NoError(t, registry.Check(ctx))
+	})
+
+	t.Run("status should not create version table", func(t *testing.T) {
+		ctx := context.Background()
+		database := newSchemaDB(t)
+		cfg := config.Config{}
+
+		api, err := apiMigrations.New(database, cfg.API().Migrate)
+		require.NoError(t, err)
+
+		status, err := api.Status(ctx)
+		require.NoError(t, err)
+		assert.Zero(t, status.Version)
+		assert.Len(t, status.Pending, int(api.Latest()))
+		assert.EqualError(t, api.Check(ctx), "schema version is 0, expected "+strconv.Itoa(int(api.Latest())))
+
+		var exists bool
+		err = database.QueryRowContext(ctx, "SELECT to_regclass($1) IS NOT NULL", config.APIMigrationsTable).Scan(&exists)
+		require.NoError(t, err)
+		assert.False(t, exists)
+	})
+
+	t.Run("migrations of one service should be rolled back without breaking the other", func(t *testing.T) {
+		ctx := context.Background()
+		database := newSchemaDB(t)
+		cfg := config.Config{MigrateAuto: true}
+
+		api, err := apiMigrations.New(database, cfg.API().Migrate)
+		require.NoError(t, err)
+		registry, err := registryMigrations.New(database, cfg.Registry().Migrate)
+		require.NoError(t, err)
+		require.NoError(t, migrate.Ensure(ctx, api, cfg.API().Migrate))
+		require.NoError(t, migrate.Ensure(ctx, registry, cfg.Registry().Migrate))
+
+		_, err = registry.Down(ctx, int(registry.Latest()))
+		require.NoError(t, err)
+
+		// ids of applications are still generated by pgcrypto
+		_, err = database.ExecContext(ctx, "INSERT INTO applications (first_name, last_name, status) VALUES ('John', 'Doe', 'new')")
+		require.NoError(t, err)
+		require.NoError(t, api.Check(ctx))
+
+		_, err = api.Down(ctx, int(api.Latest()))
+		require.NoError(t, err)
+		status, err := api.Status(ctx)
+		require.NoError(t, err)
+		assert.Zero(t, status.Version)
+	})
+}
+
+// newSchemaDB returns a test DB whose objects are created in a new empty schema,
+// objects of the DB itself, like extensions, are still there as they are in a shared database.
+func newSchemaDB(t *testing.T) *db.DB {
+	test.LoadRegistryEnv(t)
+
+	cfg, err := registryConfig.New()
+	require.NoError(t, err)
+	database := db.NewTestDB(t, cfg.DB)
+	t.Cleanup(func() {
+		database.Close()
+	})
+
+	schema := pq.QuoteIdentifier("test_" + strings.ReplaceAll(uuid.NewV4().String(), "-", ""))
+	_, err = database.Exec("CREATE SCHEMA " + schema)
+	require.NoError(t, err)
+	_, err = database.Exec("SET search_path TO " + schema + ", public")
+	require.NoError(t, err)
+	return database
+}
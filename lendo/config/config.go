@@ -0,0 +1,96 @@
+package config
+
+import (
+	apiConfig "github.com/ivanovaleksey/lendo/api/config"
+	fakebankConfig "github.com/ivanovaleksey/lendo/fakebank/config"
+	"github.com/ivanovaleksey/lendo/pkg/db"
+	"github.com/ivanovaleksey/lendo/pkg/envelope"
+	"github.com/ivanovaleksey/lendo/pkg/logging"
+	"github.com/ivanovaleksey/lendo/pkg/migrate"
+	"github.com/ivanovaleksey/lendo/pkg/tracing"
+	"github.com/ivanovaleksey/lendo/registry/bank"
+	"github.com/ivanovaleksey/lendo/registry/bank/audit"
+	registryConfig "github.com/ivanovaleksey/lendo/registry/config"
+	"github.com/ivanovaleksey/lendo/registry/models"
+	"github.com/kelseyhightower/envconfig"
+)
+
+// FakeBankURL is where the registry sends bank requests, they are served by the fake bank within the process.
+const FakeBankURL = "http://fakebank"
+
+// Version tables of the api and the registry migrations, they are apart as both services share the DB.
+const (
+	APIMigrationsTable      = "api_schema_migrations"
+	RegistryMigrationsTable = "registry_schema_migrations"
+)
+
+// Config configures the api and the registry running in one process, settings of the registry components
+// are the same as the registry ones.
+type Config struct {
+	APIAddr      string `envconfig:"api_addr" default:":8010"`
+	RegistryAddr string `envconfig:"registry_addr" default:":8020"`
+	// DB keeps the tables of both the api and the registry.
+	DB        db.Config                      `envconfig:"db"`
+	Admin     registryConfig.AdminConfig     `envconfig:"admin"`
+	BankAudit audit.Config                   `envconfig:"bank_audit"`
+	Events    envelope.Config                `envconfig:"events"`
+	FakeBank  fakebankConfig.Config          `envconfig:"fakebank"`
+	Jobs      registryConfig.JobsConfig      `envconfig:"jobs"`
+	Log       logging.Config                 `envconfig:"log"`
+	Poller    registryConfig.PollerConfig    `envconfig:"poller"`
+	Scheduler registryConfig.SchedulerConfig `envconfig:"scheduler"`
+	Tracing   tracing.Config                 `envconfig:"tracing"`
+	// TrustedTokens authenticate api callers which may set the source and the priority of applications.
+	TrustedTokens []string `envconfig:"trusted_tokens"`
+	// MigrateAuto applies pending migrations of both services on startup.
+	MigrateAuto bool `envconfig:"migrate_auto" default:"true"`
+}
+
+func (c Config) API() apiConfig.Config {
+	return apiConfig.Config{
+		Addr:   c.APIAddr,
+		DB:     c.DB,
+		Events: c.Events,
+		Log:    c.Log,
+		Migrate: migrate.Config{
+			Auto:   c.MigrateAuto,
+			Table:  APIMigrationsTable,
+			Shared: true,
+		},
+		Tracing:       c.Tracing,
+		TrustedTokens: c.TrustedTokens,
+	}
+}
+
+func (c Config) Registry() registryConfig.Config {
+	return registryConfig.Config{
+		Addr:  c.RegistryAddr,
+		Admin: c.Admin,
+		Bank: bank.Config{
+			Name: models.DefaultBank,
+			URL:  FakeBankURL,
+		},
+		BankAudit: c.BankAudit,
+		DB:        c.DB,
+		Events:    c.Events,
+		Jobs:      c.Jobs,
+		Log:       c.Log,
+		Migrate: migrate.Config{
+			Auto:   c.MigrateAuto,
+			Table:  RegistryMigrationsTable,
+			Shared: true,
+		},
+		Poller:    c.Poller,
+		Scheduler: c.Scheduler,
+		Tracing:   c.Tracing,
+	}
+}
+
+func New() (Config, error) {
+	var cfg Config
+	err := envconfig.Process("lendo", &cfg)
+	if err != nil {
+		return Config{}, err
+	}
+	return cfg, err
+}
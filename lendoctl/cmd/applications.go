@@ -0,0 +1,357 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/ivanovaleksey/lendo/lendoctl/output"
+	"github.com/ivanovaleksey/lendo/pkg/client"
+	"github.com/ivanovaleksey/lendo/pkg/envelope"
+	"github.com/ivanovaleksey/lendo/pkg/models"
+	"github.com/ivanovaleksey/lendo/pkg/nats"
+	"github.com/ivanovaleksey/lendo/pkg/pb"
+	natsgo "github.com/nats-io/nats.go"
+	"github.com/pkg/errors"
+	uuid "github.com/satori/go.uuid"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"io/ioutil"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+)
+
+func newApplicationsCmd(opts *options) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "applications",
+		Aliases: []string{"apps"},
+		Short:   "Create and inspect applications through the api",
+	}
+	cmd.AddCommand(
+		newApplicationsCreateCmd(opts),
+		newApplicationsGetCmd(opts),
+		newApplicationsListCmd(opts),
+		newApplicationsWatchCmd(opts),
+		newApplicationsTailCmd(opts),
+	)
+	return cmd
+}
+
+func applicationsTable(applications []models.Application) output.Table {
+	table := output.Table{Header: []string{"id", "status", "first_name", "last_name"}}
+	for _, application := range applications {
+		table.Append(application.ID, application.Status, application.FirstName, application.LastName)
+	}
+	return table
+}
+
+func newApplicationsCreateCmd(opts *options) *cobra.Command {
+	var (
+		item           models.NewApplication
+		file           string
+		source         string
+		priority       int
+		idempotencyKey string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "create",
+		Short: "Create applications given by flags or a JSON file with an object or an array of them",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			e, err := newEnv(cmd, opts)
+			if err != nil {
+				return err
+			}
+			c, err := e.apiClient()
+			if err != nil {
+				return err
+			}
+
+			items := []models.NewApplication{item}
+			if file != "" {
+				items, err = readApplications(file)
+				if err != nil {
+					return err
+				}
+			}
+			if idempotencyKey != "" && len(items) > 1 {
+				return errors.New("idempotency key can't be shared by several applications")
+			}
+			if (source != "" || cmd.Flags().Changed("priority")) && e.profile.APIToken == "" {
+				return errors.New("source and priority are ignored by the api without api_token in the profile")
+			}
+
+			var createOpts []client.CreateOption
+			if source != "" {
+				createOpts = append(createOpts, client.WithSource(source))
+			}
+			if cmd.Flags().Changed("priority") {
+				createOpts = append(createOpts, client.WithPriority(priority))
+			}
+			if idempotencyKey != "" {
+				createOpts = append(createOpts, client.WithIdempotencyKey(idempotencyKey))
+			}
+
+			created := make([]models.Application, 0, len(items))
+			for _, item := range items {
+				id, err := c.CreateApplication(cmd.Context(), item, createOpts...)
+				if err != nil {
+					// print what's created, so that the file may be fixed and the rest of it created
+					if len(created) > 0 {
+						_ = e.printer.Print(created, applicationsTable(created))
+					}
+					return errors.Wrapf(err, "can't create application %s %s", item.FirstName, item.LastName)
+				}
+				created = append(created, models.Application{
+					NewApplication: item,
+					ID:             id,
+					Status:         models.ApplicationStatusNew,
+				})
+			}
+			return e.printer.Print(created, applicationsTable(created))
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.StringVar(&item.FirstName, "first-name", "", "first name")
+	flags.StringVar(&item.LastName, "last-name", "", "last name")
+	flags.StringVarP(&file, "file", "f", "", `JSON file of applications, "-" reads stdin`)
+	flags.StringVar(&source, "source", "", "channel or partner submitting the applications")
+	flags.IntVar(&priority, "priority", 0, "priority overriding the one of the source")
+	flags.StringVar(&idempotencyKey, "idempotency-key", "", "key to create the application once, a random one by default")
+	return cmd
+}
+
+func readApplications(file string) ([]models.NewApplication, error) {
+	var (
+		data []byte
+		err  error
+	)
+	if file == "-" {
+		data, err = ioutil.ReadAll(os.Stdin)
+	} else {
+		data, err = ioutil.ReadFile(file)
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "can't read applications")
+	}
+
+	data = bytes.TrimSpace(data)
+	if bytes.HasPrefix(data, []byte("[")) {
+		var items []models.NewApplication
+		if err := json.Unmarshal(data, &items); err != nil {
+			return nil, errors.Wrap(err, "can't decode applications")
+		}
+		return items, nil
+	}
+	var item models.NewApplication
+	if err := json.Unmarshal(data, &item); err != nil {
+		return nil, errors.Wrap(err, "can't decode application")
+	}
+	return []models.NewApplication{item}, nil
+}
+
+func newApplicationsGetCmd(opts *options) *cobra.Command {
+	return &cobra.Command{
+		Use:   "get ID",
+		Short: "Get an application",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			id, err := uuid.FromString(args[0])
+			if err != nil {
+				return errors.Wrap(err, "invalid application ID")
+			}
+			e, err := newEnv(cmd, opts)
+			if err != nil {
+				return err
+			}
+			c, err := e.apiClient()
+			if err != nil {
+				return err
+			}
+
+			application, err := c.GetApplication(cmd.Context(), id)
+			if err != nil {
+				return err
+			}
+			return e.printer.Print(application, applicationsTable([]models.Application{application}))
+		},
+	}
+}
+
+func newApplicationsListCmd(opts *options) *cobra.Command {
+	var (
+		params client.ListParams
+		status string
+		all    bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List applications",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			e, err := newEnv(cmd, opts)
+			if err != nil {
+				return err
+			}
+			c, err := e.apiClient()
+			if err != nil {
+				return err
+			}
+			params.Status = models.ApplicationStatus(status)
+
+			var applications []models.Application
+			if all {
+				it := c.Applications(params)
+				for it.Next(cmd.Context()) {
+					applications = append(applications, it.Application())
+				}
+				if err := it.Err(); err != nil {
+					return err
+				}
+			} else {
+				applications, _, err = c.ListApplications(cmd.Context(), params)
+				if err != nil {
+					return err
+				}
+			}
+			if applications == nil {
+				applications = []models.Application{}
+			}
+			return e.printer.Print(applications, applicationsTable(applications))
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.StringVar(&status, "status", "", "new, pending, completed or rejected")
+	flags.IntVar(&params.Limit, "limit", 0, "page size, the api default is 10")
+	flags.IntVar(&params.Offset, "offset", 0, "number of applications to skip")
+	flags.BoolVar(&all, "all", false, "list all the applications page by page")
+	return cmd
+}
+
+func newApplicationsWatchCmd(opts *options) *cobra.Command {
+	var interval time.Duration
+
+	cmd := &cobra.Command{
+		Use:   "watch ID...",
+		Short: "Print status changes of applications until they are completed or rejected",
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ids := make([]uuid.UUID, 0, len(args))
+			for _, arg := range args {
+				id, err := uuid.FromString(arg)
+				if err != nil {
+					return errors.Wrapf(err, "invalid application ID %s", arg)
+				}
+				ids = append(ids, id)
+			}
+			e, err := newEnv(cmd, opts)
+			if err != nil {
+				return err
+			}
+			c, err := e.apiClient(client.WithPollInterval(interval))
+			if err != nil {
+				return err
+			}
+
+			var (
+				mu   sync.Mutex
+				wg   sync.WaitGroup
+				errs = make([]error, len(ids))
+			)
+			for i, id := range ids {
+				wg.Add(1)
+				go func(i int, id uuid.UUID) {
+					defer wg.Done()
+					errs[i] = c.WatchApplication(cmd.Context(), id, func(application models.Application) error {
+						mu.Lock()
+						defer mu.Unlock()
+
+						now := formatTime(time.Now())
+						change := struct {
+							Time string `json:"time"`
+							models.Application
+						}{now, application}
+						return e.printer.PrintRow(change, []string{now, application.ID.String(), string(application.Status)})
+					})
+				}(i, id)
+			}
+			wg.Wait()
+
+			for i, err := range errs {
+				if err != nil {
+					return errors.Wrapf(err, "can't watch application %s", ids[i])
+				}
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().DurationVar(&interval, "interval", 2*time.Second, "how often applications are polled")
+	return cmd
+}
+
+func newApplicationsTailCmd(opts *options) *cobra.Command {
+	return &cobra.Command{
+		Use:   "tail",
+		Short: "Print status changes of all applications as the registry publishes them",
+		Long: "Print status changes of all applications as the registry publishes them on applications.changed.\n" +
+			"The subscription is a plain one at nats_url of the profile, so the api still gets every change.\n" +
+			"Changes published while lendoctl is not running are not printed.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			e, err := newEnv(cmd, opts)
+			if err != nil {
+				return err
+			}
+			if e.profile.NATSURL == "" {
+				return errors.New("profile has no nats_url")
+			}
+
+			ctx, stop := signal.NotifyContext(cmd.Context(), syscall.SIGTERM, syscall.SIGINT)
+			defer stop()
+
+			natsClient, err := nats.New(nats.Config{URL: e.profile.NATSURL}, natsgo.Name("lendoctl"), natsgo.Timeout(e.timeout))
+			if err != nil {
+				return errors.Wrap(err, "can't create nats client")
+			}
+			defer natsClient.Close()
+
+			decoder := envelope.NewDecoder(models.EventApplicationStatusChanged, models.EventApplicationStatusChangedVersion)
+			subs, err := natsClient.Subscribe("applications.changed", func(msg *natsgo.Msg) {
+				var data pb.StatusChange
+				env, err := decoder.Decode(msg.Header, msg.Data, &data)
+				if err != nil {
+					log.Warnf("can't decode message: %v", err)
+					return
+				}
+				change, err := data.ToModel()
+				if err != nil {
+					log.Warnf("can't decode message: %v", err)
+					return
+				}
+
+				now := formatTime(time.Now())
+				row := struct {
+					Time          string `json:"time"`
+					CorrelationID string `json:"correlation_id,omitempty"`
+					models.StatusChange
+				}{now, env.CorrelationID, change}
+				if err := e.printer.PrintRow(row, []string{now, change.ID.String(), string(change.Status), env.CorrelationID}); err != nil {
+					log.Warnf("can't print status change: %v", err)
+				}
+			})
+			if err != nil {
+				return errors.Wrap(err, "can't subscribe")
+			}
+			defer subs.Unsubscribe()
+
+			<-ctx.Done()
+			return nil
+		},
+	}
+}
@@ -0,0 +1,200 @@
+package main
+
+import (
+	"fmt"
+	"github.com/ivanovaleksey/lendo/lendoctl/output"
+	"github.com/ivanovaleksey/lendo/pkg/client"
+	"github.com/ivanovaleksey/lendo/registry/models"
+	"github.com/pkg/errors"
+	uuid "github.com/satori/go.uuid"
+	"github.com/spf13/cobra"
+	"time"
+)
+
+func newJobsCmd(opts *options) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "jobs",
+		Short: "Inspect and control registry jobs through the admin API",
+	}
+	cmd.AddCommand(
+		newJobsListCmd(opts),
+		newJobsGetCmd(opts),
+		newJobsControlCmd(opts, client.ActionRetry, "Run a new or pending job now"),
+		newJobsControlCmd(opts, client.ActionCancel, "Cancel a new or pending job"),
+		newJobsControlCmd(opts, client.ActionFail, "Fail a new or pending job"),
+		newJobsControlCmd(opts, client.ActionRequeue, "Put a done, failed or cancelled job back to processing"),
+		newJobsRetryFailedCmd(opts),
+	)
+	return cmd
+}
+
+func applicationID(job models.Job) string {
+	if job.ApplicationID == nil {
+		return ""
+	}
+	return job.ApplicationID.String()
+}
+
+func newJobsListCmd(opts *options) *cobra.Command {
+	var (
+		params client.JobsParams
+		status string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List jobs",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			e, err := newEnv(cmd, opts)
+			if err != nil {
+				return err
+			}
+			admin, err := e.adminClient()
+			if err != nil {
+				return err
+			}
+			params.Status = models.JobStatus(status)
+
+			jobs, _, err := admin.ListJobs(cmd.Context(), params)
+			if err != nil {
+				return err
+			}
+			if jobs == nil {
+				jobs = []models.Job{}
+			}
+
+			table := output.Table{Header: []string{"id", "kind", "status", "application_id", "source", "attempts", "run_at", "last_error"}}
+			for _, job := range jobs {
+				table.Append(job.ID, job.Kind, job.Status, applicationID(job), job.Source, job.Attempts, formatTime(job.RunAt), job.LastError)
+			}
+			return e.printer.Print(jobs, table)
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.StringVar(&status, "status", "", "new, pending, done, failed or cancelled")
+	flags.DurationVar(&params.OlderThan, "older-than", 0, "minimal age of jobs, e.g. 1h")
+	flags.IntVar(&params.MinAttempts, "min-attempts", 0, "minimal number of failed attempts")
+	flags.IntVar(&params.Limit, "limit", 0, "page size")
+	flags.IntVar(&params.Offset, "offset", 0, "number of jobs to skip")
+	return cmd
+}
+
+func newJobsGetCmd(opts *options) *cobra.Command {
+	return &cobra.Command{
+		Use:   "get ID",
+		Short: "Get a job with its payload, bank exchanges and timeline",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			id, err := uuid.FromString(args[0])
+			if err != nil {
+				return errors.Wrap(err, "invalid job ID")
+			}
+			e, err := newEnv(cmd, opts)
+			if err != nil {
+				return err
+			}
+			admin, err := e.adminClient()
+			if err != nil {
+				return err
+			}
+
+			details, err := admin.GetJob(cmd.Context(), id)
+			if err != nil {
+				return err
+			}
+
+			// the table is the timeline, the job itself goes first as an event of its creation
+			table := output.Table{Header: []string{"time", "handler", "bank_status", "duration_ms", "worker_id", "error"}}
+			table.Append(formatTime(details.CreatedAt), fmt.Sprintf("created %s job", details.Kind), "", "", "", "")
+			for _, event := range details.Events {
+				table.Append(formatTime(event.CreatedAt), event.Handler, event.BankStatus, event.DurationMS, event.WorkerID, event.Error)
+			}
+			table.Append(formatTime(details.UpdatedAt), fmt.Sprintf("%s after %d attempts", details.Status, details.Attempts), "", "", "", details.LastError)
+			return e.printer.Print(details, table)
+		},
+	}
+}
+
+func newJobsControlCmd(opts *options, action, short string) *cobra.Command {
+	return &cobra.Command{
+		Use:   action + " ID...",
+		Short: short,
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ids := make([]uuid.UUID, 0, len(args))
+			for _, arg := range args {
+				id, err := uuid.FromString(arg)
+				if err != nil {
+					return errors.Wrapf(err, "invalid job ID %s", arg)
+				}
+				ids = append(ids, id)
+			}
+			e, err := newEnv(cmd, opts)
+			if err != nil {
+				return err
+			}
+			admin, err := e.adminClient()
+			if err != nil {
+				return err
+			}
+
+			for _, id := range ids {
+				if err := admin.ControlJob(cmd.Context(), id, action); err != nil {
+					return errors.Wrapf(err, "can't %s job %s", action, id)
+				}
+				fmt.Fprintf(cmd.ErrOrStderr(), "%s: %s\n", id, action)
+			}
+			return nil
+		},
+	}
+}
+
+func newJobsRetryFailedCmd(opts *options) *cobra.Command {
+	var from, to string
+
+	cmd := &cobra.Command{
+		Use:   "retry-failed",
+		Short: "Requeue jobs failed in a time range",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			fromTime, err := time.Parse(time.RFC3339, from)
+			if err != nil {
+				return errors.Wrap(err, "invalid from")
+			}
+			var toTime time.Time
+			if to != "" {
+				toTime, err = time.Parse(time.RFC3339, to)
+				if err != nil {
+					return errors.Wrap(err, "invalid to")
+				}
+			}
+			e, err := newEnv(cmd, opts)
+			if err != nil {
+				return err
+			}
+			admin, err := e.adminClient()
+			if err != nil {
+				return err
+			}
+
+			count, err := admin.RetryFailedJobs(cmd.Context(), fromTime, toTime)
+			if err != nil {
+				return err
+			}
+			resp := struct {
+				Count int64 `json:"count"`
+			}{count}
+			table := output.Table{Header: []string{"requeued"}}
+			table.Append(count)
+			return e.printer.Print(resp, table)
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.StringVar(&from, "from", "", "start of the range, RFC 3339")
+	flags.StringVar(&to, "to", "", "end of the range, RFC 3339, now by default")
+	_ = cmd.MarkFlagRequired("from")
+	return cmd
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"context"
+	"github.com/ivanovaleksey/lendo/lendoctl/output"
+	"github.com/ivanovaleksey/lendo/lendoctl/profile"
+	"github.com/ivanovaleksey/lendo/pkg/cli"
+	"github.com/ivanovaleksey/lendo/pkg/client"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"net/http"
+	"os"
+	"time"
+)
+
+func main() {
+	log.SetLevel(log.WarnLevel)
+
+	if err := newRootCmd().ExecuteContext(context.Background()); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// options are the global flags, the flags of the connection override the selected profile.
+type options struct {
+	config        string
+	profile       string
+	output        string
+	timeout       time.Duration
+	verbose       bool
+	apiURL        string
+	registryURL   string
+	reconcilerURL string
+	natsURL       string
+	apiToken      string
+	adminToken    string
+}
+
+func newRootCmd() *cobra.Command {
+	var opts options
+
+	cmd := &cobra.Command{
+		Use:           "lendoctl",
+		Short:         "Operate lendo applications and registry jobs",
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		PersistentPreRun: func(cmd *cobra.Command, _ []string) {
+			if opts.verbose {
+				log.SetLevel(log.DebugLevel)
+			}
+		},
+	}
+	cmd.CompletionOptions.DisableDefaultCmd = true
+
+	flags := cmd.PersistentFlags()
+	flags.StringVar(&opts.config, "config", envOr("LENDOCTL_CONFIG", profile.DefaultPath()), "profiles file")
+	flags.StringVarP(&opts.profile, "profile", "p", os.Getenv("LENDOCTL_PROFILE"), "profile to use instead of the current one")
+	flags.StringVarP(&opts.output, "output", "o", string(output.FormatTable), "output format: table, json or csv")
+	flags.DurationVar(&opts.timeout, "timeout", 10*time.Second, "timeout of a single request")
+	flags.BoolVarP(&opts.verbose, "verbose", "v", false, "log debug messages")
+	flags.StringVar(&opts.apiURL, "api-url", "", "api URL, overrides the profile")
+	flags.StringVar(&opts.registryURL, "registry-url", "", "registry URL, overrides the profile")
+	flags.StringVar(&opts.reconcilerURL, "reconciler-url", "", "reconciler URL, overrides the profile")
+	flags.StringVar(&opts.natsURL, "nats-url", "", "NATS URL, overrides the profile")
+	flags.StringVar(&opts.apiToken, "api-token", "", "api trusted token, overrides the profile")
+	flags.StringVar(&opts.adminToken, "admin-token", "", "registry and reconciler admin token, overrides the profile")
+
+	cmd.AddCommand(
+		newApplicationsCmd(&opts),
+		newJobsCmd(&opts),
+		newReconcileCmd(&opts),
+		newProfilesCmd(&opts),
+		cli.NewVersionCommand(),
+	)
+	return cmd
+}
+
+// env is what a command works with: the selected profile and the output.
+type env struct {
+	profile profile.Profile
+	printer *output.Printer
+	timeout time.Duration
+}
+
+func newEnv(cmd *cobra.Command, opts *options) (*env, error) {
+	format, err := output.ParseFormat(opts.output)
+	if err != nil {
+		return nil, err
+	}
+	cfg, err := profile.Load(opts.config)
+	if err != nil {
+		return nil, err
+	}
+	p, err := cfg.Get(opts.profile)
+	if err != nil {
+		return nil, err
+	}
+	if opts.apiURL != "" {
+		p.APIURL = opts.apiURL
+	}
+	if opts.registryURL != "" {
+		p.RegistryURL = opts.registryURL
+	}
+	if opts.reconcilerURL != "" {
+		p.ReconcilerURL = opts.reconcilerURL
+	}
+	if opts.natsURL != "" {
+		p.NATSURL = opts.natsURL
+	}
+	if opts.apiToken != "" {
+		p.APIToken = opts.apiToken
+	}
+	if opts.adminToken != "" {
+		p.AdminToken = opts.adminToken
+	}
+
+	return &env{
+		profile: p,
+		printer: output.NewPrinter(cmd.OutOrStdout(), format),
+		timeout: opts.timeout,
+	}, nil
+}
+
+func (e *env) apiClient(opts ...client.Option) (*client.Client, error) {
+	opts = append([]client.Option{
+		client.WithHTTPClient(&http.Client{Timeout: e.timeout}),
+		client.WithToken(e.profile.APIToken),
+	}, opts...)
+	return client.New(e.profile.APIURL, opts...)
+}
+
+func (e *env) adminClient() (*client.Admin, error) {
+	return client.NewAdmin(e.profile.RegistryURL, e.profile.AdminToken, client.WithHTTPClient(&http.Client{Timeout: e.timeout}))
+}
+
+func (e *env) reconcilerClient() (*client.Reconciler, error) {
+	if e.profile.ReconcilerURL == "" {
+		return nil, errors.New("profile has no reconciler_url")
+	}
+	return client.NewReconciler(e.profile.ReconcilerURL, e.profile.AdminToken, client.WithHTTPClient(&http.Client{Timeout: e.timeout}))
+}
+
+func envOr(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
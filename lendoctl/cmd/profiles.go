@@ -0,0 +1,159 @@
+package main
+
+import (
+	"fmt"
+	"github.com/ivanovaleksey/lendo/lendoctl/output"
+	"github.com/ivanovaleksey/lendo/lendoctl/profile"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+func newProfilesCmd(opts *options) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "profiles",
+		Short: "Manage the environments lendoctl works with",
+	}
+	cmd.AddCommand(
+		newProfilesListCmd(opts),
+		newProfilesSetCmd(opts),
+		newProfilesUseCmd(opts),
+		newProfilesDeleteCmd(opts),
+	)
+	return cmd
+}
+
+func newProfilesListCmd(opts *options) *cobra.Command {
+	return &cobra.Command{
+		Use:   "list",
+		Short: "List profiles, the current one is marked with *",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			format, err := output.ParseFormat(opts.output)
+			if err != nil {
+				return err
+			}
+			cfg, err := profile.Load(opts.config)
+			if err != nil {
+				return err
+			}
+
+			type item struct {
+				Name    string `json:"name"`
+				Current bool   `json:"current"`
+				profile.Profile
+			}
+			items := make([]item, 0, len(cfg.Profiles))
+			table := output.Table{Header: []string{"current", "name", "api_url", "registry_url"}}
+			for _, name := range cfg.Names() {
+				p := cfg.Profiles[name]
+				current := name == cfg.Current
+				// tokens are not printed
+				p.APIToken = ""
+				p.AdminToken = ""
+				items = append(items, item{Name: name, Current: current, Profile: p})
+
+				mark := ""
+				if current {
+					mark = "*"
+				}
+				table.Append(mark, name, p.APIURL, p.RegistryURL)
+			}
+			return output.NewPrinter(cmd.OutOrStdout(), format).Print(items, table)
+		},
+	}
+}
+
+func newProfilesSetCmd(opts *options) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "set NAME",
+		Short: "Create a profile or update the given settings of it",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			name := args[0]
+			cfg, err := profile.Load(opts.config)
+			if err != nil {
+				return err
+			}
+
+			current, ok := cfg.Profiles[name]
+			if !ok && name == profile.DefaultName {
+				current = profile.Local()
+			}
+			flags := cmd.Flags()
+			for flag, dst := range map[string]*string{
+				"api-url":        &current.APIURL,
+				"registry-url":   &current.RegistryURL,
+				"reconciler-url": &current.ReconcilerURL,
+				"nats-url":       &current.NATSURL,
+				"api-token":      &current.APIToken,
+				"admin-token":    &current.AdminToken,
+			} {
+				if flags.Changed(flag) {
+					*dst, _ = flags.GetString(flag)
+				}
+			}
+			cfg.Profiles[name] = current
+			if cfg.Current == "" {
+				cfg.Current = name
+			}
+			return cfg.Save(opts.config)
+		},
+	}
+
+	// the connection flags are global ones, here they are the settings of the profile
+	flags := cmd.Flags()
+	flags.String("api-url", "", "api URL")
+	flags.String("registry-url", "", "registry URL")
+	flags.String("reconciler-url", "", "reconciler URL for the reconciliation report")
+	flags.String("nats-url", "", "NATS URL for tailing status changes")
+	flags.String("api-token", "", "api trusted token, lets applications be created with a source and a priority")
+	flags.String("admin-token", "", "registry and reconciler admin token")
+	return cmd
+}
+
+func newProfilesUseCmd(opts *options) *cobra.Command {
+	return &cobra.Command{
+		Use:   "use NAME",
+		Short: "Make the profile current",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			name := args[0]
+			cfg, err := profile.Load(opts.config)
+			if err != nil {
+				return err
+			}
+			if _, ok := cfg.Profiles[name]; !ok && name != profile.DefaultName {
+				return errors.Errorf("profile %q not found", name)
+			}
+			cfg.Current = name
+			if err := cfg.Save(opts.config); err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.ErrOrStderr(), "using profile %s\n", name)
+			return nil
+		},
+	}
+}
+
+func newProfilesDeleteCmd(opts *options) *cobra.Command {
+	return &cobra.Command{
+		Use:   "delete NAME",
+		Short: "Delete the profile",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			name := args[0]
+			cfg, err := profile.Load(opts.config)
+			if err != nil {
+				return err
+			}
+			if _, ok := cfg.Profiles[name]; !ok {
+				return errors.Errorf("profile %q not found", name)
+			}
+			delete(cfg.Profiles, name)
+			if cfg.Current == name {
+				cfg.Current = ""
+			}
+			return cfg.Save(opts.config)
+		},
+	}
+}
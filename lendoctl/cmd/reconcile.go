@@ -0,0 +1,43 @@
+package main
+
+import (
+	"github.com/ivanovaleksey/lendo/lendoctl/output"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"time"
+)
+
+func newReconcileCmd(opts *options) *cobra.Command {
+	var grace time.Duration
+
+	cmd := &cobra.Command{
+		Use:   "reconcile",
+		Short: "Report discrepancies between api applications and registry jobs without repairing them",
+		Long: "Report discrepancies between api applications and registry jobs without repairing them.\n" +
+			"The reconciler at reconciler_url of the profile makes the report, the request is authorized by admin_token.\n" +
+			"A large backlog may take longer than the default --timeout.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			e, err := newEnv(cmd, opts)
+			if err != nil {
+				return err
+			}
+			c, err := e.reconcilerClient()
+			if err != nil {
+				return err
+			}
+
+			report, err := c.Report(cmd.Context(), grace)
+			if err != nil {
+				return errors.Wrap(err, "can't reconcile")
+			}
+
+			table := output.Table{Header: []string{"checked", "missing_jobs", "status_mismatches", "failed_jobs"}}
+			table.Append(report.Checked, report.MissingJobs, report.StatusMismatches, report.FailedJobs)
+			return e.printer.Print(report, table)
+		},
+	}
+
+	cmd.Flags().DurationVar(&grace, "grace", 0, "how old an application must be to be checked, the reconciler setting by default")
+	return cmd
+}
@@ -0,0 +1,102 @@
+// Package output prints lendoctl results as a table for people or as JSON or CSV for scripts.
+package output
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+)
+
+type Format string
+
+const (
+	FormatTable Format = "table"
+	FormatJSON  Format = "json"
+	FormatCSV   Format = "csv"
+)
+
+// ParseFormat checks the format given by a flag.
+func ParseFormat(value string) (Format, error) {
+	switch f := Format(value); f {
+	case FormatTable, FormatJSON, FormatCSV:
+		return f, nil
+	default:
+		return "", fmt.Errorf("unknown output format %q, use table, json or csv", value)
+	}
+}
+
+// Table is what the table and CSV formats print.
+type Table struct {
+	Header []string
+	Rows   [][]string
+}
+
+// Append adds a row of values formatted with %v.
+func (t *Table) Append(values ...interface{}) {
+	row := make([]string, 0, len(values))
+	for _, value := range values {
+		row = append(row, fmt.Sprint(value))
+	}
+	t.Rows = append(t.Rows, row)
+}
+
+// Printer prints results in the format.
+type Printer struct {
+	w      io.Writer
+	format Format
+}
+
+func NewPrinter(w io.Writer, format Format) *Printer {
+	return &Printer{
+		w:      w,
+		format: format,
+	}
+}
+
+// Print prints v as JSON or the table otherwise.
+func (p *Printer) Print(v interface{}, table Table) error {
+	switch p.format {
+	case FormatJSON:
+		enc := json.NewEncoder(p.w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(v)
+	case FormatCSV:
+		w := csv.NewWriter(p.w)
+		if err := w.Write(table.Header); err != nil {
+			return err
+		}
+		if err := w.WriteAll(table.Rows); err != nil {
+			return err
+		}
+		return w.Error()
+	default:
+		w := tabwriter.NewWriter(p.w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, strings.ToUpper(strings.Join(table.Header, "\t")))
+		for _, row := range table.Rows {
+			fmt.Fprintln(w, strings.Join(row, "\t"))
+		}
+		return w.Flush()
+	}
+}
+
+// PrintRow prints a single result as soon as it's known, e.g. a status change being watched: v as a JSON line
+// or the row otherwise.
+func (p *Printer) PrintRow(v interface{}, row []string) error {
+	switch p.format {
+	case FormatJSON:
+		return json.NewEncoder(p.w).Encode(v)
+	case FormatCSV:
+		w := csv.NewWriter(p.w)
+		if err := w.Write(row); err != nil {
+			return err
+		}
+		w.Flush()
+		return w.Error()
+	default:
+		_, err := fmt.Fprintln(p.w, strings.Join(row, "  "))
+		return err
+	}
+}
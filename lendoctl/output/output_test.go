@@ -0,0 +1,86 @@
+package output
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestPrinter_Print(t *testing.T) {
+	items := []map[string]string{
+		{"id": "1", "status": "new"},
+		{"id": "2", "status": "completed"},
+	}
+	table := Table{Header: []string{"id", "status"}}
+	table.Append(1, "new")
+	table.Append(2, "completed")
+
+	cases := []struct {
+		format   Format
+		expected string
+	}{
+		{
+			format:   FormatTable,
+			expected: "ID  STATUS\n1   new\n2   completed\n",
+		},
+		{
+			format:   FormatCSV,
+			expected: "id,status\n1,new\n2,completed\n",
+		},
+		{
+			format: FormatJSON,
+			expected: `[
+  {
+    "id": "1",
+    "status": "new"
+  },
+  {
+    "id": "2",
+    "status": "completed"
+  }
+]
+`,
+		},
+	}
+	for _, c := range cases {
+		t.Run(string(c.format), func(t *testing.T) {
+			var buf bytes.Buffer
+
+			err := NewPrinter(&buf, c.format).Print(items, table)
+
+			require.NoError(t, err)
+			assert.Equal(t, c.expected, buf.String())
+		})
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	format, err := ParseFormat("csv")
+	require.NoError(t, err)
+	assert.Equal(t, FormatCSV, format)
+
+	_, err = ParseFormat("xml")
+	assert.Error(t, err)
+}
+
+func TestPrinter_PrintRow(t *testing.T) {
+	item := map[string]string{"id": "1", "status": "new"}
+	row := []string{"1", "new"}
+
+	cases := map[Format]string{
+		FormatTable: "1  new\n",
+		FormatCSV:   "1,new\n",
+		FormatJSON:  `{"id":"1","status":"new"}` + "\n",
+	}
+	for format, expected := range cases {
+		t.Run(string(format), func(t *testing.T) {
+			var buf bytes.Buffer
+
+			err := NewPrinter(&buf, format).PrintRow(item, row)
+
+			require.NoError(t, err)
+			assert.Equal(t, expected, buf.String())
+		})
+	}
+}
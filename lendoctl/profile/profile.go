@@ -0,0 +1,121 @@
+// Package profile keeps the environments lendoctl works with, e.g. local, staging and production,
+// in a YAML file:
+//
+//	current: staging
+//	profiles:
+//	  staging:
+//	    api_url: https://api.staging.example.com
+//	    registry_url: https://registry.staging.example.com
+//	    reconciler_url: https://reconciler.staging.example.com
+//	    nats_url: nats://nats.staging.example.com:4222
+//	    api_token: secret
+//	    admin_token: secret
+package profile
+
+import (
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// DefaultName is the profile used when none is selected, without a file it points to the all-in-one mode
+// run locally.
+const DefaultName = "default"
+
+// Profile is how to reach an environment. The reconciler URL is only needed for the reconciliation report
+// and the NATS URL for tailing status changes, the admin token is shared by the registry and the reconciler.
+type Profile struct {
+	APIURL        string `json:"api_url" yaml:"api_url"`
+	RegistryURL   string `json:"registry_url" yaml:"registry_url"`
+	ReconcilerURL string `json:"reconciler_url,omitempty" yaml:"reconciler_url,omitempty"`
+	NATSURL       string `json:"nats_url,omitempty" yaml:"nats_url,omitempty"`
+	APIToken      string `json:"api_token,omitempty" yaml:"api_token,omitempty"`
+	AdminToken    string `json:"admin_token,omitempty" yaml:"admin_token,omitempty"`
+}
+
+// Local is the default profile, the addresses of lendo/cmd.
+func Local() Profile {
+	return Profile{
+		APIURL:      "http://localhost:8010",
+		RegistryURL: "http://localhost:8020",
+	}
+}
+
+type Config struct {
+	// Current is the profile used unless another one is selected.
+	Current  string             `yaml:"current,omitempty"`
+	Profiles map[string]Profile `yaml:"profiles"`
+}
+
+// DefaultPath is ~/.lendoctl.yaml.
+func DefaultPath() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ".lendoctl.yaml"
+	}
+	return filepath.Join(home, ".lendoctl.yaml")
+}
+
+// Load reads the config file, a missing file is an empty config.
+func Load(path string) (Config, error) {
+	cfg := Config{Profiles: make(map[string]Profile)}
+
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return cfg, nil
+	}
+	if err != nil {
+		return Config{}, errors.Wrap(err, "can't read profiles")
+	}
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return Config{}, errors.Wrapf(err, "can't parse profiles %s", path)
+	}
+	if cfg.Profiles == nil {
+		cfg.Profiles = make(map[string]Profile)
+	}
+	return cfg, nil
+}
+
+// Save writes the config file readable by the owner only, as profiles keep tokens.
+func (c Config) Save(path string) error {
+	data, err := yaml.Marshal(c)
+	if err != nil {
+		return errors.Wrap(err, "can't encode profiles")
+	}
+	if err := ioutil.WriteFile(path, data, 0600); err != nil {
+		return errors.Wrap(err, "can't write profiles")
+	}
+	return nil
+}
+
+// Get returns the named profile, an empty name selects the current one.
+func (c Config) Get(name string) (Profile, error) {
+	if name == "" {
+		name = c.Current
+	}
+	if name == "" {
+		name = DefaultName
+	}
+	p, ok := c.Profiles[name]
+	switch {
+	case ok:
+		return p, nil
+	case name == DefaultName:
+		return Local(), nil
+	default:
+		return Profile{}, errors.Errorf("profile %q not found", name)
+	}
+}
+
+// Names returns the profile names in order.
+func (c Config) Names() []string {
+	names := make([]string, 0, len(c.Profiles))
+	for name := range c.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,92 @@
+package profile
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoad(t *testing.T) {
+	t.Run("when file doesn't exist", func(t *testing.T) {
+		cfg, err := Load(filepath.Join(t.TempDir(), "missing.yaml"))
+
+		require.NoError(t, err)
+		p, err := cfg.Get("")
+		require.NoError(t, err)
+		assert.Equal(t, Local(), p)
+	})
+
+	t.Run("should select current profile", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "lendoctl.yaml")
+		content := `
+current: staging
+profiles:
+  staging:
+    api_url: http://api.staging
+    registry_url: http://registry.staging
+    reconciler_url: http://reconciler.staging
+    nats_url: nats://nats.staging:4222
+    api_token: trusted
+    admin_token: secret
+  production:
+    api_url: http://api.production
+`
+		require.NoError(t, ioutil.WriteFile(path, []byte(content), 0600))
+
+		cfg, err := Load(path)
+		require.NoError(t, err)
+
+		p, err := cfg.Get("")
+		require.NoError(t, err)
+		expected := Profile{
+			APIURL:        "http://api.staging",
+			RegistryURL:   "http://registry.staging",
+			ReconcilerURL: "http://reconciler.staging",
+			NATSURL:       "nats://nats.staging:4222",
+			APIToken:      "trusted",
+			AdminToken:    "secret",
+		}
+		assert.Equal(t, expected, p)
+
+		p, err = cfg.Get("production")
+		require.NoError(t, err)
+		assert.Equal(t, "http://api.production", p.APIURL)
+
+		_, err = cfg.Get("dev")
+		assert.EqualError(t, err, `profile "dev" not found`)
+
+		assert.Equal(t, []string{"production", "staging"}, cfg.Names())
+	})
+
+	t.Run("with invalid file", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "lendoctl.yaml")
+		require.NoError(t, ioutil.WriteFile(path, []byte("profiles: [staging"), 0600))
+
+		_, err := Load(path)
+
+		assert.Error(t, err)
+	})
+}
+
+func TestConfig_Save(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "lendoctl.yaml")
+	cfg := Config{
+		Current: "staging",
+		Profiles: map[string]Profile{
+			"staging": {APIURL: "http://api.staging", AdminToken: "secret"},
+		},
+	}
+
+	require.NoError(t, cfg.Save(path))
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	loaded, err := Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, cfg, loaded)
+}
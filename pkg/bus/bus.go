@@ -0,0 +1,63 @@
+// Package bus abstracts a message bus the services talk over, so that handlers don't depend on a transport.
+// NATS implements it in pkg/nats, Memory implements it in a single process.
+package bus
+
+import (
+	"net/http"
+)
+
+// Msg is a message published to or received from a bus.
+type Msg struct {
+	Subject string
+	Header  http.Header
+	Data    []byte
+
+	// Acker is set by the transport to a received message.
+	Acker Acker
+}
+
+// Acker acknowledges a received message.
+type Acker interface {
+	Ack() error
+	Nak() error
+}
+
+// Ack tells the transport the message is processed.
+func (m *Msg) Ack() error {
+	if m.Acker == nil {
+		return nil
+	}
+	return m.Acker.Ack()
+}
+
+// Nak tells the transport the message is not processed and may be redelivered.
+func (m *Msg) Nak() error {
+	if m.Acker == nil {
+		return nil
+	}
+	return m.Acker.Nak()
+}
+
+type Publisher interface {
+	PublishMsg(msg *Msg) error
+}
+
+// MsgHandler is called with every message of a subscription.
+type MsgHandler func(msg *Msg)
+
+type Subscriber interface {
+	// QueueSubscribe subscribes to messages of the subject, each message is delivered to one of the
+	// subscribers of the queue.
+	QueueSubscribe(subject, queue string, handler MsgHandler) (Subscription, error)
+}
+
+type Subscription interface {
+	// Drain unsubscribes letting the received messages be processed.
+	Drain() error
+	IsValid() bool
+}
+
+type Bus interface {
+	Publisher
+	Subscriber
+}
@@ -0,0 +1,155 @@
+package bus
+
+import (
+	"context"
+	"github.com/ivanovaleksey/lendo/pkg/tracing"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"sync"
+	"time"
+)
+
+const defaultCheckInterval = time.Second
+
+type Consumer struct {
+	bus           Subscriber
+	componentName string
+
+	queue   string
+	subject string
+	subsMu  sync.Mutex
+	subs    Subscription
+
+	handler Handler
+	logger  log.FieldLogger
+
+	checkInterval time.Duration
+	stop          chan struct{}
+	stopOnce      sync.Once
+}
+
+type Handler interface {
+	Handle(ctx context.Context, msg *Msg) error
+}
+
+func NewConsumer(opts ...ConsumerOption) *Consumer {
+	c := &Consumer{
+		checkInterval: defaultCheckInterval,
+		stop:          make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	c.logger = log.WithField("component", c.componentName)
+	return c
+}
+
+// Run subscribes and blocks until ctx is done or the consumer is closed.
+// It fails when the subscription becomes invalid, e.g. the connection is closed, so that it's subscribed again.
+func (c *Consumer) Run(ctx context.Context) error {
+	subs, err := c.subscribe()
+	if err != nil || subs == nil {
+		return err
+	}
+
+	ticker := time.NewTicker(c.checkInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if subs.IsValid() {
+				continue
+			}
+			select {
+			case <-c.stop:
+				return nil
+			default:
+				return errors.New("subscription is not valid")
+			}
+		case <-c.stop:
+			return nil
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+// subscribe returns nil when the consumer is already closed, otherwise the subscription is drained by Close.
+func (c *Consumer) subscribe() (Subscription, error) {
+	c.subsMu.Lock()
+	defer c.subsMu.Unlock()
+
+	select {
+	case <-c.stop:
+		return nil, nil
+	default:
+	}
+
+	subs, err := c.bus.QueueSubscribe(c.subject, c.queue, c.handle)
+	if err != nil {
+		return nil, err
+	}
+	c.subs = subs
+	return subs, nil
+}
+
+// handle acks a message handled without an error and naks it otherwise.
+func (c *Consumer) handle(msg *Msg) {
+	messagesProcessed.WithLabelValues(c.componentName).Inc()
+
+	ctx, span := tracing.StartConsume(context.Background(), msg.Subject, msg.Header)
+	err := c.safeHandle(ctx, msg)
+	tracing.End(span, err)
+
+	if err != nil {
+		messagesFailed.WithLabelValues(c.componentName).Inc()
+		c.logger.Error(err)
+		if err := msg.Nak(); err != nil {
+			c.logger.Error(errors.Wrap(err, "can't nak message"))
+		}
+		return
+	}
+	if err := msg.Ack(); err != nil {
+		c.logger.Error(errors.Wrap(err, "can't ack message"))
+	}
+}
+
+// safeHandle turns a panic of the handler into an error, so that the message is nak'ed instead of killing the process.
+func (c *Consumer) safeHandle(ctx context.Context, msg *Msg) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = errors.Errorf("panic: %v", r)
+		}
+	}()
+	return c.handler.Handle(ctx, msg)
+}
+
+func (c *Consumer) Close() error {
+	c.stopOnce.Do(func() { close(c.stop) })
+
+	c.subsMu.Lock()
+	defer c.subsMu.Unlock()
+	if c.subs == nil {
+		return nil
+	}
+	return c.subs.Drain()
+}
+
+func (c *Consumer) CheckHealth(ctx context.Context) error {
+	c.subsMu.Lock()
+	subs := c.subs
+	c.subsMu.Unlock()
+
+	if subs == nil {
+		return errors.New("not subscribed")
+	}
+	if !subs.IsValid() {
+		return errors.New("subscription is not valid")
+	}
+	return nil
+}
+
+func (c *Consumer) ComponentName() string {
+	return c.componentName
+}
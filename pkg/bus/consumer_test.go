@@ -0,0 +1,147 @@
+package bus
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestConsumer(t *testing.T) {
+	t.Run("should handle messages of subject", func(t *testing.T) {
+		b := NewMemory()
+		defer b.Close()
+
+		handler := &fakeHandler{received: make(chan *Msg, 10)}
+		consumer := newTestConsumer(b, handler)
+		errs := runConsumer(t, consumer)
+		assert.NoError(t, consumer.CheckHealth(context.Background()))
+
+		require.NoError(t, b.PublishMsg(&Msg{Subject: "applications.new", Data: []byte("a")}))
+		require.NoError(t, consumer.Close())
+		require.NoError(t, <-errs)
+
+		require.Len(t, handler.received, 1)
+		assert.Equal(t, []byte("a"), (<-handler.received).Data)
+		assert.Error(t, consumer.CheckHealth(context.Background()))
+	})
+
+	t.Run("should nak message on error", func(t *testing.T) {
+		b := NewMemory(WithMaxDeliver(2))
+		defer b.Close()
+
+		handler := &fakeHandler{received: make(chan *Msg, 10), err: errors.New("failed")}
+		consumer := newTestConsumer(b, handler)
+		runConsumer(t, consumer)
+
+		require.NoError(t, b.PublishMsg(&Msg{Subject: "applications.new"}))
+
+		deadline := time.After(timeout)
+		for i := 0; i < 2; i++ {
+			select {
+			case <-handler.received:
+			case <-deadline:
+				t.Fatal("message is not redelivered")
+			}
+		}
+		require.NoError(t, consumer.Close())
+	})
+
+	t.Run("should nak message when handler panics", func(t *testing.T) {
+		b := NewMemory(WithMaxDeliver(2))
+		defer b.Close()
+
+		handler := &fakeHandler{received: make(chan *Msg, 10), panics: true}
+		consumer := newTestConsumer(b, handler)
+		runConsumer(t, consumer)
+
+		require.NoError(t, b.PublishMsg(&Msg{Subject: "applications.new"}))
+
+		deadline := time.After(timeout)
+		for i := 0; i < 2; i++ {
+			select {
+			case <-handler.received:
+			case <-deadline:
+				t.Fatal("message is not redelivered")
+			}
+		}
+		assert.NoError(t, consumer.CheckHealth(context.Background()))
+		require.NoError(t, consumer.Close())
+	})
+
+	t.Run("should stop when context is done", func(t *testing.T) {
+		b := NewMemory()
+		defer b.Close()
+
+		consumer := newTestConsumer(b, &fakeHandler{})
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		err := consumer.Run(ctx)
+
+		require.NoError(t, err)
+		require.NoError(t, consumer.Close())
+	})
+
+	t.Run("should fail when subscription is not valid", func(t *testing.T) {
+		b := NewMemory()
+
+		consumer := newTestConsumer(b, &fakeHandler{})
+		errs := runConsumer(t, consumer)
+		require.NoError(t, b.Close())
+
+		select {
+		case err := <-errs:
+			assert.EqualError(t, err, "subscription is not valid")
+		case <-time.After(timeout):
+			t.Fatal("consumer didn't fail")
+		}
+	})
+
+	t.Run("without subscription", func(t *testing.T) {
+		consumer := newTestConsumer(NewMemory(), &fakeHandler{})
+
+		assert.EqualError(t, consumer.CheckHealth(context.Background()), "not subscribed")
+	})
+}
+
+func newTestConsumer(b Subscriber, h Handler) *Consumer {
+	c := NewConsumer(
+		WithBus(b),
+		WithQueue("registry"),
+		WithSubject("applications.new"),
+		WithHandler(h),
+		WithComponentName("consumer.applications.new"),
+	)
+	c.checkInterval = time.Millisecond
+	return c
+}
+
+// runConsumer runs the consumer in background and waits for it to subscribe,
+// its error is sent to the channel once it stops.
+func runConsumer(t *testing.T, c *Consumer) <-chan error {
+	errs := make(chan error, 1)
+	go func() {
+		errs <- c.Run(context.Background())
+	}()
+	require.Eventually(t, func() bool {
+		return c.CheckHealth(context.Background()) == nil
+	}, timeout, time.Millisecond)
+	return errs
+}
+
+type fakeHandler struct {
+	received chan *Msg
+	err      error
+	panics   bool
+}
+
+func (h *fakeHandler) Handle(ctx context.Context, msg *Msg) error {
+	h.received <- msg
+	if h.panics {
+		panic("boom")
+	}
+	return h.err
+}
@@ -0,0 +1,293 @@
+package bus
+
+import (
+	"context"
+	"github.com/pkg/errors"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+const defaultMaxDeliver = 3
+
+var (
+	ErrClosed       = errors.New("bus is closed")
+	ErrAcknowledged = errors.New("message is already acknowledged")
+)
+
+// Memory is a bus delivering messages within the process, for tests and the single-process mode.
+// Subjects may have "*" and ">" wildcards like NATS ones. Unlike core NATS, a nak'ed message is redelivered
+// to its queue until it has been delivered MaxDeliver times.
+type Memory struct {
+	maxDeliver int
+
+	mu     sync.Mutex
+	subs   []*memorySub
+	next   map[string]int
+	closed bool
+}
+
+func NewMemory(opts ...MemoryOption) *Memory {
+	m := &Memory{
+		maxDeliver: defaultMaxDeliver,
+		next:       make(map[string]int),
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
+}
+
+// PublishMsg delivers the message to every subscriber without a queue and to one subscriber of every queue.
+// A message nobody is subscribed to is dropped.
+func (m *Memory) PublishMsg(msg *Msg) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.closed {
+		return ErrClosed
+	}
+
+	data := append([]byte(nil), msg.Data...)
+	queues := make(map[string]bool)
+	for _, sub := range m.subs {
+		if !matchSubject(sub.subject, msg.Subject) {
+			continue
+		}
+		if sub.queue == "" {
+			sub.deliver(msg.Subject, msg.Header, data, 1)
+			continue
+		}
+		if queues[sub.queue] {
+			continue
+		}
+		queues[sub.queue] = true
+		if member := m.pick(sub.subject, sub.queue); member != nil {
+			member.deliver(msg.Subject, msg.Header, data, 1)
+		}
+	}
+	return nil
+}
+
+func (m *Memory) QueueSubscribe(subject, queue string, handler MsgHandler) (Subscription, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.closed {
+		return nil, ErrClosed
+	}
+
+	sub := &memorySub{
+		bus:     m,
+		subject: subject,
+		queue:   queue,
+		handler: handler,
+		valid:   true,
+		wake:    make(chan struct{}, 1),
+		done:    make(chan struct{}),
+	}
+	m.subs = append(m.subs, sub)
+	go sub.run()
+	return sub, nil
+}
+
+// Close drains all the subscriptions and rejects new messages.
+func (m *Memory) Close() error {
+	m.mu.Lock()
+	m.closed = true
+	// Drain removes the subscription from m.subs in place, so it's iterated over a copy
+	subs := append([]*memorySub(nil), m.subs...)
+	m.mu.Unlock()
+
+	for _, sub := range subs {
+		_ = sub.Drain()
+	}
+	return nil
+}
+
+func (m *Memory) ComponentName() string {
+	return "bus"
+}
+
+func (m *Memory) CheckHealth(ctx context.Context) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.closed {
+		return ErrClosed
+	}
+	return nil
+}
+
+// pick returns the next subscriber of the queue in turn, it must be called under the lock.
+func (m *Memory) pick(subject, queue string) *memorySub {
+	var members []*memorySub
+	for _, sub := range m.subs {
+		if sub.subject == subject && sub.queue == queue {
+			members = append(members, sub)
+		}
+	}
+	if len(members) == 0 {
+		return nil
+	}
+
+	key := subject + " " + queue
+	i := m.next[key] % len(members)
+	m.next[key] = i + 1
+	return members[i]
+}
+
+func (m *Memory) redeliver(sub *memorySub, msg *Msg, data []byte, delivered int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.closed || delivered >= m.maxDeliver {
+		return
+	}
+	if sub.queue != "" {
+		sub = m.pick(sub.subject, sub.queue)
+		if sub == nil {
+			return
+		}
+	}
+	sub.deliver(msg.Subject, msg.Header, data, delivered+1)
+}
+
+func (m *Memory) remove(sub *memorySub) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i, s := range m.subs {
+		if s == sub {
+			m.subs = append(m.subs[:i], m.subs[i+1:]...)
+			return
+		}
+	}
+}
+
+type memorySub struct {
+	bus     *Memory
+	subject string
+	queue   string
+	handler MsgHandler
+
+	mu      sync.Mutex
+	pending []*Msg
+	valid   bool
+	wake    chan struct{}
+	done    chan struct{}
+}
+
+func (s *memorySub) deliver(subject string, header http.Header, data []byte, delivered int) {
+	msg := &Msg{
+		Subject: subject,
+		Header:  header.Clone(),
+		Data:    data,
+	}
+	msg.Acker = &memoryAcker{sub: s, msg: msg, data: data, delivered: delivered}
+
+	s.mu.Lock()
+	if !s.valid {
+		s.mu.Unlock()
+		return
+	}
+	s.pending = append(s.pending, msg)
+	s.mu.Unlock()
+	s.notify()
+}
+
+func (s *memorySub) notify() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (s *memorySub) run() {
+	defer close(s.done)
+
+	for {
+		s.mu.Lock()
+		if len(s.pending) == 0 {
+			valid := s.valid
+			s.mu.Unlock()
+			if !valid {
+				return
+			}
+			<-s.wake
+			continue
+		}
+		msg := s.pending[0]
+		s.pending = s.pending[1:]
+		s.mu.Unlock()
+
+		s.handler(msg)
+	}
+}
+
+// Drain unsubscribes and waits for the received messages to be handled, so it must not be called by the handler.
+func (s *memorySub) Drain() error {
+	s.bus.remove(s)
+
+	s.mu.Lock()
+	s.valid = false
+	s.mu.Unlock()
+	s.notify()
+
+	<-s.done
+	return nil
+}
+
+func (s *memorySub) IsValid() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.valid
+}
+
+type memoryAcker struct {
+	sub       *memorySub
+	msg       *Msg
+	data      []byte
+	delivered int
+
+	mu    sync.Mutex
+	acked bool
+}
+
+func (a *memoryAcker) Ack() error {
+	return a.ack()
+}
+
+func (a *memoryAcker) Nak() error {
+	if err := a.ack(); err != nil {
+		return err
+	}
+	a.sub.bus.redeliver(a.sub, a.msg, a.data, a.delivered)
+	return nil
+}
+
+func (a *memoryAcker) ack() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.acked {
+		return ErrAcknowledged
+	}
+	a.acked = true
+	return nil
+}
+
+// matchSubject tells whether the subject matches the pattern, where "*" matches a token and ">" matches the rest.
+func matchSubject(pattern, subject string) bool {
+	p := strings.Split(pattern, ".")
+	s := strings.Split(subject, ".")
+	for i, token := range p {
+		if token == ">" {
+			return len(s) > i
+		}
+		if i >= len(s) || token != "*" && token != s[i] {
+			return false
+		}
+	}
+	return len(p) == len(s)
+}
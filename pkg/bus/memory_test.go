@@ -0,0 +1,233 @@
+package bus
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+const timeout = time.Second
+
+func TestMemory(t *testing.T) {
+	t.Run("should deliver message to one subscriber of queue", func(t *testing.T) {
+		b := NewMemory()
+		defer b.Close()
+
+		received := make(chan string, 10)
+		for _, name := range []string{"a", "b"} {
+			name := name
+			_, err := b.QueueSubscribe("applications.new", "registry", func(msg *Msg) {
+				received <- name
+			})
+			require.NoError(t, err)
+		}
+
+		require.NoError(t, b.PublishMsg(&Msg{Subject: "applications.new"}))
+		require.NoError(t, b.PublishMsg(&Msg{Subject: "applications.new"}))
+
+		got := []string{receive(t, received), receive(t, received)}
+		assert.ElementsMatch(t, []string{"a", "b"}, got)
+		assertNothing(t, received)
+	})
+
+	t.Run("should deliver message to every queue", func(t *testing.T) {
+		b := NewMemory()
+		defer b.Close()
+
+		received := make(chan string, 10)
+		for _, queue := range []string{"api", "registry", ""} {
+			queue := queue
+			_, err := b.QueueSubscribe("applications.new", queue, func(msg *Msg) {
+				received <- queue
+			})
+			require.NoError(t, err)
+		}
+
+		require.NoError(t, b.PublishMsg(&Msg{Subject: "applications.new"}))
+
+		got := []string{receive(t, received), receive(t, received), receive(t, received)}
+		assert.ElementsMatch(t, []string{"api", "registry", ""}, got)
+	})
+
+	t.Run("should match wildcards", func(t *testing.T) {
+		b := NewMemory()
+		defer b.Close()
+
+		received := make(chan string, 10)
+		for _, subject := range []string{"applications.*", "applications.>", "applications.new", "applications"} {
+			subject := subject
+			_, err := b.QueueSubscribe(subject, "", func(msg *Msg) {
+				received <- subject
+			})
+			require.NoError(t, err)
+		}
+
+		require.NoError(t, b.PublishMsg(&Msg{Subject: "applications.new"}))
+
+		got := []string{receive(t, received), receive(t, received), receive(t, received)}
+		assert.ElementsMatch(t, []string{"applications.*", "applications.>", "applications.new"}, got)
+		assertNothing(t, received)
+	})
+
+	t.Run("should copy message", func(t *testing.T) {
+		b := NewMemory()
+		defer b.Close()
+
+		received := make(chan *Msg, 1)
+		_, err := b.QueueSubscribe("applications.new", "", func(msg *Msg) {
+			received <- msg
+		})
+		require.NoError(t, err)
+
+		msg := &Msg{Subject: "applications.new", Header: http.Header{}, Data: []byte("a")}
+		msg.Header.Set("Content-Type", "text/plain")
+		require.NoError(t, b.PublishMsg(msg))
+		msg.Header.Set("Content-Type", "application/json")
+		msg.Data[0] = 'b'
+
+		got := <-received
+		assert.Equal(t, "text/plain", got.Header.Get("Content-Type"))
+		assert.Equal(t, []byte("a"), got.Data)
+	})
+
+	t.Run("should redeliver nak'ed message", func(t *testing.T) {
+		b := NewMemory(WithMaxDeliver(3))
+		defer b.Close()
+
+		var mu sync.Mutex
+		deliveries := 0
+		done := make(chan struct{})
+		_, err := b.QueueSubscribe("applications.new", "registry", func(msg *Msg) {
+			mu.Lock()
+			deliveries++
+			n := deliveries
+			mu.Unlock()
+
+			if n < 2 {
+				assert.NoError(t, msg.Nak())
+				return
+			}
+			assert.NoError(t, msg.Ack())
+			close(done)
+		})
+		require.NoError(t, err)
+
+		require.NoError(t, b.PublishMsg(&Msg{Subject: "applications.new"}))
+
+		select {
+		case <-done:
+		case <-time.After(timeout):
+			t.Fatal("message is not redelivered")
+		}
+	})
+
+	t.Run("should stop redelivery after max deliveries", func(t *testing.T) {
+		b := NewMemory(WithMaxDeliver(2))
+		defer b.Close()
+
+		received := make(chan string, 10)
+		_, err := b.QueueSubscribe("applications.new", "", func(msg *Msg) {
+			received <- msg.Subject
+			_ = msg.Nak()
+		})
+		require.NoError(t, err)
+
+		require.NoError(t, b.PublishMsg(&Msg{Subject: "applications.new"}))
+
+		receive(t, received)
+		receive(t, received)
+		assertNothing(t, received)
+	})
+
+	t.Run("should acknowledge message once", func(t *testing.T) {
+		b := NewMemory()
+		defer b.Close()
+
+		errs := make(chan error, 1)
+		_, err := b.QueueSubscribe("applications.new", "", func(msg *Msg) {
+			_ = msg.Ack()
+			errs <- msg.Nak()
+		})
+		require.NoError(t, err)
+
+		require.NoError(t, b.PublishMsg(&Msg{Subject: "applications.new"}))
+
+		assert.Equal(t, ErrAcknowledged, <-errs)
+	})
+
+	t.Run("drain should let received messages be handled", func(t *testing.T) {
+		b := NewMemory()
+		defer b.Close()
+
+		var mu sync.Mutex
+		handled := 0
+		subs, err := b.QueueSubscribe("applications.new", "", func(msg *Msg) {
+			time.Sleep(time.Millisecond)
+			mu.Lock()
+			handled++
+			mu.Unlock()
+		})
+		require.NoError(t, err)
+
+		for i := 0; i < 5; i++ {
+			require.NoError(t, b.PublishMsg(&Msg{Subject: "applications.new"}))
+		}
+		require.NoError(t, subs.Drain())
+
+		assert.Equal(t, 5, handled)
+		assert.False(t, subs.IsValid())
+		require.NoError(t, b.PublishMsg(&Msg{Subject: "applications.new"}))
+		assert.Equal(t, 5, handled)
+	})
+
+	t.Run("close should drain every subscription", func(t *testing.T) {
+		b := NewMemory()
+
+		var subs []Subscription
+		for _, subject := range []string{"applications.new", "applications.changed", "applications.>"} {
+			sub, err := b.QueueSubscribe(subject, "", func(msg *Msg) {})
+			require.NoError(t, err)
+			subs = append(subs, sub)
+		}
+
+		require.NoError(t, b.Close())
+
+		for _, sub := range subs {
+			assert.False(t, sub.IsValid())
+		}
+	})
+
+	t.Run("when closed", func(t *testing.T) {
+		b := NewMemory()
+		require.NoError(t, b.Close())
+
+		assert.Equal(t, ErrClosed, b.PublishMsg(&Msg{Subject: "applications.new"}))
+		_, err := b.QueueSubscribe("applications.new", "", func(msg *Msg) {})
+		assert.Equal(t, ErrClosed, err)
+		assert.Equal(t, ErrClosed, b.CheckHealth(context.Background()))
+	})
+}
+
+func receive(t *testing.T, ch <-chan string) string {
+	t.Helper()
+	select {
+	case v := <-ch:
+		return v
+	case <-time.After(timeout):
+		t.Fatal("message is not delivered")
+		return ""
+	}
+}
+
+func assertNothing(t *testing.T, ch <-chan string) {
+	t.Helper()
+	select {
+	case v := <-ch:
+		t.Errorf("unexpected message to %q", v)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
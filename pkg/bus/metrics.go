@@ -0,0 +1,23 @@
+package bus
+
+import (
+	"github.com/ivanovaleksey/lendo/pkg/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	messagesProcessed = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metrics.Namespace,
+		Subsystem: "consumer",
+		Name:      "messages_processed_total",
+		Help:      "Number of messages processed by a consumer.",
+	}, []string{"consumer"})
+
+	messagesFailed = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metrics.Namespace,
+		Subsystem: "consumer",
+		Name:      "messages_failed_total",
+		Help:      "Number of messages a consumer failed to handle.",
+	}, []string{"consumer"})
+)
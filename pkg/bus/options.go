@@ -1,4 +1,4 @@
-package nats
+package bus
 
 type ConsumerOption func(consumer *Consumer)
 
@@ -14,9 +14,9 @@ func WithSubject(s string) ConsumerOption {
 	}
 }
 
-func WithClient(cli *Client) ConsumerOption {
+func WithBus(b Subscriber) ConsumerOption {
 	return func(c *Consumer) {
-		c.client = cli
+		c.bus = b
 	}
 }
 
@@ -31,3 +31,12 @@ func WithComponentName(name string) ConsumerOption {
 		c.componentName = name
 	}
 }
+
+type MemoryOption func(*Memory)
+
+// WithMaxDeliver sets how many times a message is delivered while it's nak'ed.
+func WithMaxDeliver(n int) MemoryOption {
+	return func(m *Memory) {
+		m.maxDeliver = n
+	}
+}
@@ -0,0 +1,95 @@
+// Package cli builds the command-line interface of the service binaries.
+//
+// Every setting comes from a flag, an environment variable or a YAML file, in this order of precedence,
+// and falls back to its default. Flags and file keys are derived from the environment variables,
+// e.g. LENDO_POLLER_DRAIN_TIMEOUT is --poller-drain-timeout and "poller: {drain_timeout: ...}".
+package cli
+
+import (
+	"fmt"
+	"github.com/spf13/cobra"
+	"os"
+	"runtime"
+	"strings"
+)
+
+// Version of the binaries, it's set on build by the Makefile.
+var Version = "dev"
+
+// ConfigFlag is the flag of the YAML config file, LENDO_CONFIG sets it too.
+const ConfigFlag = "config"
+
+// NewRoot returns the root command of a binary, it runs run when no subcommand is given.
+func NewRoot(name string, cfg interface{}, run func(cmd *cobra.Command) error) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:           name,
+		Args:          cobra.NoArgs,
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return run(cmd)
+		},
+	}
+	cmd.CompletionOptions.DisableDefaultCmd = true
+	cmd.PersistentFlags().StringP(ConfigFlag, "c", os.Getenv(strings.ToUpper(EnvPrefix+"_"+ConfigFlag)), "YAML config file")
+	BindFlags(cmd.Flags(), cfg)
+	return cmd
+}
+
+// NewServeCommand returns a command running run with the settings of cfg given by flags.
+func NewServeCommand(use, short string, cfg interface{}, run func(cmd *cobra.Command) error) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   use,
+		Short: short,
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return run(cmd)
+		},
+	}
+	BindFlags(cmd.Flags(), cfg)
+	return cmd
+}
+
+// LoadConfig fills cfg from the flags of the command, the environment and the config file.
+func LoadConfig(cmd *cobra.Command, cfg interface{}) error {
+	file, err := cmd.Flags().GetString(ConfigFlag)
+	if err != nil {
+		return err
+	}
+	return Load(cfg, cmd.Flags(), file)
+}
+
+// NewConfigCommand returns the "config print" command, it prints cfg as it's loaded.
+func NewConfigCommand(cfg interface{}) *cobra.Command {
+	print := &cobra.Command{
+		Use:   "print",
+		Short: "Print the effective config as YAML, secrets are masked",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			if err := LoadConfig(cmd, cfg); err != nil {
+				return err
+			}
+			return Print(cmd.OutOrStdout(), cfg)
+		},
+	}
+	BindFlags(print.Flags(), cfg)
+
+	cmd := &cobra.Command{
+		Use:   "config",
+		Short: "Inspect the config",
+	}
+	cmd.AddCommand(print)
+	return cmd
+}
+
+// NewVersionCommand returns the "version" command.
+func NewVersionCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "version",
+		Short: "Print the version",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, _ []string) {
+			fmt.Fprintf(cmd.OutOrStdout(), "%s %s %s/%s\n", Version, runtime.Version(), runtime.GOOS, runtime.GOARCH)
+		},
+	}
+}
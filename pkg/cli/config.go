@@ -0,0 +1,446 @@
+package cli
+
+import (
+	"encoding"
+	"fmt"
+	"github.com/kelseyhightower/envconfig"
+	"github.com/pkg/errors"
+	"github.com/spf13/pflag"
+	"gopkg.in/yaml.v3"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// EnvPrefix is the prefix of the environment variables of all the services.
+const EnvPrefix = "lendo"
+
+const masked = "******"
+
+var (
+	gatherRegexp  = regexp.MustCompile("([^A-Z]+|[A-Z]+[^A-Z]+|[A-Z]+)")
+	acronymRegexp = regexp.MustCompile("([A-Z]+)([A-Z][^A-Z]+)")
+	secretRegexp  = regexp.MustCompile("(SECRET|TOKEN|PASSWORD)$")
+)
+
+// setting is a config field named the way envconfig names it.
+type setting struct {
+	// Name is the field name, e.g. DrainTimeout.
+	Name string
+	// Key is the environment variable, e.g. LENDO_POLLER_DRAIN_TIMEOUT.
+	Key string
+	// Alt is the variable envconfig falls back to for a field with the envconfig tag, e.g. URL.
+	Alt string
+	// Path is the YAML path, e.g. poller.drain_timeout.
+	Path  []string
+	Field reflect.Value
+	Tags  reflect.StructTag
+}
+
+// Flag is the flag name of the setting, e.g. --poller-drain-timeout.
+func (s setting) Flag() string {
+	return strings.ReplaceAll(strings.Join(s.Path, "-"), "_", "-")
+}
+
+// BindFlags adds a flag for every setting of cfg, e.g. --db-url for LENDO_DB_URL.
+func BindFlags(flags *pflag.FlagSet, cfg interface{}) {
+	for _, s := range settings(cfg) {
+		usage := fmt.Sprintf("overrides %s", s.Key)
+		if def, ok := s.Tags.Lookup("default"); ok {
+			usage += fmt.Sprintf(" (default %q)", def)
+		}
+		flags.String(s.Flag(), "", usage)
+		if s.Field.Kind() == reflect.Bool {
+			flags.Lookup(s.Flag()).NoOptDefVal = "true"
+		}
+	}
+}
+
+// Load fills cfg from the flags bound by BindFlags, the environment and the YAML file if it's given,
+// in the order of precedence. Defaults apply to the settings set nowhere.
+//
+// The file mirrors the environment: "db: {url: ...}" and "db_url: ..." both set LENDO_DB_URL.
+// Every source is decoded the way envconfig decodes the environment, which is left untouched.
+func Load(cfg interface{}, flags *pflag.FlagSet, file string) error {
+	all := settings(cfg)
+
+	var values map[string]string
+	if file != "" {
+		var err error
+		values, err = readFile(file, all)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, s := range all {
+		value, ok := lookup(s, flags, values)
+		if def := s.Tags.Get("default"); !ok && def != "" {
+			value, ok = def, true
+		}
+		if !ok {
+			if required, _ := strconv.ParseBool(s.Tags.Get("required")); required {
+				key := s.Key
+				if s.Alt != "" {
+					key = s.Alt
+				}
+				return errors.Errorf("required key %s missing value", key)
+			}
+			continue
+		}
+
+		if err := decode(value, s.Field); err != nil {
+			return &envconfig.ParseError{
+				KeyName:   s.Key,
+				FieldName: s.Name,
+				TypeName:  s.Field.Type().String(),
+				Value:     value,
+				Err:       err,
+			}
+		}
+	}
+	return nil
+}
+
+// lookup finds the value of the setting in the order of precedence: the flag, the environment and the file.
+func lookup(s setting, flags *pflag.FlagSet, file map[string]string) (string, bool) {
+	if flags != nil {
+		if f := flags.Lookup(s.Flag()); f != nil && f.Changed {
+			return f.Value.String(), true
+		}
+	}
+	if value, ok := os.LookupEnv(s.Key); ok {
+		return value, true
+	}
+	if s.Alt != "" {
+		if value, ok := os.LookupEnv(s.Alt); ok {
+			return value, true
+		}
+	}
+	value, ok := file[s.Key]
+	return value, ok
+}
+
+// Print writes cfg as a YAML file which Load accepts, secrets and passwords of URLs are masked.
+func Print(w io.Writer, cfg interface{}) error {
+	root := &yaml.Node{Kind: yaml.MappingNode}
+	for _, s := range settings(cfg) {
+		node := root
+		for _, name := range s.Path[:len(s.Path)-1] {
+			node = child(node, name)
+		}
+		value := format(s.Field)
+		if value != "" && secretRegexp.MatchString(s.Key) {
+			value = masked
+		}
+		if u, err := url.Parse(value); err == nil && u.User != nil {
+			if _, ok := u.User.Password(); ok {
+				value = u.Redacted()
+			}
+		}
+		node.Content = append(node.Content,
+			&yaml.Node{Kind: yaml.ScalarNode, Value: s.Path[len(s.Path)-1]},
+			&yaml.Node{Kind: yaml.ScalarNode, Value: value, Style: style(s.Field, value)},
+		)
+	}
+
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(&yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{root}}); err != nil {
+		return errors.Wrap(err, "can't encode config")
+	}
+	return enc.Close()
+}
+
+// settings lists the fields of cfg like envconfig does.
+func settings(cfg interface{}) []setting {
+	return gather(strings.ToUpper(EnvPrefix), nil, reflect.ValueOf(cfg).Elem())
+}
+
+func gather(prefix string, path []string, v reflect.Value) []setting {
+	var result []setting
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field, ftype := v.Field(i), t.Field(i)
+		if !field.CanSet() || ftype.Tag.Get("ignored") == "true" {
+			continue
+		}
+
+		name := ftype.Tag.Get("envconfig")
+		if name == "" {
+			name = ftype.Name
+			if ftype.Tag.Get("split_words") == "true" {
+				name = splitWords(name)
+			}
+		}
+		key := strings.ToUpper(prefix + "_" + name)
+		fieldPath := append(append([]string(nil), path...), strings.ToLower(name))
+
+		if field.Kind() == reflect.Struct && !decodable(field) {
+			innerPrefix, innerPath := key, fieldPath
+			if ftype.Anonymous {
+				innerPrefix, innerPath = prefix, path
+			}
+			result = append(result, gather(innerPrefix, innerPath, field)...)
+			continue
+		}
+		result = append(result, setting{
+			Name:  ftype.Name,
+			Key:   key,
+			Alt:   strings.ToUpper(ftype.Tag.Get("envconfig")),
+			Path:  fieldPath,
+			Field: field,
+			Tags:  ftype.Tag,
+		})
+	}
+	return result
+}
+
+func splitWords(name string) string {
+	var words []string
+	for _, match := range gatherRegexp.FindAllStringSubmatch(name, -1) {
+		if m := acronymRegexp.FindStringSubmatch(match[0]); len(m) == 3 {
+			words = append(words, m[1], m[2])
+		} else {
+			words = append(words, match[0])
+		}
+	}
+	return strings.Join(words, "_")
+}
+
+// decodable tells if a struct is set as a whole rather than by its fields.
+func decodable(v reflect.Value) bool {
+	if !v.CanAddr() {
+		return false
+	}
+	switch v.Addr().Interface().(type) {
+	case envconfig.Decoder, envconfig.Setter, encoding.TextUnmarshaler, encoding.BinaryUnmarshaler:
+		return true
+	}
+	return false
+}
+
+// readFile maps the YAML file to the environment variables of the settings.
+func readFile(file string, all []setting) (map[string]string, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, errors.Wrap(err, "can't read config file")
+	}
+	var doc map[string]interface{}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, errors.Wrapf(err, "can't parse config file %s", file)
+	}
+
+	known := make(map[string]bool, len(all))
+	for _, s := range all {
+		known[s.Key] = true
+	}
+	values := make(map[string]string)
+	if err := flatten(strings.ToUpper(EnvPrefix), doc, known, values); err != nil {
+		return nil, errors.Wrapf(err, "invalid config file %s", file)
+	}
+	return values, nil
+}
+
+func flatten(prefix string, doc map[string]interface{}, known map[string]bool, values map[string]string) error {
+	for name, value := range doc {
+		key := strings.ToUpper(prefix + "_" + name)
+		if known[key] {
+			values[key] = scalar(value)
+			continue
+		}
+		nested, ok := value.(map[string]interface{})
+		if !ok {
+			return errors.Errorf("unknown setting %s", strings.ToLower(strings.TrimPrefix(key, strings.ToUpper(EnvPrefix)+"_")))
+		}
+		if err := flatten(key, nested, known, values); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// scalar formats a YAML value the way envconfig parses it, lists are comma separated and maps are key:value pairs.
+func scalar(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case []interface{}:
+		items := make([]string, 0, len(v))
+		for _, item := range v {
+			items = append(items, scalar(item))
+		}
+		return strings.Join(items, ",")
+	case map[string]interface{}:
+		pairs := make([]string, 0, len(v))
+		for k, item := range v {
+			pairs = append(pairs, k+":"+scalar(item))
+		}
+		sort.Strings(pairs)
+		return strings.Join(pairs, ",")
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// format formats a field the way envconfig parses it.
+func format(v reflect.Value) string {
+	if m, ok := v.Interface().(encoding.TextMarshaler); ok {
+		text, err := m.MarshalText()
+		if err == nil {
+			return string(text)
+		}
+	}
+	if d, ok := v.Interface().(time.Duration); ok {
+		return d.String()
+	}
+
+	switch v.Kind() {
+	case reflect.Slice:
+		items := make([]string, 0, v.Len())
+		for i := 0; i < v.Len(); i++ {
+			items = append(items, format(v.Index(i)))
+		}
+		return strings.Join(items, ",")
+	case reflect.Map:
+		pairs := make([]string, 0, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			pairs = append(pairs, format(iter.Key())+":"+format(iter.Value()))
+		}
+		sort.Strings(pairs)
+		return strings.Join(pairs, ",")
+	default:
+		return fmt.Sprint(v.Interface())
+	}
+}
+
+// decode parses a value into a field the way envconfig does, it's the inverse of format.
+func decode(value string, field reflect.Value) error {
+	if field.CanAddr() {
+		switch v := field.Addr().Interface().(type) {
+		case envconfig.Decoder:
+			return v.Decode(value)
+		case envconfig.Setter:
+			return v.Set(value)
+		case encoding.TextUnmarshaler:
+			return v.UnmarshalText([]byte(value))
+		case encoding.BinaryUnmarshaler:
+			return v.UnmarshalBinary([]byte(value))
+		}
+	}
+
+	typ := field.Type()
+	if typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+		if field.IsNil() {
+			field.Set(reflect.New(typ))
+		}
+		field = field.Elem()
+	}
+
+	switch typ.Kind() {
+	case reflect.String:
+		field.SetString(value)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if typ == reflect.TypeOf(time.Duration(0)) {
+			d, err := time.ParseDuration(value)
+			if err != nil {
+				return err
+			}
+			field.SetInt(int64(d))
+			return nil
+		}
+		val, err := strconv.ParseInt(value, 0, typ.Bits())
+		if err != nil {
+			return err
+		}
+		field.SetInt(val)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		val, err := strconv.ParseUint(value, 0, typ.Bits())
+		if err != nil {
+			return err
+		}
+		field.SetUint(val)
+	case reflect.Bool:
+		val, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		field.SetBool(val)
+	case reflect.Float32, reflect.Float64:
+		val, err := strconv.ParseFloat(value, typ.Bits())
+		if err != nil {
+			return err
+		}
+		field.SetFloat(val)
+	case reflect.Slice:
+		sl := reflect.MakeSlice(typ, 0, 0)
+		if typ.Elem().Kind() == reflect.Uint8 {
+			sl = reflect.ValueOf([]byte(value))
+		} else if strings.TrimSpace(value) != "" {
+			items := strings.Split(value, ",")
+			sl = reflect.MakeSlice(typ, len(items), len(items))
+			for i, item := range items {
+				if err := decode(item, sl.Index(i)); err != nil {
+					return err
+				}
+			}
+		}
+		field.Set(sl)
+	case reflect.Map:
+		mp := reflect.MakeMap(typ)
+		if strings.TrimSpace(value) != "" {
+			for _, pair := range strings.Split(value, ",") {
+				kv := strings.Split(pair, ":")
+				if len(kv) != 2 {
+					return errors.Errorf("invalid map item: %q", pair)
+				}
+				k := reflect.New(typ.Key()).Elem()
+				if err := decode(kv[0], k); err != nil {
+					return err
+				}
+				v := reflect.New(typ.Elem()).Elem()
+				if err := decode(kv[1], v); err != nil {
+					return err
+				}
+				mp.SetMapIndex(k, v)
+			}
+		}
+		field.Set(mp)
+	}
+	return nil
+}
+
+// style quotes strings which YAML would read as another type.
+func style(v reflect.Value, value string) yaml.Style {
+	if v.Kind() != reflect.String {
+		return 0
+	}
+	var decoded interface{}
+	if err := yaml.Unmarshal([]byte(value), &decoded); err != nil || decoded == nil {
+		return yaml.DoubleQuotedStyle
+	}
+	if _, ok := decoded.(string); !ok {
+		return yaml.DoubleQuotedStyle
+	}
+	return 0
+}
+
+func child(node *yaml.Node, name string) *yaml.Node {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == name {
+			return node.Content[i+1]
+		}
+	}
+	c := &yaml.Node{Kind: yaml.MappingNode}
+	node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: name}, c)
+	return c
+}
@@ -0,0 +1,122 @@
+package client
+
+import (
+	"context"
+	"github.com/ivanovaleksey/lendo/registry/models"
+	"github.com/ivanovaleksey/lendo/registry/responses"
+	uuid "github.com/satori/go.uuid"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+const jobsPath = "/admin/jobs"
+
+// Admin calls the registry admin API. Only reads are retried, job actions are not idempotent.
+type Admin struct {
+	client *Client
+}
+
+// NewAdmin creates a client of the admin API of the registry served at baseURL, token is LENDO_ADMIN_TOKEN
+// of the registry.
+func NewAdmin(baseURL, token string, opts ...Option) (*Admin, error) {
+	client, err := New(baseURL, opts...)
+	if err != nil {
+		return nil, err
+	}
+	client.token = token
+	return &Admin{client: client}, nil
+}
+
+// JobsParams filters jobs, zero values are not sent.
+type JobsParams struct {
+	Status models.JobStatus
+	// OlderThan is the minimal age of jobs.
+	OlderThan   time.Duration
+	MinAttempts int
+	Limit       int
+	Offset      int
+}
+
+// ListJobs returns a page of jobs and the total number of them.
+func (admin *Admin) ListJobs(ctx context.Context, params JobsParams) ([]models.Job, int, error) {
+	query := make(url.Values)
+	if params.Status != "" {
+		query.Set("status", string(params.Status))
+	}
+	if params.OlderThan > 0 {
+		query.Set("older_than", params.OlderThan.String())
+	}
+	if params.MinAttempts > 0 {
+		query.Set("min_attempts", strconv.Itoa(params.MinAttempts))
+	}
+	if params.Limit > 0 {
+		query.Set("limit", strconv.Itoa(params.Limit))
+	}
+	if params.Offset > 0 {
+		query.Set("offset", strconv.Itoa(params.Offset))
+	}
+
+	var resp responses.GetJobsResponse
+	err := admin.client.do(ctx, request{
+		method: http.MethodGet,
+		path:   jobsPath,
+		query:  query,
+	}, &resp)
+	if err != nil {
+		return nil, 0, err
+	}
+	return resp.Items, resp.Total, nil
+}
+
+// GetJob returns the job with its bank exchanges and timeline.
+func (admin *Admin) GetJob(ctx context.Context, id uuid.UUID) (models.JobDetails, error) {
+	var resp responses.GetJobResponse
+	err := admin.client.do(ctx, request{
+		method: http.MethodGet,
+		path:   jobsPath + "/" + id.String(),
+	}, &resp)
+	if err != nil {
+		return models.JobDetails{}, err
+	}
+	return resp.JobDetails, nil
+}
+
+// Job actions, an action not allowed in the job status fails with ErrConflict.
+const (
+	ActionRetry   = "retry"
+	ActionCancel  = "cancel"
+	ActionFail    = "fail"
+	ActionRequeue = "requeue"
+)
+
+// ControlJob applies one of the job actions to the job.
+func (admin *Admin) ControlJob(ctx context.Context, id uuid.UUID, action string) error {
+	return admin.client.do(ctx, request{
+		method: http.MethodPost,
+		path:   jobsPath + "/" + id.String() + "/" + action,
+	}, nil)
+}
+
+// RetryFailedJobs requeues jobs failed within the range and returns their number, a zero to means now.
+func (admin *Admin) RetryFailedJobs(ctx context.Context, from, to time.Time) (int64, error) {
+	body := struct {
+		From time.Time  `json:"from"`
+		To   *time.Time `json:"to,omitempty"`
+	}{From: from}
+	if !to.IsZero() {
+		body.To = &to
+	}
+
+	var resp responses.RetryFailedJobsResponse
+	err := admin.client.do(ctx, request{
+		method: http.MethodPost,
+		path:   jobsPath + "/retry-failed",
+		body:   body,
+	}, &resp)
+	if err != nil {
+		return 0, err
+	}
+	return resp.Count, nil
+}
@@ -0,0 +1,202 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"github.com/ivanovaleksey/lendo/registry/app"
+	"github.com/ivanovaleksey/lendo/registry/config"
+	"github.com/ivanovaleksey/lendo/registry/models"
+	"github.com/ivanovaleksey/lendo/registry/services/jobs"
+	uuid "github.com/satori/go.uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+const adminToken = "secret"
+
+func TestAdmin_ListJobs(t *testing.T) {
+	fx := newAdminFixture(t, adminToken)
+	defer fx.Finish()
+
+	job := models.Job{ID: uuid.NewV4(), Status: models.JobStatusFailed, Attempts: 3}
+	fx.srv.jobs = []models.Job{job}
+
+	jobs, total, err := fx.admin.ListJobs(fx.ctx, JobsParams{
+		Status:      models.JobStatusFailed,
+		OlderThan:   time.Hour,
+		MinAttempts: 3,
+		Limit:       5,
+		Offset:      10,
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, 1, total)
+	require.Len(t, jobs, 1)
+	assert.Equal(t, job.ID, jobs[0].ID)
+
+	params := fx.srv.params
+	assert.Equal(t, models.JobStatusFailed, params.Status)
+	assert.Equal(t, 3, params.MinAttempts)
+	assert.Equal(t, 5, params.Limit)
+	assert.Equal(t, 10, params.Offset)
+	assert.WithinDuration(t, time.Now().Add(-time.Hour), params.CreatedBefore, time.Minute)
+}
+
+func TestAdmin_GetJob(t *testing.T) {
+	t.Run("when job exists", func(t *testing.T) {
+		fx := newAdminFixture(t, adminToken)
+		defer fx.Finish()
+
+		job := models.Job{ID: uuid.NewV4(), Status: models.JobStatusPending}
+		fx.srv.jobs = []models.Job{job}
+
+		details, err := fx.admin.GetJob(fx.ctx, job.ID)
+
+		require.NoError(t, err)
+		assert.Equal(t, job.ID, details.ID)
+		assert.Equal(t, job.Status, details.Status)
+	})
+
+	t.Run("when job doesn't exist", func(t *testing.T) {
+		fx := newAdminFixture(t, adminToken)
+		defer fx.Finish()
+
+		_, err := fx.admin.GetJob(fx.ctx, uuid.NewV4())
+
+		assert.True(t, errors.Is(err, ErrNotFound))
+	})
+
+	t.Run("with invalid token", func(t *testing.T) {
+		fx := newAdminFixture(t, "invalid")
+		defer fx.Finish()
+
+		_, err := fx.admin.GetJob(fx.ctx, uuid.NewV4())
+
+		assert.True(t, errors.Is(err, ErrUnauthorized))
+	})
+}
+
+func TestAdmin_ControlJob(t *testing.T) {
+	t.Run("should apply action", func(t *testing.T) {
+		fx := newAdminFixture(t, adminToken)
+		defer fx.Finish()
+
+		id := uuid.NewV4()
+
+		err := fx.admin.ControlJob(fx.ctx, id, ActionRequeue)
+
+		require.NoError(t, err)
+		assert.Equal(t, []string{ActionRequeue + " " + id.String()}, fx.srv.actions)
+	})
+
+	t.Run("when transition is invalid", func(t *testing.T) {
+		fx := newAdminFixture(t, adminToken)
+		defer fx.Finish()
+
+		fx.srv.actionErr = jobsSrv.ErrInvalidTransition
+
+		err := fx.admin.ControlJob(fx.ctx, uuid.NewV4(), ActionCancel)
+
+		assert.True(t, errors.Is(err, ErrConflict))
+		assert.Len(t, fx.srv.actions, 1)
+	})
+}
+
+func TestAdmin_RetryFailedJobs(t *testing.T) {
+	fx := newAdminFixture(t, adminToken)
+	defer fx.Finish()
+
+	from := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := from.Add(24 * time.Hour)
+	fx.srv.retried = 7
+
+	count, err := fx.admin.RetryFailedJobs(fx.ctx, from, to)
+
+	require.NoError(t, err)
+	assert.EqualValues(t, 7, count)
+	assert.True(t, from.Equal(fx.srv.from))
+	assert.True(t, to.Equal(fx.srv.to))
+}
+
+type adminFixture struct {
+	t   *testing.T
+	ctx context.Context
+
+	srv    *fakeJobsService
+	server *httptest.Server
+	admin  *Admin
+}
+
+func newAdminFixture(t *testing.T, token string) *adminFixture {
+	fx := &adminFixture{
+		t:   t,
+		ctx: context.Background(),
+		srv: &fakeJobsService{},
+	}
+	cfg := config.Config{
+		Admin: config.AdminConfig{Token: adminToken},
+	}
+	fx.server = httptest.NewServer(app.New(cfg, app.WithJobsSrv(fx.srv)))
+
+	admin, err := NewAdmin(fx.server.URL, token, WithRetries(0))
+	require.NoError(t, err)
+	fx.admin = admin
+
+	return fx
+}
+
+func (fx *adminFixture) Finish() {
+	fx.server.Close()
+}
+
+type fakeJobsService struct {
+	jobs      []models.Job
+	params    jobsSrv.GetListParams
+	actions   []string
+	actionErr error
+	from, to  time.Time
+	retried   int64
+}
+
+func (srv *fakeJobsService) GetList(ctx context.Context, params jobsSrv.GetListParams) ([]models.Job, int, error) {
+	srv.params = params
+	return srv.jobs, len(srv.jobs), nil
+}
+
+func (srv *fakeJobsService) GetByID(ctx context.Context, id uuid.UUID) (models.JobDetails, error) {
+	for _, job := range srv.jobs {
+		if job.ID == id {
+			return models.JobDetails{Job: job}, nil
+		}
+	}
+	return models.JobDetails{}, jobsSrv.ErrJobNotFound
+}
+
+func (srv *fakeJobsService) Retry(ctx context.Context, id uuid.UUID) error {
+	return srv.control(ActionRetry, id)
+}
+
+func (srv *fakeJobsService) Cancel(ctx context.Context, id uuid.UUID) error {
+	return srv.control(ActionCancel, id)
+}
+
+func (srv *fakeJobsService) Fail(ctx context.Context, id uuid.UUID) error {
+	return srv.control(ActionFail, id)
+}
+
+func (srv *fakeJobsService) Requeue(ctx context.Context, id uuid.UUID) error {
+	return srv.control(ActionRequeue, id)
+}
+
+func (srv *fakeJobsService) RetryFailed(ctx context.Context, from, to time.Time) (int64, error) {
+	srv.from, srv.to = from, to
+	return srv.retried, nil
+}
+
+func (srv *fakeJobsService) control(action string, id uuid.UUID) error {
+	srv.actions = append(srv.actions, action+" "+id.String())
+	return srv.actionErr
+}
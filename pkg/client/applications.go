@@ -0,0 +1,179 @@
+package client
+
+import (
+	"context"
+	apiModels "github.com/ivanovaleksey/lendo/api/models"
+	"github.com/ivanovaleksey/lendo/api/responses"
+	"github.com/ivanovaleksey/lendo/pkg/models"
+	uuid "github.com/satori/go.uuid"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+const applicationsPath = "/api/applications"
+
+// CreateApplication creates the application and returns its ID. Retries of the request carry the same
+// idempotency key, so that the application is created once.
+func (client *Client) CreateApplication(ctx context.Context, item models.NewApplication, opts ...CreateOption) (uuid.UUID, error) {
+	settings := createSettings{
+		idempotencyKey: uuid.NewV4().String(),
+	}
+	for _, opt := range opts {
+		opt(&settings)
+	}
+
+	header := make(http.Header)
+	header.Set(apiModels.IdempotencyKeyHeader, settings.idempotencyKey)
+	settings.metadata.Inject(header)
+
+	var resp responses.CreateApplicationResponse
+	err := client.do(ctx, request{
+		method: http.MethodPost,
+		path:   applicationsPath,
+		header: header,
+		body:   item,
+	}, &resp)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	return resp.ID, nil
+}
+
+// GetApplication returns the application, the error matches ErrNotFound when there is no such application.
+func (client *Client) GetApplication(ctx context.Context, id uuid.UUID) (models.Application, error) {
+	var resp responses.GetApplicationResponse
+	err := client.do(ctx, request{
+		method: http.MethodGet,
+		path:   applicationsPath + "/" + id.String(),
+	}, &resp)
+	if err != nil {
+		return models.Application{}, err
+	}
+	return resp.Application, nil
+}
+
+// ListParams filters applications, zero values are not sent.
+type ListParams struct {
+	Status models.ApplicationStatus
+	Limit  int
+	Offset int
+}
+
+// ListApplications returns a page of applications and the total number of them.
+func (client *Client) ListApplications(ctx context.Context, params ListParams) ([]models.Application, int, error) {
+	query := make(url.Values)
+	if params.Status != "" {
+		query.Set("status", string(params.Status))
+	}
+	if params.Limit > 0 {
+		query.Set("limit", strconv.Itoa(params.Limit))
+	}
+	if params.Offset > 0 {
+		query.Set("offset", strconv.Itoa(params.Offset))
+	}
+
+	var resp responses.GetApplicationsResponse
+	err := client.do(ctx, request{
+		method: http.MethodGet,
+		path:   applicationsPath,
+		query:  query,
+	}, &resp)
+	if err != nil {
+		return nil, 0, err
+	}
+	return resp.Items, resp.Total, nil
+}
+
+// Applications returns an iterator over all applications matching the params, starting from params.Offset.
+// Pages are of params.Limit applications.
+func (client *Client) Applications(params ListParams) *ApplicationsIterator {
+	return &ApplicationsIterator{
+		client: client,
+		params: params,
+	}
+}
+
+// ApplicationsIterator gets applications page by page:
+//
+//	it := client.Applications(params)
+//	for it.Next(ctx) {
+//	    application := it.Application()
+//	}
+//	if err := it.Err(); err != nil {
+//	}
+type ApplicationsIterator struct {
+	client *Client
+	params ListParams
+
+	page    []models.Application
+	current models.Application
+	done    bool
+	err     error
+}
+
+// Next advances to the next application, it returns false when there are no more applications or on an error.
+func (it *ApplicationsIterator) Next(ctx context.Context) bool {
+	if it.err != nil {
+		return false
+	}
+	if len(it.page) == 0 {
+		if it.done {
+			return false
+		}
+		page, total, err := it.client.ListApplications(ctx, it.params)
+		if err != nil {
+			it.err = err
+			return false
+		}
+		it.params.Offset += len(page)
+		it.done = len(page) == 0 || it.params.Offset >= total
+		it.page = page
+		if len(page) == 0 {
+			return false
+		}
+	}
+	it.current, it.page = it.page[0], it.page[1:]
+	return true
+}
+
+// Application returns the application Next advanced to.
+func (it *ApplicationsIterator) Application() models.Application {
+	return it.current
+}
+
+// Err returns the error which stopped the iteration.
+func (it *ApplicationsIterator) Err() error {
+	return it.err
+}
+
+// WatchApplication polls the application and calls fn with it on every status change, starting from the current
+// status. It returns when the status is final, fn fails or ctx is done.
+func (client *Client) WatchApplication(ctx context.Context, id uuid.UUID, fn func(models.Application) error) error {
+	ticker := time.NewTicker(client.pollInterval)
+	defer ticker.Stop()
+
+	var status models.ApplicationStatus
+	for {
+		application, err := client.GetApplication(ctx, id)
+		if err != nil {
+			return err
+		}
+		if application.Status != status {
+			status = application.Status
+			if err := fn(application); err != nil {
+				return err
+			}
+		}
+		if status.Final() {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,168 @@
+// Package client is a Go client of the api and the registry admin API, it retries failed requests
+// and maps error responses to typed errors.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	apiModels "github.com/ivanovaleksey/lendo/api/models"
+	"github.com/ivanovaleksey/lendo/api/responses"
+	"github.com/pkg/errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"path"
+	"time"
+)
+
+const (
+	defaultRetries      = 3
+	defaultBackoff      = 100 * time.Millisecond
+	defaultPollInterval = 2 * time.Second
+)
+
+// Client calls the api. Requests are retried after network errors and 5xx responses unless they may create
+// a duplicate, create requests carry an idempotency key to be retried safely.
+type Client struct {
+	baseURL      *url.URL
+	httpClient   *http.Client
+	token        string
+	retries      int
+	backoff      time.Duration
+	pollInterval time.Duration
+}
+
+// New creates a client of the api served at baseURL, e.g. "http://api:8000".
+func New(baseURL string, opts ...Option) (*Client, error) {
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, errors.Wrap(err, "can't parse url")
+	}
+	client := &Client{
+		baseURL: u,
+		httpClient: &http.Client{
+			Timeout: 5 * time.Second,
+		},
+		retries:      defaultRetries,
+		backoff:      defaultBackoff,
+		pollInterval: defaultPollInterval,
+	}
+	for _, opt := range opts {
+		opt(client)
+	}
+	return client, nil
+}
+
+type request struct {
+	method string
+	path   string
+	query  url.Values
+	header http.Header
+	body   interface{}
+}
+
+// retryable reports whether the request may be sent again without side effects.
+func (r request) retryable() bool {
+	if r.method == http.MethodGet {
+		return true
+	}
+	return r.header.Get(apiModels.IdempotencyKeyHeader) != ""
+}
+
+func (client *Client) do(ctx context.Context, r request, out interface{}) error {
+	var body []byte
+	if r.body != nil {
+		var err error
+		body, err = json.Marshal(r.body)
+		if err != nil {
+			return errors.Wrap(err, "can't encode request body")
+		}
+	}
+
+	backoff := client.backoff
+	for attempt := 0; ; attempt++ {
+		err := client.send(ctx, r, body, out)
+		if err == nil || ctx.Err() != nil || attempt >= client.retries || !r.retryable() || !temporary(err) {
+			return err
+		}
+
+		timer := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+		backoff *= 2
+	}
+}
+
+func (client *Client) send(ctx context.Context, r request, body []byte, out interface{}) error {
+	reqURL := *client.baseURL
+	reqURL.Path = path.Join(reqURL.Path, r.path)
+	reqURL.RawQuery = r.query.Encode()
+
+	var reqBody io.Reader
+	if body != nil {
+		reqBody = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, r.method, reqURL.String(), reqBody)
+	if err != nil {
+		return errors.Wrap(err, "can't create request")
+	}
+	for key, values := range r.header {
+		req.Header[key] = values
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if client.token != "" {
+		req.Header.Set("Authorization", "Bearer "+client.token)
+	}
+
+	resp, err := client.httpClient.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "can't do request")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		return decodeError(resp)
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return errors.Wrap(err, "can't decode response body")
+	}
+	return nil
+}
+
+func decodeError(resp *http.Response) error {
+	apiErr := &Error{StatusCode: resp.StatusCode}
+
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return apiErr
+	}
+	var respBody responses.ErrorResponse
+	if err := json.Unmarshal(data, &respBody); err != nil {
+		apiErr.Message = http.StatusText(resp.StatusCode)
+		return apiErr
+	}
+	apiErr.Message = respBody.Error
+	apiErr.Debug = respBody.Debug
+	return apiErr
+}
+
+// temporary reports whether the request may succeed when it's retried, that's either a network error
+// or a 5xx response.
+func temporary(err error) bool {
+	var apiErr *Error
+	if errors.As(err, &apiErr) {
+		return apiErr.temporary()
+	}
+	return true
+}
@@ -0,0 +1,327 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"github.com/brianvoe/gofakeit"
+	"github.com/ivanovaleksey/lendo/api/app"
+	"github.com/ivanovaleksey/lendo/api/config"
+	apiModels "github.com/ivanovaleksey/lendo/api/models"
+	"github.com/ivanovaleksey/lendo/api/services/applications"
+	"github.com/ivanovaleksey/lendo/pkg/models"
+	uuid "github.com/satori/go.uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestClient_CreateApplication(t *testing.T) {
+	item := models.NewApplication{
+		FirstName: gofakeit.FirstName(),
+		LastName:  gofakeit.LastName(),
+	}
+
+	t.Run("should retry with the same idempotency key", func(t *testing.T) {
+		fx := newFixture(t, WithToken(trustedToken))
+		defer fx.Finish()
+
+		var keys []string
+		fx.wrap(func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				keys = append(keys, r.Header.Get(apiModels.IdempotencyKeyHeader))
+				if len(keys) < 3 {
+					// the application is created, but the response is lost
+					next.ServeHTTP(httptest.NewRecorder(), r)
+					w.WriteHeader(http.StatusBadGateway)
+					return
+				}
+				next.ServeHTTP(w, r)
+			})
+		})
+
+		id, err := fx.client.CreateApplication(fx.ctx, item, WithSource("web"), WithPriority(5))
+
+		require.NoError(t, err)
+		require.Len(t, keys, 3)
+		assert.NotEmpty(t, keys[0])
+		assert.Equal(t, keys[0], keys[1])
+		assert.Equal(t, keys[0], keys[2])
+		require.Len(t, fx.srv.applications, 1)
+		assert.Equal(t, fx.srv.applications[0].ID, id)
+		assert.Equal(t, item, fx.srv.applications[0].NewApplication)
+		assert.Equal(t, "web", fx.srv.metadata.Source)
+		require.NotNil(t, fx.srv.metadata.Priority)
+		assert.Equal(t, 5, *fx.srv.metadata.Priority)
+	})
+
+	t.Run("should not pass metadata without trusted token", func(t *testing.T) {
+		for _, token := range []string{"", "invalid"} {
+			fx := newFixture(t, WithToken(token))
+
+			_, err := fx.client.CreateApplication(fx.ctx, item, WithSource("web"), WithPriority(5))
+
+			require.NoError(t, err)
+			assert.Equal(t, models.Metadata{}, fx.srv.metadata)
+			fx.Finish()
+		}
+	})
+
+	t.Run("should give up after retries", func(t *testing.T) {
+		fx := newFixture(t)
+		defer fx.Finish()
+
+		var calls int
+		fx.wrap(func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls++
+				w.WriteHeader(http.StatusServiceUnavailable)
+			})
+		})
+
+		_, err := fx.client.CreateApplication(fx.ctx, item)
+
+		assert.True(t, errors.Is(err, &Error{StatusCode: http.StatusServiceUnavailable}))
+		assert.Equal(t, 3, calls)
+	})
+
+	t.Run("should not retry bad request", func(t *testing.T) {
+		fx := newFixture(t)
+		defer fx.Finish()
+
+		var calls int
+		fx.wrap(func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls++
+				next.ServeHTTP(w, r)
+			})
+		})
+
+		_, err := fx.client.CreateApplication(fx.ctx, models.NewApplication{})
+
+		require.Error(t, err)
+		assert.True(t, errors.Is(err, ErrBadRequest))
+		var apiErr *Error
+		require.True(t, errors.As(err, &apiErr))
+		assert.Equal(t, "bad request", apiErr.Message)
+		assert.NotEmpty(t, apiErr.Debug)
+		assert.Equal(t, 1, calls)
+	})
+}
+
+func TestClient_GetApplication(t *testing.T) {
+	t.Run("when application exists", func(t *testing.T) {
+		fx := newFixture(t)
+		defer fx.Finish()
+
+		application := fx.srv.add(models.ApplicationStatusPending)
+
+		got, err := fx.client.GetApplication(fx.ctx, application.ID)
+
+		require.NoError(t, err)
+		assert.Equal(t, application, got)
+	})
+
+	t.Run("when application doesn't exist", func(t *testing.T) {
+		fx := newFixture(t)
+		defer fx.Finish()
+
+		_, err := fx.client.GetApplication(fx.ctx, uuid.NewV4())
+
+		assert.True(t, errors.Is(err, ErrNotFound))
+		assert.False(t, errors.Is(err, ErrInternal))
+	})
+}
+
+func TestClient_Applications(t *testing.T) {
+	fx := newFixture(t)
+	defer fx.Finish()
+
+	var expected []models.Application
+	for i := 0; i < 5; i++ {
+		application := fx.srv.add(models.ApplicationStatusNew)
+		expected = append(expected, application)
+	}
+	fx.srv.add(models.ApplicationStatusCompleted)
+
+	var got []models.Application
+	it := fx.client.Applications(ListParams{Status: models.ApplicationStatusNew, Limit: 2})
+	for it.Next(fx.ctx) {
+		got = append(got, it.Application())
+	}
+
+	require.NoError(t, it.Err())
+	assert.Equal(t, expected, got)
+	assert.Equal(t, []int{0, 2, 4}, fx.srv.offsets)
+}
+
+func TestClient_WatchApplication(t *testing.T) {
+	fx := newFixture(t)
+	defer fx.Finish()
+
+	application := fx.srv.add(models.ApplicationStatusNew)
+	statuses := []models.ApplicationStatus{
+		models.ApplicationStatusNew,
+		models.ApplicationStatusPending,
+		models.ApplicationStatusPending,
+		models.ApplicationStatusCompleted,
+	}
+	fx.wrap(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			fx.srv.setStatus(application.ID, statuses[0])
+			if len(statuses) > 1 {
+				statuses = statuses[1:]
+			}
+			next.ServeHTTP(w, r)
+		})
+	})
+
+	var got []models.ApplicationStatus
+	err := fx.client.WatchApplication(fx.ctx, application.ID, func(application models.Application) error {
+		got = append(got, application.Status)
+		return nil
+	})
+
+	require.NoError(t, err)
+	expected := []models.ApplicationStatus{
+		models.ApplicationStatusNew,
+		models.ApplicationStatusPending,
+		models.ApplicationStatusCompleted,
+	}
+	assert.Equal(t, expected, got)
+}
+
+type testFixture struct {
+	t   *testing.T
+	ctx context.Context
+
+	srv     *fakeService
+	handler http.Handler
+	server  *httptest.Server
+	client  *Client
+}
+
+const trustedToken = "trusted"
+
+func newFixture(t *testing.T, opts ...Option) *testFixture {
+	fx := &testFixture{
+		t:   t,
+		ctx: context.Background(),
+		srv: &fakeService{keys: make(map[string]uuid.UUID)},
+	}
+	cfg := config.Config{
+		TrustedTokens: []string{trustedToken},
+	}
+	fx.handler = app.New(cfg, app.WithApplicationsSrv(fx.srv))
+	fx.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fx.handler.ServeHTTP(w, r)
+	}))
+
+	opts = append([]Option{WithRetries(2), WithBackoff(time.Millisecond), WithPollInterval(time.Millisecond)}, opts...)
+	client, err := New(fx.server.URL, opts...)
+	require.NoError(t, err)
+	fx.client = client
+
+	return fx
+}
+
+// wrap puts a middleware in front of the api, e.g. to fail some requests.
+func (fx *testFixture) wrap(middleware func(http.Handler) http.Handler) {
+	fx.handler = middleware(fx.handler)
+}
+
+func (fx *testFixture) Finish() {
+	fx.server.Close()
+}
+
+// fakeService keeps applications in memory the way the applications service keeps them in the DB.
+type fakeService struct {
+	mu           sync.Mutex
+	applications []models.Application
+	keys         map[string]uuid.UUID
+	metadata     models.Metadata
+	offsets      []int
+}
+
+func (srv *fakeService) GetList(ctx context.Context, params applicationsSrv.GetListParams) ([]models.Application, int, error) {
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+
+	srv.offsets = append(srv.offsets, params.Offset)
+
+	var filtered []models.Application
+	for _, application := range srv.applications {
+		if params.Status == "" || string(application.Status) == params.Status {
+			filtered = append(filtered, application)
+		}
+	}
+	total := len(filtered)
+	if params.Offset >= total {
+		return []models.Application{}, total, nil
+	}
+	filtered = filtered[params.Offset:]
+	if limit := params.GetLimit(); len(filtered) > limit {
+		filtered = filtered[:limit]
+	}
+	return filtered, total, nil
+}
+
+func (srv *fakeService) GetByID(ctx context.Context, id uuid.UUID) (models.Application, error) {
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+
+	for _, application := range srv.applications {
+		if application.ID == id {
+			return application, nil
+		}
+	}
+	return models.Application{}, applicationsSrv.ErrApplicationNotFound
+}
+
+func (srv *fakeService) Create(ctx context.Context, item models.NewApplication, idempotencyKey string) (uuid.UUID, error) {
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+
+	srv.metadata, _ = models.MetadataFromContext(ctx)
+	if id, ok := srv.keys[idempotencyKey]; ok && idempotencyKey != "" {
+		return id, nil
+	}
+	application := models.Application{
+		NewApplication: item,
+		ID:             uuid.NewV4(),
+		Status:         models.ApplicationStatusNew,
+	}
+	srv.applications = append(srv.applications, application)
+	srv.keys[idempotencyKey] = application.ID
+	return application.ID, nil
+}
+
+func (srv *fakeService) add(status models.ApplicationStatus) models.Application {
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+
+	application := models.Application{
+		NewApplication: models.NewApplication{
+			FirstName: gofakeit.FirstName(),
+			LastName:  gofakeit.LastName(),
+		},
+		ID:     uuid.NewV4(),
+		Status: status,
+	}
+	srv.applications = append(srv.applications, application)
+	return application
+}
+
+func (srv *fakeService) setStatus(id uuid.UUID, status models.ApplicationStatus) {
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+
+	for i := range srv.applications {
+		if srv.applications[i].ID == id {
+			srv.applications[i].Status = status
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package client
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// Errors to match an API error with errors.Is by its status code.
+var (
+	ErrBadRequest   = &Error{StatusCode: http.StatusBadRequest}
+	ErrUnauthorized = &Error{StatusCode: http.StatusUnauthorized}
+	ErrNotFound     = &Error{StatusCode: http.StatusNotFound}
+	ErrConflict     = &Error{StatusCode: http.StatusConflict}
+	// ErrUnprocessableEntity is returned when an idempotency key is reused with another application.
+	ErrUnprocessableEntity = &Error{StatusCode: http.StatusUnprocessableEntity}
+	ErrInternal            = &Error{StatusCode: http.StatusInternalServerError}
+)
+
+// Error is an error response of the API.
+type Error struct {
+	StatusCode int
+	Message    string
+	Debug      string
+}
+
+func (e *Error) Error() string {
+	if e.Debug == "" {
+		return fmt.Sprintf("api error: code=%d, message=%s", e.StatusCode, e.Message)
+	}
+	return fmt.Sprintf("api error: code=%d, message=%s, debug=%s", e.StatusCode, e.Message, e.Debug)
+}
+
+// Is reports whether the target is an API error of the same status code.
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.StatusCode == e.StatusCode
+}
+
+func (e *Error) temporary() bool {
+	return e.StatusCode >= http.StatusInternalServerError
+}
@@ -0,0 +1,77 @@
+package client
+
+import (
+	"github.com/ivanovaleksey/lendo/pkg/models"
+	"net/http"
+	"time"
+)
+
+type Option func(*Client)
+
+// WithHTTPClient replaces the HTTP client, e.g. to set a transport or a timeout.
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+// WithRetries sets how many times a request is retried after a network error or a 5xx response,
+// zero disables retries.
+func WithRetries(retries int) Option {
+	return func(c *Client) {
+		c.retries = retries
+	}
+}
+
+// WithBackoff sets the delay before the first retry, it doubles with every next one.
+func WithBackoff(backoff time.Duration) Option {
+	return func(c *Client) {
+		c.backoff = backoff
+	}
+}
+
+// WithToken authenticates the client as a trusted caller of the api, only such a caller may set the source
+// and the priority of applications.
+func WithToken(token string) Option {
+	return func(c *Client) {
+		c.token = token
+	}
+}
+
+// WithPollInterval sets how often WatchApplication gets the application.
+func WithPollInterval(interval time.Duration) Option {
+	return func(c *Client) {
+		c.pollInterval = interval
+	}
+}
+
+type CreateOption func(*createSettings)
+
+type createSettings struct {
+	idempotencyKey string
+	metadata       models.Metadata
+}
+
+// WithIdempotencyKey sets the key identifying the create request, a random one is used by default.
+// Pass the same key to create the same application from another process.
+func WithIdempotencyKey(key string) CreateOption {
+	return func(s *createSettings) {
+		s.idempotencyKey = key
+	}
+}
+
+// WithSource sets the channel or the partner submitting the application, the api ignores it unless the client
+// is created WithToken.
+func WithSource(source string) CreateOption {
+	return func(s *createSettings) {
+		s.metadata.Source = source
+	}
+}
+
+// WithPriority overrides the priority configured for the source, the api ignores it unless the client
+// is created WithToken.
+func WithPriority(priority int) CreateOption {
+	return func(s *createSettings) {
+		s.metadata.Priority = &priority
+	}
+}
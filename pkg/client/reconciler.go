@@ -0,0 +1,44 @@
+package client
+
+import (
+	"context"
+	"github.com/ivanovaleksey/lendo/reconciler"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// Reconciler calls the HTTP API of the reconciler.
+type Reconciler struct {
+	client *Client
+}
+
+// NewReconciler creates a client of the reconciler served at baseURL, token is LENDO_ADMIN_TOKEN of the reconciler.
+func NewReconciler(baseURL, token string, opts ...Option) (*Reconciler, error) {
+	client, err := New(baseURL, opts...)
+	if err != nil {
+		return nil, err
+	}
+	client.token = token
+	return &Reconciler{client: client}, nil
+}
+
+// Report reconciles in the dry run mode and returns discrepancies, nothing is repaired.
+// A zero grace means the grace period configured in the reconciler.
+func (rec *Reconciler) Report(ctx context.Context, grace time.Duration) (reconciler.Report, error) {
+	query := make(url.Values)
+	if grace > 0 {
+		query.Set("grace", grace.String())
+	}
+
+	var report reconciler.Report
+	err := rec.client.do(ctx, request{
+		method: http.MethodGet,
+		path:   reconciler.ReportPath,
+		query:  query,
+	}, &report)
+	if err != nil {
+		return reconciler.Report{}, err
+	}
+	return report, nil
+}
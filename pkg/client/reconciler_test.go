@@ -0,0 +1,91 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"github.com/ivanovaleksey/lendo/pkg/models"
+	"github.com/ivanovaleksey/lendo/reconciler"
+	registryModels "github.com/ivanovaleksey/lendo/registry/models"
+	uuid "github.com/satori/go.uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestReconciler_Report(t *testing.T) {
+	t.Run("should report discrepancies", func(t *testing.T) {
+		fx := newReconcilerFixture(t, adminToken)
+		defer fx.Finish()
+
+		fx.applications.items = []models.Application{
+			{ID: uuid.NewV4(), Status: models.ApplicationStatusNew},
+			{ID: uuid.NewV4(), Status: models.ApplicationStatusPending},
+		}
+
+		report, err := fx.reconciler.Report(fx.ctx, time.Hour)
+
+		require.NoError(t, err)
+		assert.Equal(t, reconciler.Report{Checked: 2, MissingJobs: 1}, report)
+		assert.WithinDuration(t, time.Now().Add(-time.Hour), fx.applications.createdBefore, time.Minute)
+	})
+
+	t.Run("with invalid token", func(t *testing.T) {
+		fx := newReconcilerFixture(t, "invalid")
+		defer fx.Finish()
+
+		_, err := fx.reconciler.Report(fx.ctx, 0)
+
+		assert.True(t, errors.Is(err, ErrUnauthorized))
+	})
+}
+
+type reconcilerFixture struct {
+	t   *testing.T
+	ctx context.Context
+
+	applications *fakeApplications
+	server       *httptest.Server
+	reconciler   *Reconciler
+}
+
+func newReconcilerFixture(t *testing.T, token string) *reconcilerFixture {
+	fx := &reconcilerFixture{
+		t:            t,
+		ctx:          context.Background(),
+		applications: &fakeApplications{},
+	}
+	rec := reconciler.New(fx.applications, fakeJobs{}, nil, nil)
+	fx.server = httptest.NewServer(rec.ReportHandler(adminToken))
+
+	client, err := NewReconciler(fx.server.URL, token, WithRetries(0))
+	require.NoError(t, err)
+	fx.reconciler = client
+
+	return fx
+}
+
+func (fx *reconcilerFixture) Finish() {
+	fx.server.Close()
+}
+
+// fakeApplications returns all the items in one batch.
+type fakeApplications struct {
+	items         []models.Application
+	createdBefore time.Time
+}
+
+func (f *fakeApplications) GetUnfinished(ctx context.Context, createdBefore time.Time, after uuid.UUID, limit int) ([]models.Application, error) {
+	f.createdBefore = createdBefore
+	if after != uuid.Nil {
+		return nil, nil
+	}
+	return f.items, nil
+}
+
+type fakeJobs struct{}
+
+func (fakeJobs) GetLatestByApplicationIDs(ctx context.Context, ids []uuid.UUID) ([]registryModels.Job, error) {
+	return nil, nil
+}
@@ -1,69 +1,201 @@
 package closer
 
 import (
+	"fmt"
 	log "github.com/sirupsen/logrus"
 	"os"
 	"os/signal"
 	"sync"
+	"time"
 )
 
+const defaultDeadline = 30 * time.Second
+
 type CloseFunc func() error
 
+// Phase is a shutdown step. Phases are closed one after another in the order they are declared,
+// close functions of the same phase are called concurrently.
+type Phase int
+
+const (
+	// PhaseIngress stops accepting work: HTTP servers, consumers, readiness.
+	PhaseIngress Phase = iota
+	// PhaseWorkers stops components processing the accepted work.
+	PhaseWorkers
+	// PhasePublishers stops clients the workers publish with.
+	PhasePublishers
+	// PhaseInfra stops shared infrastructure: DB, tracing.
+	PhaseInfra
+)
+
+var phases = []Phase{PhaseIngress, PhaseWorkers, PhasePublishers, PhaseInfra}
+
+func (p Phase) String() string {
+	switch p {
+	case PhaseIngress:
+		return "ingress"
+	case PhaseWorkers:
+		return "workers"
+	case PhasePublishers:
+		return "publishers"
+	case PhaseInfra:
+		return "infra"
+	default:
+		return fmt.Sprintf("Phase(%d)", int(p))
+	}
+}
+
+// PhaseReport describes how a phase was closed.
+type PhaseReport struct {
+	Phase    Phase
+	Duration time.Duration
+	Errors   int
+}
+
 type Closer struct {
-	funcs []CloseFunc
-	mu    sync.Mutex
+	funcs   map[Phase][]CloseFunc
+	closing bool
+	mu      sync.Mutex
+
+	signals  []os.Signal
+	deadline time.Duration
+	exit     func(code int)
+
+	reportMu sync.Mutex
+	reports  []PhaseReport
 
 	once   sync.Once
 	closed chan struct{}
 }
 
-func New(signals ...os.Signal) *Closer {
+func New(opts ...Option) *Closer {
 	cl := &Closer{
-		closed: make(chan struct{}),
+		funcs:    make(map[Phase][]CloseFunc),
+		deadline: defaultDeadline,
+		exit:     os.Exit,
+		closed:   make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(cl)
+	}
+	if len(cl.signals) > 0 {
+		ch := make(chan os.Signal, 2)
+		signal.Notify(ch, cl.signals...)
+		go cl.watchSignals(ch)
 	}
-	if len(signals) > 0 {
-		go func() {
-			ch := make(chan os.Signal, 1)
-			signal.Notify(ch, signals...)
+	return cl
+}
 
-			sign := <-ch
-			log.Debugf("got signal: %s\n", sign)
+// watchSignals starts closing on the first signal and forces exit on the second one.
+func (c *Closer) watchSignals(ch chan os.Signal) {
+	defer signal.Stop(ch)
 
-			signal.Stop(ch)
-			cl.CloseAll()
-		}()
+	sign := <-ch
+	log.Debugf("got signal: %s\n", sign)
+	go c.CloseAll()
+
+	select {
+	case sign := <-ch:
+		log.Errorf("got second signal: %s, forcing exit\n", sign)
+		c.exit(1)
+	case <-c.closed:
 	}
-	return cl
 }
 
-func (c *Closer) Add(closer CloseFunc) {
+// Add registers a close function for the phase. Once closing has begun the function is called right away,
+// so that a component started during shutdown isn't left running.
+func (c *Closer) Add(phase Phase, closer CloseFunc) {
 	c.mu.Lock()
-	defer c.mu.Unlock()
-	c.funcs = append(c.funcs, closer)
+	if !c.closing {
+		c.funcs[phase] = append(c.funcs[phase], closer)
+		c.mu.Unlock()
+		return
+	}
+	c.mu.Unlock()
+
+	if err := closer(); err != nil {
+		log.WithField("phase", phase.String()).Errorf("closer error: %v\n", err)
+	}
 }
 
+// CloseAll closes the phases in order. If the deadline is exceeded, the remaining phases are abandoned.
 func (c *Closer) CloseAll() {
 	c.once.Do(func() {
 		defer close(c.closed)
 
+		// the funcs are copied so that Add doesn't wait for the shutdown
 		c.mu.Lock()
-		defer c.mu.Unlock()
+		c.closing = true
+		funcs := make(map[Phase][]CloseFunc, len(c.funcs))
+		for phase, fns := range c.funcs {
+			funcs[phase] = fns
+		}
+		c.mu.Unlock()
 
-		errs := make(chan error, len(c.funcs))
-		for _, closeFunc := range c.funcs {
-			go func(fn CloseFunc) {
-				errs <- fn()
-			}(closeFunc)
+		var deadline <-chan time.Time
+		if c.deadline > 0 {
+			timer := time.NewTimer(c.deadline)
+			defer timer.Stop()
+			deadline = timer.C
 		}
-		for i := 0; i < cap(errs); i++ {
-			err := <-errs
-			if err != nil {
-				log.Errorf("closer error: %v\n", err)
+
+		start := time.Now()
+		for _, phase := range phases {
+			phaseFuncs := funcs[phase]
+			if len(phaseFuncs) == 0 {
+				continue
+			}
+
+			done := make(chan PhaseReport, 1)
+			go func(phase Phase) {
+				done <- closePhase(phase, phaseFuncs)
+			}(phase)
+
+			select {
+			case report := <-done:
+				c.reportMu.Lock()
+				c.reports = append(c.reports, report)
+				c.reportMu.Unlock()
+
+				log.WithField("phase", phase.String()).Infof("closed in %s with %d errors", report.Duration, report.Errors)
+			case <-deadline:
+				log.WithField("phase", phase.String()).Errorf("shutdown deadline %s exceeded", c.deadline)
+				return
 			}
 		}
+		log.Infof("closed all in %s", time.Since(start))
 	})
 }
 
+func closePhase(phase Phase, funcs []CloseFunc) PhaseReport {
+	start := time.Now()
+
+	errs := make(chan error, len(funcs))
+	for _, closeFunc := range funcs {
+		go func(fn CloseFunc) {
+			errs <- fn()
+		}(closeFunc)
+	}
+
+	report := PhaseReport{Phase: phase}
+	for i := 0; i < cap(errs); i++ {
+		err := <-errs
+		if err != nil {
+			report.Errors++
+			log.WithField("phase", phase.String()).Errorf("closer error: %v\n", err)
+		}
+	}
+	report.Duration = time.Since(start)
+	return report
+}
+
+// Reports returns timings of the phases closed so far.
+func (c *Closer) Reports() []PhaseReport {
+	c.reportMu.Lock()
+	defer c.reportMu.Unlock()
+	return append([]PhaseReport(nil), c.reports...)
+}
+
 func (c *Closer) Wait() {
 	<-c.closed
 }
@@ -0,0 +1,167 @@
+package closer
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestCloser_CloseAll(t *testing.T) {
+	t.Run("should close phases in order", func(t *testing.T) {
+		cl := New()
+
+		var (
+			mu    sync.Mutex
+			order []string
+		)
+		record := func(name string, delay time.Duration) CloseFunc {
+			return func() error {
+				time.Sleep(delay)
+				mu.Lock()
+				defer mu.Unlock()
+				order = append(order, name)
+				return nil
+			}
+		}
+		cl.Add(PhaseInfra, record("db", 0))
+		cl.Add(PhasePublishers, record("nats", 0))
+		cl.Add(PhaseWorkers, record("poller", 10*time.Millisecond))
+		cl.Add(PhaseIngress, record("server", 20*time.Millisecond))
+		cl.Add(PhaseIngress, record("consumer", 0))
+
+		cl.CloseAll()
+		cl.Wait()
+
+		assert.Equal(t, []string{"consumer", "server", "poller", "nats", "db"}, order)
+	})
+
+	t.Run("should report phases", func(t *testing.T) {
+		cl := New()
+		cl.Add(PhaseIngress, func() error { return nil })
+		cl.Add(PhaseInfra, func() error { return errors.New("boom") })
+		cl.Add(PhaseInfra, func() error { return nil })
+
+		cl.CloseAll()
+
+		reports := cl.Reports()
+		require.Len(t, reports, 2)
+		assert.Equal(t, PhaseIngress, reports[0].Phase)
+		assert.Equal(t, 0, reports[0].Errors)
+		assert.Equal(t, PhaseInfra, reports[1].Phase)
+		assert.Equal(t, 1, reports[1].Errors)
+	})
+
+	t.Run("should abandon phases after deadline", func(t *testing.T) {
+		cl := New(WithDeadline(10 * time.Millisecond))
+
+		release := make(chan struct{})
+		defer close(release)
+
+		var infraClosed bool
+		cl.Add(PhaseWorkers, func() error {
+			<-release
+			return nil
+		})
+		cl.Add(PhaseInfra, func() error {
+			infraClosed = true
+			return nil
+		})
+
+		start := time.Now()
+		cl.CloseAll()
+
+		assert.Less(t, int64(time.Since(start)), int64(time.Second))
+		assert.False(t, infraClosed)
+		assert.Empty(t, cl.Reports())
+	})
+
+	t.Run("should close only once", func(t *testing.T) {
+		cl := New()
+
+		var calls int
+		cl.Add(PhaseIngress, func() error {
+			calls++
+			return nil
+		})
+
+		cl.CloseAll()
+		cl.CloseAll()
+
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("should not block add while closing", func(t *testing.T) {
+		cl := New()
+		release := make(chan struct{})
+		cl.Add(PhaseIngress, func() error {
+			<-release
+			return nil
+		})
+		go cl.CloseAll()
+
+		require.Eventually(t, func() bool {
+			cl.mu.Lock()
+			defer cl.mu.Unlock()
+			return cl.closing
+		}, time.Second, time.Millisecond)
+
+		added := make(chan struct{})
+		go func() {
+			defer close(added)
+			cl.Add(PhaseInfra, func() error { return nil })
+		}()
+
+		select {
+		case <-added:
+		case <-time.After(time.Second):
+			t.Fatal("add is blocked by close")
+		}
+		close(release)
+		cl.Wait()
+	})
+
+	t.Run("should call func added after close", func(t *testing.T) {
+		cl := New()
+		cl.CloseAll()
+
+		var called bool
+		cl.Add(PhaseIngress, func() error {
+			called = true
+			return nil
+		})
+
+		assert.True(t, called)
+	})
+}
+
+func TestCloser_Signals(t *testing.T) {
+	t.Run("should force exit on second signal", func(t *testing.T) {
+		exited := make(chan int, 1)
+		cl := New(
+			WithSignals(syscall.SIGUSR1),
+			WithExitFunc(func(code int) { exited <- code }),
+		)
+
+		release := make(chan struct{})
+		defer close(release)
+		cl.Add(PhaseIngress, func() error {
+			<-release
+			return nil
+		})
+
+		require.NoError(t, syscall.Kill(syscall.Getpid(), syscall.SIGUSR1))
+		time.Sleep(50 * time.Millisecond)
+		require.NoError(t, syscall.Kill(syscall.Getpid(), syscall.SIGUSR1))
+
+		select {
+		case code := <-exited:
+			assert.Equal(t, 1, code)
+		case <-time.After(time.Second):
+			t.Fatal("exit was not forced")
+		}
+	})
+}
@@ -0,0 +1,29 @@
+package closer
+
+import (
+	"os"
+	"time"
+)
+
+type Option func(*Closer)
+
+// WithSignals makes the closer close all on the first signal and force exit on the second one.
+func WithSignals(signals ...os.Signal) Option {
+	return func(c *Closer) {
+		c.signals = signals
+	}
+}
+
+// WithDeadline sets the overall shutdown deadline, zero means no deadline.
+func WithDeadline(d time.Duration) Option {
+	return func(c *Closer) {
+		c.deadline = d
+	}
+}
+
+// WithExitFunc sets the function called to force exit.
+func WithExitFunc(fn func(code int)) Option {
+	return func(c *Closer) {
+		c.exit = fn
+	}
+}
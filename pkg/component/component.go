@@ -9,11 +9,11 @@ import (
 )
 
 const (
-	CloseDelay   = 3 * time.Second
 	CloseTimeout = 5 * time.Second
 )
 
 type Component interface {
+	// Run blocks until ctx is done or the component is closed, an error means the component failed.
 	Run(context.Context) error
 	Closer
 }
@@ -40,6 +40,10 @@ func Run(ctx context.Context, cmp Component) func() error {
 }
 
 func Close(cmp Closer, delay time.Duration) error {
+	return closeWithTimeout(cmp, delay, CloseTimeout)
+}
+
+func closeWithTimeout(cmp Closer, delay, timeout time.Duration) error {
 	logger := log.WithField("component", cmp.ComponentName())
 
 	if delay > 0 {
@@ -48,7 +52,7 @@ func Close(cmp Closer, delay time.Duration) error {
 	}
 
 	logger.Debug("closing")
-	cl := closer.NewTimeoutCloser(cmp, CloseTimeout)
+	cl := closer.NewTimeoutCloser(cmp, timeout)
 	if err := cl.Close(); err != nil {
 		logger.Errorf("close error: %v", err)
 		return errors.WithMessage(err, "can't close " + cmp.ComponentName())
@@ -56,3 +60,9 @@ func Close(cmp Closer, delay time.Duration) error {
 	logger.Debug("closed")
 	return nil
 }
+
+// HealthChecker is implemented by components which can report their health.
+type HealthChecker interface {
+	ComponentName() string
+	CheckHealth(ctx context.Context) error
+}
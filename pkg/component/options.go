@@ -0,0 +1,35 @@
+package component
+
+import (
+	"time"
+)
+
+type SuperviseOption func(*supervised)
+
+func WithRestartPolicy(policy RestartPolicy) SuperviseOption {
+	return func(sv *supervised) {
+		sv.policy = policy
+	}
+}
+
+// WithBackoff sets the delay before the first restart and its upper bound, the delay doubles on every restart.
+func WithBackoff(min, max time.Duration) SuperviseOption {
+	return func(sv *supervised) {
+		sv.minBackoff = min
+		sv.maxBackoff = max
+	}
+}
+
+// WithMaxRestarts limits the number of restarts, zero means no limit.
+func WithMaxRestarts(n int) SuperviseOption {
+	return func(sv *supervised) {
+		sv.maxRestarts = n
+	}
+}
+
+// WithCloseTimeout overrides CloseTimeout for components which need longer to close.
+func WithCloseTimeout(d time.Duration) SuperviseOption {
+	return func(sv *supervised) {
+		sv.closeTimeout = d
+	}
+}
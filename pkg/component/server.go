@@ -0,0 +1,34 @@
+package component
+
+import (
+	"context"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"net/http"
+	"time"
+)
+
+const (
+	// GracefulDelay is how long a server keeps serving once it's closed, so that k8s stops the traffic.
+	GracefulDelay = 3 * time.Second
+	// GracefulTimeout is how long a server waits for the requests in flight.
+	GracefulTimeout = 5 * time.Second
+)
+
+// ShutdownServer gracefully shuts the server down, it takes up to GracefulDelay + GracefulTimeout.
+func ShutdownServer(srv *http.Server) error {
+	ctx, cancel := context.WithTimeout(context.Background(), GracefulTimeout)
+	defer cancel()
+
+	// waiting for k8s to stop traffic
+	log.Info("waiting for graceful delay")
+	time.Sleep(GracefulDelay)
+
+	log.Info("shutting down")
+	srv.SetKeepAlivesEnabled(false)
+	if err := srv.Shutdown(ctx); err != nil {
+		return errors.Wrap(err, "shutdown error")
+	}
+	log.Info("shutdown gracefully")
+	return nil
+}
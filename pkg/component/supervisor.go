@@ -0,0 +1,260 @@
+package component
+
+import (
+	"context"
+	"fmt"
+	"github.com/ivanovaleksey/lendo/pkg/closer"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultMinBackoff = time.Second
+	defaultMaxBackoff = 30 * time.Second
+)
+
+// RestartPolicy defines what the supervisor does when a component fails to run.
+type RestartPolicy int
+
+const (
+	// RestartNever leaves a failed component in the failed state.
+	RestartNever RestartPolicy = iota
+	// RestartOnFailure runs a failed component again after an exponential backoff.
+	RestartOnFailure
+	// FailFast closes the whole process when a component fails.
+	FailFast
+)
+
+func (p RestartPolicy) String() string {
+	switch p {
+	case RestartNever:
+		return "never"
+	case RestartOnFailure:
+		return "on-failure"
+	case FailFast:
+		return "fail-fast"
+	default:
+		return fmt.Sprintf("RestartPolicy(%d)", int(p))
+	}
+}
+
+type State string
+
+const (
+	StateStarting   State = "starting"
+	StateRunning    State = "running"
+	StateRestarting State = "restarting"
+	StateFailed     State = "failed"
+	StateStopped    State = "stopped"
+)
+
+// Supervisor runs components according to their restart policies.
+// A component is considered failed when its Run returns an error or panics.
+// Restarts never happen once the component is being closed.
+type Supervisor struct {
+	closer *closer.Closer
+
+	mu         sync.Mutex
+	components []*supervised
+}
+
+func NewSupervisor(cl *closer.Closer) *Supervisor {
+	return &Supervisor{
+		closer: cl,
+	}
+}
+
+// Run starts the component under supervision and returns a function closing it.
+func (s *Supervisor) Run(ctx context.Context, cmp Component, opts ...SuperviseOption) func() error {
+	sv := &supervised{
+		cmp:          cmp,
+		closer:       s.closer,
+		minBackoff:   defaultMinBackoff,
+		maxBackoff:   defaultMaxBackoff,
+		closeTimeout: CloseTimeout,
+		state:        StateStarting,
+		stop:         make(chan struct{}),
+		done:         make(chan struct{}),
+		logger:       log.WithField("component", cmp.ComponentName()),
+	}
+	for _, opt := range opts {
+		opt(sv)
+	}
+
+	s.mu.Lock()
+	s.components = append(s.components, sv)
+	s.mu.Unlock()
+
+	go sv.loop(ctx)
+
+	return sv.close
+}
+
+// States returns the current state of every supervised component.
+func (s *Supervisor) States() map[string]State {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	states := make(map[string]State, len(s.components))
+	for _, sv := range s.components {
+		states[sv.cmp.ComponentName()] = sv.getState()
+	}
+	return states
+}
+
+// CheckHealth fails when any of the supervised components has failed.
+func (s *Supervisor) CheckHealth(ctx context.Context) error {
+	var failed []string
+	for name, state := range s.States() {
+		if state == StateFailed {
+			failed = append(failed, name)
+		}
+	}
+	if len(failed) > 0 {
+		sort.Strings(failed)
+		return errors.Errorf("failed components: %s", strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+func (s *Supervisor) ComponentName() string {
+	return "supervisor"
+}
+
+type supervised struct {
+	cmp          Component
+	closer       *closer.Closer
+	policy       RestartPolicy
+	minBackoff   time.Duration
+	maxBackoff   time.Duration
+	maxRestarts  int
+	closeTimeout time.Duration
+	logger       log.FieldLogger
+
+	mu       sync.Mutex
+	state    State
+	restarts int
+	stopping bool
+	stop     chan struct{}
+	done     chan struct{}
+}
+
+func (sv *supervised) loop(ctx context.Context) {
+	defer close(sv.done)
+
+	for {
+		if !sv.setState(StateRunning) {
+			return
+		}
+
+		sv.logger.Debug("running")
+		err := sv.run(ctx)
+		if err == nil {
+			return
+		}
+		sv.logger.Errorf("error: %v", err)
+
+		switch {
+		case sv.policy == FailFast:
+			sv.setState(StateFailed)
+			sv.logger.Error("fail-fast component failed, closing the process")
+			go sv.closer.CloseAll()
+			return
+		case sv.policy == RestartNever:
+			sv.setState(StateFailed)
+			return
+		case sv.maxRestarts > 0 && sv.restarts >= sv.maxRestarts:
+			sv.setState(StateFailed)
+			sv.logger.Errorf("giving up after %d restarts", sv.restarts)
+			return
+		}
+
+		if !sv.setState(StateRestarting) {
+			return
+		}
+		backoff := sv.backoff()
+		sv.restarts++
+		sv.logger.Infof("restarting in %s (attempt %d)", backoff, sv.restarts)
+
+		timer := time.NewTimer(backoff)
+		select {
+		case <-timer.C:
+		case <-sv.stop:
+			timer.Stop()
+			return
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		}
+	}
+}
+
+func (sv *supervised) run(ctx context.Context) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = errors.Errorf("panic: %v", r)
+		}
+	}()
+	return sv.cmp.Run(ctx)
+}
+
+func (sv *supervised) backoff() time.Duration {
+	backoff := sv.minBackoff
+	for i := 0; i < sv.restarts && backoff < sv.maxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > sv.maxBackoff {
+		backoff = sv.maxBackoff
+	}
+	return backoff
+}
+
+// setState changes the state unless the component is being closed.
+func (sv *supervised) setState(state State) bool {
+	sv.mu.Lock()
+	defer sv.mu.Unlock()
+	if sv.stopping {
+		return false
+	}
+	sv.state = state
+	return true
+}
+
+func (sv *supervised) getState() State {
+	sv.mu.Lock()
+	defer sv.mu.Unlock()
+	return sv.state
+}
+
+// close closes the component and waits for its Run to return, both within the close timeout.
+// A component whose Run hasn't returned in time is left in its current state.
+func (sv *supervised) close() error {
+	sv.mu.Lock()
+	if sv.stopping {
+		sv.mu.Unlock()
+		return nil
+	}
+	sv.stopping = true
+	close(sv.stop)
+	sv.mu.Unlock()
+
+	deadline := time.NewTimer(sv.closeTimeout)
+	defer deadline.Stop()
+
+	err := closeWithTimeout(sv.cmp, 0, sv.closeTimeout)
+	select {
+	case <-sv.done:
+	case <-deadline.C:
+		sv.logger.Errorf("run hasn't returned in %s", sv.closeTimeout)
+		return errors.Errorf("can't close %s: run hasn't returned in %s", sv.cmp.ComponentName(), sv.closeTimeout)
+	}
+
+	sv.mu.Lock()
+	sv.state = StateStopped
+	sv.mu.Unlock()
+	return err
+}
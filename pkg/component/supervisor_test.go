@@ -0,0 +1,242 @@
+package component
+
+import (
+	"context"
+	"errors"
+	"github.com/ivanovaleksey/lendo/pkg/closer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSupervisor_Run(t *testing.T) {
+	t.Run("when component runs", func(t *testing.T) {
+		fx := newFixture(t)
+		cmp := &fakeComponent{name: "poller"}
+
+		closure := fx.supervisor.Run(fx.ctx, cmp)
+		waitState(t, fx.supervisor, "poller", StateRunning)
+
+		require.NoError(t, closure())
+		assert.Equal(t, 1, cmp.Runs())
+		assert.True(t, cmp.Closed())
+		assert.Equal(t, StateStopped, fx.supervisor.States()["poller"])
+	})
+
+	t.Run("when restart policy is never", func(t *testing.T) {
+		fx := newFixture(t)
+		cmp := &fakeComponent{name: "poller", errs: []error{errors.New("boom")}}
+
+		fx.supervisor.Run(fx.ctx, cmp, WithRestartPolicy(RestartNever))
+
+		waitState(t, fx.supervisor, "poller", StateFailed)
+		assert.Equal(t, 1, cmp.Runs())
+		assert.EqualError(t, fx.supervisor.CheckHealth(fx.ctx), "failed components: poller")
+	})
+
+	t.Run("when restart policy is on-failure", func(t *testing.T) {
+		fx := newFixture(t)
+		cmp := &fakeComponent{name: "poller", errs: []error{errors.New("boom"), errors.New("boom")}}
+
+		closure := fx.supervisor.Run(fx.ctx, cmp,
+			WithRestartPolicy(RestartOnFailure),
+			WithBackoff(time.Millisecond, 2*time.Millisecond),
+		)
+
+		require.Eventually(t, func() bool { return cmp.Runs() == 3 }, time.Second, time.Millisecond)
+		waitState(t, fx.supervisor, "poller", StateRunning)
+		assert.NoError(t, fx.supervisor.CheckHealth(fx.ctx))
+		require.NoError(t, closure())
+	})
+
+	t.Run("when component panics", func(t *testing.T) {
+		fx := newFixture(t)
+		cmp := &fakeComponent{name: "poller", panics: 1}
+
+		fx.supervisor.Run(fx.ctx, cmp,
+			WithRestartPolicy(RestartOnFailure),
+			WithBackoff(time.Millisecond, time.Millisecond),
+		)
+
+		require.Eventually(t, func() bool { return cmp.Runs() == 2 }, time.Second, time.Millisecond)
+		waitState(t, fx.supervisor, "poller", StateRunning)
+	})
+
+	t.Run("when max restarts are exceeded", func(t *testing.T) {
+		fx := newFixture(t)
+		cmp := &fakeComponent{name: "poller", errs: []error{errors.New("1"), errors.New("2"), errors.New("3")}}
+
+		fx.supervisor.Run(fx.ctx, cmp,
+			WithRestartPolicy(RestartOnFailure),
+			WithBackoff(time.Millisecond, time.Millisecond),
+			WithMaxRestarts(1),
+		)
+
+		waitState(t, fx.supervisor, "poller", StateFailed)
+		assert.Equal(t, 2, cmp.Runs())
+	})
+
+	t.Run("when closed during backoff", func(t *testing.T) {
+		fx := newFixture(t)
+		cmp := &fakeComponent{name: "poller", errs: []error{errors.New("boom")}}
+
+		closure := fx.supervisor.Run(fx.ctx, cmp,
+			WithRestartPolicy(RestartOnFailure),
+			WithBackoff(time.Hour, time.Hour),
+		)
+		waitState(t, fx.supervisor, "poller", StateRestarting)
+
+		require.NoError(t, closure())
+		assert.Equal(t, 1, cmp.Runs())
+		assert.Equal(t, StateStopped, fx.supervisor.States()["poller"])
+	})
+
+	t.Run("when run ignores close", func(t *testing.T) {
+		fx := newFixture(t)
+		cmp := &stuckComponent{release: make(chan struct{})}
+		defer close(cmp.release)
+
+		closure := fx.supervisor.Run(fx.ctx, cmp, WithCloseTimeout(50*time.Millisecond))
+		waitState(t, fx.supervisor, "stuck", StateRunning)
+
+		done := make(chan error, 1)
+		go func() {
+			done <- closure()
+		}()
+
+		select {
+		case err := <-done:
+			assert.EqualError(t, err, "can't close stuck: run hasn't returned in 50ms")
+		case <-time.After(time.Second):
+			t.Fatal("close didn't return in time")
+		}
+		assert.Equal(t, StateRunning, fx.supervisor.States()["stuck"])
+	})
+
+	t.Run("when restart policy is fail-fast", func(t *testing.T) {
+		fx := newFixture(t)
+		cmp := &fakeComponent{name: "poller", errs: []error{errors.New("boom")}}
+
+		closure := fx.supervisor.Run(fx.ctx, cmp, WithRestartPolicy(FailFast))
+		fx.closer.Add(closer.PhaseWorkers, closure)
+
+		done := make(chan struct{})
+		go func() {
+			fx.closer.Wait()
+			close(done)
+		}()
+
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Fatal("closer was not closed")
+		}
+		assert.True(t, cmp.Closed())
+	})
+}
+
+func TestSupervised_Backoff(t *testing.T) {
+	sv := &supervised{minBackoff: time.Second, maxBackoff: 5 * time.Second}
+
+	var got []time.Duration
+	for sv.restarts = 0; sv.restarts < 5; sv.restarts++ {
+		got = append(got, sv.backoff())
+	}
+
+	expected := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second}
+	assert.Equal(t, expected, got)
+}
+
+type fixture struct {
+	t   *testing.T
+	ctx context.Context
+
+	closer     *closer.Closer
+	supervisor *Supervisor
+}
+
+func newFixture(t *testing.T) *fixture {
+	cl := closer.New()
+	return &fixture{
+		t:          t,
+		ctx:        context.Background(),
+		closer:     cl,
+		supervisor: NewSupervisor(cl),
+	}
+}
+
+func waitState(t *testing.T, s *Supervisor, name string, state State) {
+	require.Eventually(t, func() bool {
+		return s.States()[name] == state
+	}, time.Second, time.Millisecond)
+}
+
+type fakeComponent struct {
+	name   string
+	errs   []error
+	panics int
+
+	mu     sync.Mutex
+	runs   int
+	closed bool
+}
+
+func (c *fakeComponent) Run(ctx context.Context) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.runs++
+	if c.panics > 0 {
+		c.panics--
+		panic("boom")
+	}
+	if len(c.errs) > 0 {
+		err := c.errs[0]
+		c.errs = c.errs[1:]
+		return err
+	}
+	return nil
+}
+
+func (c *fakeComponent) Runs() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.runs
+}
+
+func (c *fakeComponent) Closed() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.closed
+}
+
+func (c *fakeComponent) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.closed = true
+	return nil
+}
+
+func (c *fakeComponent) ComponentName() string {
+	return c.name
+}
+
+// stuckComponent doesn't return from Run until released, whether it's closed or not.
+type stuckComponent struct {
+	release chan struct{}
+}
+
+func (c *stuckComponent) Run(ctx context.Context) error {
+	<-c.release
+	return nil
+}
+
+func (c *stuckComponent) Close() error {
+	return nil
+}
+
+func (c *stuckComponent) ComponentName() string {
+	return "stuck"
+}
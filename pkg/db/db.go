@@ -1,23 +1,41 @@
 package db
 
 import (
+	"context"
+	"database/sql"
 	"github.com/DATA-DOG/go-txdb"
+	"github.com/XSAM/otelsql"
 	"github.com/jmoiron/sqlx"
 	_ "github.com/lib/pq"
 	uuid "github.com/satori/go.uuid"
 	"github.com/stretchr/testify/require"
+	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
+	"sync"
 	"testing"
 )
 
+const driverName = "postgres"
+
+var (
+	tracedDriverOnce sync.Once
+	tracedDriverName string
+	tracedDriverErr  error
+)
+
 type DB struct {
 	*sqlx.DB
 }
 
 func New(cfg Config) (*DB, error) {
-	db, err := sqlx.Open("postgres", cfg.URL)
+	name, err := tracedDriver()
+	if err != nil {
+		return nil, err
+	}
+	sqlDB, err := sql.Open(name, cfg.URL)
 	if err != nil {
 		return nil, err
 	}
+	db := sqlx.NewDb(sqlDB, driverName)
 	if err := db.Ping(); err != nil {
 		return nil, err
 	}
@@ -25,9 +43,17 @@ func New(cfg Config) (*DB, error) {
 	return &DB{DB: db}, nil
 }
 
+// tracedDriver registers a postgres driver which starts a span for every query.
+func tracedDriver() (string, error) {
+	tracedDriverOnce.Do(func() {
+		tracedDriverName, tracedDriverErr = otelsql.Register(driverName, semconv.DBSystemPostgreSQL.Value.AsString())
+	})
+	return tracedDriverName, tracedDriverErr
+}
+
 func NewTestDB(t *testing.T, cfg Config) *DB {
 	driver := uuid.NewV4().String()
-	txdb.Register(driver, "postgres", cfg.URL)
+	txdb.Register(driver, driverName, cfg.URL)
 
 	db, err := sqlx.Open(driver, cfg.URL)
 	require.NoError(t, err)
@@ -39,3 +65,7 @@ func NewTestDB(t *testing.T, cfg Config) *DB {
 func (db *DB) ComponentName() string {
 	return "db"
 }
+
+func (db *DB) CheckHealth(ctx context.Context) error {
+	return db.PingContext(ctx)
+}
@@ -0,0 +1,11 @@
+package db
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+)
+
+// Collector exposes connection pool stats labeled with the database name.
+func (db *DB) Collector(name string) prometheus.Collector {
+	return collectors.NewDBStatsCollector(db.DB.DB, name)
+}
@@ -0,0 +1,130 @@
+package envelope
+
+import (
+	"context"
+	"github.com/ivanovaleksey/lendo/pkg/models"
+	"github.com/ivanovaleksey/lendo/pkg/pb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// Contract tests pin the wire format of the events, a failing test means consumers may break:
+// bump the event version and add an upcaster instead of changing the golden files.
+func TestContract(t *testing.T) {
+	const applicationID = "0b7e4f2a-3c55-4d8e-8a61-9f2d7c1e5a30"
+
+	cases := []struct {
+		golden   string
+		producer string
+		typ      string
+		version  int
+		data     proto.Message
+		decoded  proto.Message
+	}{
+		{
+			golden:   "application_new.v1",
+			producer: "api",
+			typ:      models.EventApplicationNew,
+			version:  models.EventApplicationNewVersion,
+			data: &pb.Application{
+				Id:        applicationID,
+				FirstName: "Ada",
+				LastName:  "Lovelace",
+				Status:    models.ApplicationStatusNew,
+			},
+			decoded: &pb.Application{},
+		},
+		{
+			golden:   "application_status_changed.v1",
+			producer: "registry",
+			typ:      models.EventApplicationStatusChanged,
+			version:  models.EventApplicationStatusChangedVersion,
+			data: &pb.StatusChange{
+				Id:     applicationID,
+				Status: models.ApplicationStatusCompleted,
+			},
+			decoded: &pb.StatusChange{},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.golden, func(t *testing.T) {
+			t.Run("json", func(t *testing.T) {
+				golden, err := ioutil.ReadFile(filepath.Join("testdata", c.golden+".json"))
+				require.NoError(t, err)
+
+				t.Run("should encode", func(t *testing.T) {
+					encoder := newTestEncoder(c.producer)
+					ctx := ContextWithCorrelationID(context.Background(), "req-1")
+
+					msg, err := encoder.Encode(ctx, make(http.Header), c.typ, c.version, c.data)
+
+					require.NoError(t, err)
+					assert.JSONEq(t, string(golden), string(msg))
+				})
+
+				t.Run("should decode", func(t *testing.T) {
+					decoded := proto.Clone(c.decoded)
+					env, err := NewDecoder(c.typ, c.version).Decode(jsonHeader(), golden, decoded)
+
+					require.NoError(t, err)
+					assert.Equal(t, "req-1", env.CorrelationID)
+					assert.True(t, proto.Equal(c.data, decoded))
+				})
+			})
+
+			t.Run("protobuf", func(t *testing.T) {
+				golden, err := ioutil.ReadFile(filepath.Join("testdata", c.golden+".pb"))
+				require.NoError(t, err)
+				header := http.Header{}
+				header.Set("Content-Type", ProtobufContentType)
+				header.Set("ce-specversion", "1.0")
+				header.Set("ce-id", "7d3c9bb0-5a4e-4c1f-9d5b-2f0c8e4a6b10")
+				header.Set("ce-type", c.typ)
+				header.Set("ce-source", c.producer)
+				header.Set("ce-time", "2021-04-10T12:34:56Z")
+				header.Set("ce-dataversion", strconv.Itoa(c.version))
+				header.Set("ce-correlationid", "req-1")
+
+				t.Run("should encode", func(t *testing.T) {
+					encoder := newTestEncoder(c.producer)
+					WithFormat(FormatProtobuf)(encoder)
+					ctx := ContextWithCorrelationID(context.Background(), "req-1")
+
+					gotHeader := make(http.Header)
+					msg, err := encoder.Encode(ctx, gotHeader, c.typ, c.version, c.data)
+
+					require.NoError(t, err)
+					assert.Equal(t, header, gotHeader)
+					assert.Equal(t, golden, msg)
+				})
+
+				t.Run("should decode", func(t *testing.T) {
+					decoded := proto.Clone(c.decoded)
+					env, err := NewDecoder(c.typ, c.version).Decode(header, golden, decoded)
+
+					require.NoError(t, err)
+					assert.Equal(t, "req-1", env.CorrelationID)
+					assert.True(t, proto.Equal(c.data, decoded))
+				})
+			})
+		})
+	}
+}
+
+func newTestEncoder(producer string) *Encoder {
+	encoder := NewEncoder(producer)
+	encoder.now = func() time.Time {
+		return time.Date(2021, time.April, 10, 12, 34, 56, 0, time.UTC)
+	}
+	encoder.newID = func() string {
+		return "7d3c9bb0-5a4e-4c1f-9d5b-2f0c8e4a6b10"
+	}
+	return encoder
+}
@@ -0,0 +1,262 @@
+// Package envelope wraps messages exchanged by the services into versioned envelopes.
+// An envelope is a CloudEvents 1.0 event, so that the wire contract doesn't change silently when a domain
+// struct gets a field: the data schema has an explicit version and consumers upcast data of older versions.
+//
+// Events are encoded either in the structured JSON mode or in the binary mode with protobuf data, where the
+// attributes are passed in "ce-" headers. Consumers tell the formats apart by the Content-Type header,
+// so producers may switch the format once all the consumers understand both.
+package envelope
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/pkg/errors"
+	uuid "github.com/satori/go.uuid"
+	"google.golang.org/protobuf/proto"
+	"net/http"
+	"time"
+)
+
+const (
+	SpecVersion = "1.0"
+	// ContentType is set to the Content-Type message header of enveloped messages in the JSON format.
+	ContentType     = "application/cloudevents+json"
+	dataContentType = "application/json"
+	// ProtobufContentType is set to the Content-Type message header of messages in the protobuf format.
+	ProtobufContentType = "application/protobuf"
+
+	contentTypeHeader = "Content-Type"
+	// attributeHeaderPrefix prefixes headers carrying event attributes in the binary mode.
+	attributeHeaderPrefix = "ce-"
+
+	// CorrelationIDHeader is read from HTTP requests to correlate the events they cause.
+	CorrelationIDHeader = "X-Correlation-ID"
+)
+
+// Envelope is a CloudEvents event, DataVersion and CorrelationID are extension attributes.
+type Envelope struct {
+	SpecVersion     string    `json:"specversion"`
+	ID              string    `json:"id"`
+	Type            string    `json:"type"`
+	Source          string    `json:"source"`
+	Time            time.Time `json:"time"`
+	DataContentType string    `json:"datacontenttype"`
+	// DataVersion is the schema version of Data.
+	DataVersion int `json:"dataversion"`
+	// CorrelationID is shared by all the events caused by the same request.
+	CorrelationID string `json:"correlationid,omitempty"`
+	// Data is only kept for the JSON format.
+	Data json.RawMessage `json:"data"`
+}
+
+// Encoder wraps data into envelopes on behalf of a producer.
+type Encoder struct {
+	producer string
+	format   Format
+	now      func() time.Time
+	newID    func() string
+}
+
+type EncoderOption func(*Encoder)
+
+// WithFormat sets the format of events, it's FormatJSON by default.
+func WithFormat(format Format) EncoderOption {
+	return func(e *Encoder) {
+		e.format = format
+	}
+}
+
+// NewEncoder returns an encoder of events produced by the given service, it becomes the event source.
+func NewEncoder(producer string, opts ...EncoderOption) *Encoder {
+	e := &Encoder{
+		producer: producer,
+		format:   FormatJSON,
+		now: func() time.Time {
+			return time.Now().UTC()
+		},
+		newID: func() string {
+			return uuid.NewV4().String()
+		},
+	}
+	for _, opt := range opts {
+		opt(e)
+	}
+	return e
+}
+
+// Encode wraps data of the given type and schema version and sets the content type, along with the event
+// attributes in the protobuf format, to header. The event continues the correlation of ctx or starts a new one.
+// Data must be a protobuf message in the protobuf format.
+func (e *Encoder) Encode(ctx context.Context, header http.Header, typ string, version int, data interface{}) ([]byte, error) {
+	env := Envelope{
+		SpecVersion: SpecVersion,
+		ID:          e.newID(),
+		Type:        typ,
+		Source:      e.producer,
+		Time:        e.now(),
+		DataVersion: version,
+	}
+	env.CorrelationID = CorrelationIDFromContext(ctx)
+	if env.CorrelationID == "" {
+		env.CorrelationID = env.ID
+	}
+
+	switch e.format {
+	case FormatJSON:
+		raw, err := marshalJSON(data)
+		if err != nil {
+			return nil, errors.Wrap(err, "can't encode data")
+		}
+		env.DataContentType = dataContentType
+		env.Data = raw
+		header.Set(contentTypeHeader, ContentType)
+		return json.Marshal(env)
+	case FormatProtobuf:
+		m, ok := data.(proto.Message)
+		if !ok {
+			return nil, errors.Errorf("%s data %T is not a protobuf message", typ, data)
+		}
+		raw, err := proto.Marshal(m)
+		if err != nil {
+			return nil, errors.Wrap(err, "can't encode data")
+		}
+		env.DataContentType = ProtobufContentType
+		setAttributes(header, env)
+		header.Set(contentTypeHeader, ProtobufContentType)
+		return raw, nil
+	default:
+		return nil, errors.Errorf("unknown format %q", e.format)
+	}
+}
+
+// Upcaster converts data of a schema version to the next version.
+type Upcaster func(data json.RawMessage) (json.RawMessage, error)
+
+// Decoder unwraps envelopes of a type into the current schema version.
+type Decoder struct {
+	typ       string
+	version   int
+	upcasters map[int]Upcaster
+}
+
+type DecoderOption func(*Decoder)
+
+// WithUpcaster registers an upcaster of data from the given version to the next one.
+func WithUpcaster(from int, fn Upcaster) DecoderOption {
+	return func(d *Decoder) {
+		d.upcasters[from] = fn
+	}
+}
+
+// NewDecoder returns a decoder of events of the type whose current schema version is version.
+func NewDecoder(typ string, version int, opts ...DecoderOption) *Decoder {
+	d := &Decoder{
+		typ:       typ,
+		version:   version,
+		upcasters: make(map[int]Upcaster),
+	}
+	for _, opt := range opts {
+		opt(d)
+	}
+	return d
+}
+
+// Decode unwraps the envelope of a message in the format told by its header and decodes its data upcast
+// to the current version into v, which must be a protobuf message in the protobuf format.
+// A message without an envelope is taken as JSON data of version 1, which is how the services talked before.
+func (d *Decoder) Decode(header http.Header, msg []byte, v interface{}) (Envelope, error) {
+	if header.Get(contentTypeHeader) == ProtobufContentType {
+		return d.decodeProtobuf(header, msg, v)
+	}
+
+	var env Envelope
+	if err := json.Unmarshal(msg, &env); err != nil {
+		return Envelope{}, errors.Wrap(err, "can't parse envelope")
+	}
+	if env.SpecVersion == "" {
+		env = Envelope{
+			Type:        d.typ,
+			DataVersion: 1,
+			Data:        msg,
+		}
+	}
+
+	if err := d.check(env); err != nil {
+		return env, err
+	}
+	for env.DataVersion < d.version {
+		upcast, ok := d.upcasters[env.DataVersion]
+		if !ok {
+			return env, errors.Errorf("no upcaster of %s version %d", env.Type, env.DataVersion)
+		}
+		data, err := upcast(env.Data)
+		if err != nil {
+			return env, errors.Wrapf(err, "can't upcast %s version %d", env.Type, env.DataVersion)
+		}
+		env.Data = data
+		env.DataVersion++
+	}
+
+	if err := unmarshalJSON(env.Data, v); err != nil {
+		return env, errors.Wrap(err, "can't parse data")
+	}
+	return env, nil
+}
+
+// decodeProtobuf decodes a message in the binary mode. Protobuf data evolves by its field numbers,
+// which upcasters can't help with, so it must be of the current version: switch producers to JSON
+// while rolling out a new version.
+func (d *Decoder) decodeProtobuf(header http.Header, msg []byte, v interface{}) (Envelope, error) {
+	env, err := getAttributes(header)
+	if err != nil {
+		return env, errors.Wrap(err, "can't parse envelope")
+	}
+	if err := d.check(env); err != nil {
+		return env, err
+	}
+	if env.DataVersion < d.version {
+		return env, errors.Errorf("can't upcast protobuf %s version %d", env.Type, env.DataVersion)
+	}
+
+	m, ok := v.(proto.Message)
+	if !ok {
+		return env, errors.Errorf("%s data %T is not a protobuf message", env.Type, v)
+	}
+	if err := proto.Unmarshal(msg, m); err != nil {
+		return env, errors.Wrap(err, "can't parse data")
+	}
+	return env, nil
+}
+
+func (d *Decoder) check(env Envelope) error {
+	if env.Type != d.typ {
+		return errors.Errorf("unexpected event type %q", env.Type)
+	}
+	if env.DataVersion > d.version {
+		return errors.Errorf("unsupported %s version %d", env.Type, env.DataVersion)
+	}
+	return nil
+}
+
+type correlationIDCtxKey struct{}
+
+// ContextWithCorrelationID returns a copy of ctx carrying the correlation ID.
+func ContextWithCorrelationID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, correlationIDCtxKey{}, id)
+}
+
+// CorrelationIDFromContext returns the correlation ID of ctx or an empty string.
+func CorrelationIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(correlationIDCtxKey{}).(string)
+	return id
+}
+
+// Middleware puts the correlation ID passed in the request header into the request context.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if id := r.Header.Get(CorrelationIDHeader); id != "" {
+			r = r.WithContext(ContextWithCorrelationID(r.Context(), id))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,282 @@
+package envelope
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/ivanovaleksey/lendo/pkg/pb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+const testType = "lendo.test"
+
+type testData struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+func TestEncoder_Encode(t *testing.T) {
+	t.Run("should start correlation", func(t *testing.T) {
+		header := make(http.Header)
+		msg, err := NewEncoder("test").Encode(context.Background(), header, testType, 1, testData{Name: "a"})
+		require.NoError(t, err)
+
+		assert.Equal(t, ContentType, header.Get("Content-Type"))
+		var env Envelope
+		require.NoError(t, json.Unmarshal(msg, &env))
+		assert.Equal(t, SpecVersion, env.SpecVersion)
+		assert.NotEmpty(t, env.ID)
+		assert.Equal(t, env.ID, env.CorrelationID)
+		assert.Equal(t, "test", env.Source)
+		assert.JSONEq(t, `{"name": "a", "count": 0}`, string(env.Data))
+	})
+
+	t.Run("should continue correlation of context", func(t *testing.T) {
+		ctx := ContextWithCorrelationID(context.Background(), "req-1")
+
+		msg, err := NewEncoder("test").Encode(ctx, make(http.Header), testType, 1, testData{})
+		require.NoError(t, err)
+
+		var env Envelope
+		require.NoError(t, json.Unmarshal(msg, &env))
+		assert.Equal(t, "req-1", env.CorrelationID)
+	})
+
+	t.Run("should encode protobuf message with protobuf names in JSON", func(t *testing.T) {
+		data := &pb.StatusChange{Id: "a"}
+
+		msg, err := NewEncoder("test").Encode(context.Background(), make(http.Header), testType, 1, data)
+		require.NoError(t, err)
+
+		var env Envelope
+		require.NoError(t, json.Unmarshal(msg, &env))
+		assert.JSONEq(t, `{"id": "a", "status": ""}`, string(env.Data))
+	})
+
+	t.Run("should put attributes into header in protobuf format", func(t *testing.T) {
+		ctx := ContextWithCorrelationID(context.Background(), "req-1")
+		data := &pb.StatusChange{Id: "a", Status: "completed"}
+
+		header := make(http.Header)
+		msg, err := NewEncoder("test", WithFormat(FormatProtobuf)).Encode(ctx, header, testType, 2, data)
+		require.NoError(t, err)
+
+		assert.Equal(t, ProtobufContentType, header.Get("Content-Type"))
+		assert.Equal(t, SpecVersion, header.Get("ce-specversion"))
+		assert.NotEmpty(t, header.Get("ce-id"))
+		assert.Equal(t, testType, header.Get("ce-type"))
+		assert.Equal(t, "test", header.Get("ce-source"))
+		assert.Equal(t, "2", header.Get("ce-dataversion"))
+		assert.Equal(t, "req-1", header.Get("ce-correlationid"))
+		var got pb.StatusChange
+		require.NoError(t, proto.Unmarshal(msg, &got))
+		assert.True(t, proto.Equal(data, &got))
+	})
+
+	t.Run("when data is not protobuf message in protobuf format", func(t *testing.T) {
+		_, err := NewEncoder("test", WithFormat(FormatProtobuf)).Encode(context.Background(), make(http.Header), testType, 1, testData{})
+
+		assert.EqualError(t, err, `lendo.test data envelope.testData is not a protobuf message`)
+	})
+}
+
+func TestDecoder_Decode(t *testing.T) {
+	// version 2 renamed "title" to "name", version 3 added "count"
+	upcasters := []DecoderOption{
+		WithUpcaster(1, func(data json.RawMessage) (json.RawMessage, error) {
+			var v1 struct {
+				Title string `json:"title"`
+			}
+			if err := json.Unmarshal(data, &v1); err != nil {
+				return nil, err
+			}
+			return json.Marshal(map[string]string{"name": v1.Title})
+		}),
+		WithUpcaster(2, func(data json.RawMessage) (json.RawMessage, error) {
+			var v2 map[string]interface{}
+			if err := json.Unmarshal(data, &v2); err != nil {
+				return nil, err
+			}
+			v2["count"] = 1
+			return json.Marshal(v2)
+		}),
+	}
+
+	t.Run("should decode current version", func(t *testing.T) {
+		msg := encode(t, testType, 3, testData{Name: "a", Count: 5})
+
+		var data testData
+		env, err := NewDecoder(testType, 3, upcasters...).Decode(jsonHeader(), msg, &data)
+
+		require.NoError(t, err)
+		assert.Equal(t, testData{Name: "a", Count: 5}, data)
+		assert.Equal(t, 3, env.DataVersion)
+	})
+
+	t.Run("should upcast old versions", func(t *testing.T) {
+		msg := encode(t, testType, 1, map[string]string{"title": "a"})
+
+		var data testData
+		env, err := NewDecoder(testType, 3, upcasters...).Decode(jsonHeader(), msg, &data)
+
+		require.NoError(t, err)
+		assert.Equal(t, testData{Name: "a", Count: 1}, data)
+		assert.Equal(t, 3, env.DataVersion)
+	})
+
+	t.Run("should take message without envelope as version 1", func(t *testing.T) {
+		var data testData
+		env, err := NewDecoder(testType, 3, upcasters...).Decode(nil, []byte(`{"title": "a"}`), &data)
+
+		require.NoError(t, err)
+		assert.Equal(t, testData{Name: "a", Count: 1}, data)
+		assert.Empty(t, env.ID)
+	})
+
+	t.Run("when version is newer than supported", func(t *testing.T) {
+		msg := encode(t, testType, 4, testData{})
+
+		var data testData
+		_, err := NewDecoder(testType, 3, upcasters...).Decode(jsonHeader(), msg, &data)
+
+		assert.EqualError(t, err, `unsupported lendo.test version 4`)
+	})
+
+	t.Run("when there is no upcaster", func(t *testing.T) {
+		msg := encode(t, testType, 1, testData{})
+
+		var data testData
+		_, err := NewDecoder(testType, 2).Decode(jsonHeader(), msg, &data)
+
+		assert.EqualError(t, err, `no upcaster of lendo.test version 1`)
+	})
+
+	t.Run("when type is unexpected", func(t *testing.T) {
+		msg := encode(t, "lendo.other", 1, testData{})
+
+		var data testData
+		_, err := NewDecoder(testType, 1).Decode(jsonHeader(), msg, &data)
+
+		assert.EqualError(t, err, `unexpected event type "lendo.other"`)
+	})
+
+	t.Run("with invalid message", func(t *testing.T) {
+		var data testData
+		_, err := NewDecoder(testType, 1).Decode(jsonHeader(), []byte("{"), &data)
+
+		assert.Error(t, err)
+	})
+
+	t.Run("should decode JSON into protobuf message ignoring unknown fields", func(t *testing.T) {
+		msg := encode(t, testType, 1, map[string]string{"id": "a", "status": "new", "reason": "b"})
+
+		var data pb.StatusChange
+		_, err := NewDecoder(testType, 1).Decode(jsonHeader(), msg, &data)
+
+		require.NoError(t, err)
+		assert.Equal(t, "a", data.Id)
+		assert.Equal(t, "new", data.Status)
+	})
+}
+
+func TestDecoder_Decode_Protobuf(t *testing.T) {
+	data := &pb.StatusChange{Id: "a", Status: "completed"}
+
+	t.Run("should decode current version", func(t *testing.T) {
+		ctx := ContextWithCorrelationID(context.Background(), "req-1")
+		header, msg := encodeProtobuf(t, ctx, testType, 2, data)
+
+		var got pb.StatusChange
+		env, err := NewDecoder(testType, 2).Decode(header, msg, &got)
+
+		require.NoError(t, err)
+		assert.True(t, proto.Equal(data, &got))
+		assert.Equal(t, SpecVersion, env.SpecVersion)
+		assert.Equal(t, testType, env.Type)
+		assert.Equal(t, "test", env.Source)
+		assert.Equal(t, 2, env.DataVersion)
+		assert.Equal(t, "req-1", env.CorrelationID)
+		assert.False(t, env.Time.IsZero())
+	})
+
+	t.Run("when version is older than current", func(t *testing.T) {
+		header, msg := encodeProtobuf(t, context.Background(), testType, 1, data)
+
+		var got pb.StatusChange
+		_, err := NewDecoder(testType, 2).Decode(header, msg, &got)
+
+		assert.EqualError(t, err, `can't upcast protobuf lendo.test version 1`)
+	})
+
+	t.Run("when version is newer than supported", func(t *testing.T) {
+		header, msg := encodeProtobuf(t, context.Background(), testType, 3, data)
+
+		var got pb.StatusChange
+		_, err := NewDecoder(testType, 2).Decode(header, msg, &got)
+
+		assert.EqualError(t, err, `unsupported lendo.test version 3`)
+	})
+
+	t.Run("when type is unexpected", func(t *testing.T) {
+		header, msg := encodeProtobuf(t, context.Background(), "lendo.other", 1, data)
+
+		var got pb.StatusChange
+		_, err := NewDecoder(testType, 1).Decode(header, msg, &got)
+
+		assert.EqualError(t, err, `unexpected event type "lendo.other"`)
+	})
+
+	t.Run("without attributes", func(t *testing.T) {
+		header := make(http.Header)
+		header.Set("Content-Type", ProtobufContentType)
+
+		var got pb.StatusChange
+		_, err := NewDecoder(testType, 1).Decode(header, nil, &got)
+
+		assert.EqualError(t, err, `can't parse envelope: no specversion attribute`)
+	})
+}
+
+func TestFormat_UnmarshalText(t *testing.T) {
+	var f Format
+	require.NoError(t, f.UnmarshalText([]byte("protobuf")))
+	assert.Equal(t, FormatProtobuf, f)
+
+	assert.EqualError(t, f.UnmarshalText([]byte("xml")), `unknown format "xml"`)
+}
+
+func TestMiddleware(t *testing.T) {
+	var id string
+	handler := Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id = CorrelationIDFromContext(r.Context())
+	}))
+
+	req := httptest.NewRequest(http.MethodPost, "/api/applications", nil)
+	req.Header.Set(CorrelationIDHeader, "req-1")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	assert.Equal(t, "req-1", id)
+}
+
+func encode(t *testing.T, typ string, version int, data interface{}) []byte {
+	msg, err := NewEncoder("test").Encode(context.Background(), make(http.Header), typ, version, data)
+	require.NoError(t, err)
+	return msg
+}
+
+func encodeProtobuf(t *testing.T, ctx context.Context, typ string, version int, data interface{}) (http.Header, []byte) {
+	header := make(http.Header)
+	msg, err := NewEncoder("test", WithFormat(FormatProtobuf)).Encode(ctx, header, typ, version, data)
+	require.NoError(t, err)
+	return header, msg
+}
+
+func jsonHeader() http.Header {
+	header := make(http.Header)
+	header.Set("Content-Type", ContentType)
+	return header
+}
@@ -0,0 +1,93 @@
+package envelope
+
+import (
+	"encoding/json"
+	"github.com/pkg/errors"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Format is how events are put on the wire.
+type Format string
+
+const (
+	// FormatJSON is the structured mode with JSON data.
+	FormatJSON Format = "json"
+	// FormatProtobuf is the binary mode with protobuf data.
+	FormatProtobuf Format = "protobuf"
+)
+
+func (f *Format) UnmarshalText(text []byte) error {
+	switch format := Format(text); format {
+	case FormatJSON, FormatProtobuf:
+		*f = format
+		return nil
+	default:
+		return errors.Errorf("unknown format %q", text)
+	}
+}
+
+type Config struct {
+	// Format of the produced events, consumers understand both formats.
+	Format Format `default:"json"`
+}
+
+var (
+	// Protobuf messages are encoded with the field names of .proto files, which match the domain structs.
+	protojsonMarshal   = protojson.MarshalOptions{UseProtoNames: true, EmitUnpopulated: true}
+	protojsonUnmarshal = protojson.UnmarshalOptions{DiscardUnknown: true}
+)
+
+func marshalJSON(data interface{}) (json.RawMessage, error) {
+	if m, ok := data.(proto.Message); ok {
+		return protojsonMarshal.Marshal(m)
+	}
+	return json.Marshal(data)
+}
+
+func unmarshalJSON(data json.RawMessage, v interface{}) error {
+	if m, ok := v.(proto.Message); ok {
+		return protojsonUnmarshal.Unmarshal(data, m)
+	}
+	return json.Unmarshal(data, v)
+}
+
+func setAttributes(header http.Header, env Envelope) {
+	header.Set(attributeHeaderPrefix+"specversion", env.SpecVersion)
+	header.Set(attributeHeaderPrefix+"id", env.ID)
+	header.Set(attributeHeaderPrefix+"type", env.Type)
+	header.Set(attributeHeaderPrefix+"source", env.Source)
+	header.Set(attributeHeaderPrefix+"time", env.Time.Format(time.RFC3339Nano))
+	header.Set(attributeHeaderPrefix+"dataversion", strconv.Itoa(env.DataVersion))
+	header.Set(attributeHeaderPrefix+"correlationid", env.CorrelationID)
+}
+
+func getAttributes(header http.Header) (Envelope, error) {
+	env := Envelope{
+		SpecVersion:     header.Get(attributeHeaderPrefix + "specversion"),
+		ID:              header.Get(attributeHeaderPrefix + "id"),
+		Type:            header.Get(attributeHeaderPrefix + "type"),
+		Source:          header.Get(attributeHeaderPrefix + "source"),
+		DataContentType: header.Get(contentTypeHeader),
+		CorrelationID:   header.Get(attributeHeaderPrefix + "correlationid"),
+	}
+	if env.SpecVersion == "" {
+		return env, errors.New("no specversion attribute")
+	}
+
+	var err error
+	if t := header.Get(attributeHeaderPrefix + "time"); t != "" {
+		env.Time, err = time.Parse(time.RFC3339Nano, t)
+		if err != nil {
+			return env, errors.Wrap(err, "can't parse time attribute")
+		}
+	}
+	env.DataVersion, err = strconv.Atoi(header.Get(attributeHeaderPrefix + "dataversion"))
+	if err != nil {
+		return env, errors.Wrap(err, "can't parse dataversion attribute")
+	}
+	return env, nil
+}
@@ -0,0 +1,2 @@
+
+$0b7e4f2a-3c55-4d8e-8a61-9f2d7c1e5a30	completed
\ No newline at end of file
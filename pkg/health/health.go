@@ -0,0 +1,144 @@
+// Package health aggregates component health checks into liveness and readiness endpoints.
+package health
+
+import (
+	"context"
+	"github.com/go-chi/render"
+	"github.com/ivanovaleksey/lendo/pkg/component"
+	"github.com/pkg/errors"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	StatusOK   = "ok"
+	StatusFail = "fail"
+
+	checkTimeout = 2 * time.Second
+)
+
+var ErrShuttingDown = errors.New("shutting down")
+
+// Health keeps liveness and readiness checks.
+// Liveness fails when the process can't recover by itself and has to be restarted,
+// readiness fails when the process can't serve traffic at the moment.
+type Health struct {
+	mu        sync.RWMutex
+	liveness  []component.HealthChecker
+	readiness []component.HealthChecker
+
+	shuttingDown bool
+}
+
+type Report struct {
+	Status     string                     `json:"status"`
+	Components map[string]ComponentReport `json:"components"`
+}
+
+type ComponentReport struct {
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+func (r Report) Render(w http.ResponseWriter, req *http.Request) error {
+	if r.Status != StatusOK {
+		render.Status(req, http.StatusServiceUnavailable)
+	}
+	return nil
+}
+
+func New() *Health {
+	return &Health{}
+}
+
+// AddLiveness registers checkers for both liveness and readiness.
+func (h *Health) AddLiveness(checkers ...component.HealthChecker) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.liveness = append(h.liveness, checkers...)
+	h.readiness = append(h.readiness, checkers...)
+}
+
+// AddReadiness registers checkers for readiness only.
+func (h *Health) AddReadiness(checkers ...component.HealthChecker) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.readiness = append(h.readiness, checkers...)
+}
+
+// Shutdown makes readiness fail, so no new traffic is routed while the process stops.
+func (h *Health) Shutdown() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.shuttingDown = true
+}
+
+func (h *Health) Liveness(ctx context.Context) Report {
+	h.mu.RLock()
+	checkers := h.liveness
+	h.mu.RUnlock()
+
+	return check(ctx, checkers)
+}
+
+func (h *Health) Readiness(ctx context.Context) Report {
+	h.mu.RLock()
+	checkers, shuttingDown := h.readiness, h.shuttingDown
+	h.mu.RUnlock()
+
+	report := check(ctx, checkers)
+	if shuttingDown {
+		report.Status = StatusFail
+		report.Components["process"] = ComponentReport{Status: StatusFail, Error: ErrShuttingDown.Error()}
+	}
+	return report
+}
+
+func (h *Health) LivenessHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		render.Render(w, r, h.Liveness(r.Context()))
+	}
+}
+
+func (h *Health) ReadinessHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		render.Render(w, r, h.Readiness(r.Context()))
+	}
+}
+
+func check(ctx context.Context, checkers []component.HealthChecker) Report {
+	ctx, cancel := context.WithTimeout(ctx, checkTimeout)
+	defer cancel()
+
+	report := Report{
+		Status:     StatusOK,
+		Components: make(map[string]ComponentReport, len(checkers)),
+	}
+
+	var (
+		mu sync.Mutex
+		wg sync.WaitGroup
+	)
+	for _, checker := range checkers {
+		wg.Add(1)
+		go func(checker component.HealthChecker) {
+			defer wg.Done()
+
+			res := ComponentReport{Status: StatusOK}
+			if err := checker.CheckHealth(ctx); err != nil {
+				res = ComponentReport{Status: StatusFail, Error: err.Error()}
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			report.Components[checker.ComponentName()] = res
+			if res.Status != StatusOK {
+				report.Status = StatusFail
+			}
+		}(checker)
+	}
+	wg.Wait()
+
+	return report
+}
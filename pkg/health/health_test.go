@@ -0,0 +1,97 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHealth(t *testing.T) {
+	t.Run("when all components are healthy", func(t *testing.T) {
+		h := New()
+		h.AddLiveness(checker{name: "nats"})
+		h.AddReadiness(checker{name: "db"})
+
+		resp := serve(h.ReadinessHandler())
+
+		assert.Equal(t, http.StatusOK, resp.Code)
+		assert.Equal(t, Report{
+			Status: StatusOK,
+			Components: map[string]ComponentReport{
+				"nats": {Status: StatusOK},
+				"db":   {Status: StatusOK},
+			},
+		}, decode(t, resp))
+	})
+
+	t.Run("readiness failure should not affect liveness", func(t *testing.T) {
+		h := New()
+		h.AddLiveness(checker{name: "nats"})
+		h.AddReadiness(checker{name: "db", err: errors.New("connection refused")})
+
+		resp := serve(h.LivenessHandler())
+		assert.Equal(t, http.StatusOK, resp.Code)
+		assert.Equal(t, StatusOK, decode(t, resp).Status)
+
+		resp = serve(h.ReadinessHandler())
+		assert.Equal(t, http.StatusServiceUnavailable, resp.Code)
+		report := decode(t, resp)
+		assert.Equal(t, StatusFail, report.Status)
+		assert.Equal(t, ComponentReport{Status: StatusFail, Error: "connection refused"}, report.Components["db"])
+		assert.Equal(t, ComponentReport{Status: StatusOK}, report.Components["nats"])
+	})
+
+	t.Run("when liveness fails", func(t *testing.T) {
+		h := New()
+		h.AddLiveness(checker{name: "nats", err: errors.New("disconnected")})
+
+		resp := serve(h.LivenessHandler())
+		assert.Equal(t, http.StatusServiceUnavailable, resp.Code)
+
+		resp = serve(h.ReadinessHandler())
+		assert.Equal(t, http.StatusServiceUnavailable, resp.Code)
+	})
+
+	t.Run("when shutting down", func(t *testing.T) {
+		h := New()
+		h.AddLiveness(checker{name: "nats"})
+		h.Shutdown()
+
+		resp := serve(h.LivenessHandler())
+		assert.Equal(t, http.StatusOK, resp.Code)
+
+		resp = serve(h.ReadinessHandler())
+		assert.Equal(t, http.StatusServiceUnavailable, resp.Code)
+		assert.Equal(t, ErrShuttingDown.Error(), decode(t, resp).Components["process"].Error)
+	})
+}
+
+type checker struct {
+	name string
+	err  error
+}
+
+func (c checker) ComponentName() string {
+	return c.name
+}
+
+func (c checker) CheckHealth(context.Context) error {
+	return c.err
+}
+
+func serve(h http.Handler) *httptest.ResponseRecorder {
+	resp := httptest.NewRecorder()
+	h.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/", nil))
+	return resp
+}
+
+func decode(t *testing.T, resp *httptest.ResponseRecorder) Report {
+	var report Report
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&report))
+	return report
+}
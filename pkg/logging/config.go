@@ -0,0 +1,31 @@
+package logging
+
+import (
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// Format is how log entries are written.
+type Format string
+
+const (
+	FormatText Format = "text"
+	FormatJSON Format = "json"
+)
+
+func (f *Format) UnmarshalText(text []byte) error {
+	switch format := Format(text); format {
+	case FormatText, FormatJSON:
+		*f = format
+		return nil
+	default:
+		return errors.Errorf("unknown format %q", text)
+	}
+}
+
+type Config struct {
+	Level  log.Level `default:"info"`
+	Format Format    `default:"text"`
+	// RedactFields are masked in log entries, customer names are not logged unless it's emptied.
+	RedactFields []string `split_words:"true" default:"first_name,last_name"`
+}
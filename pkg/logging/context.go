@@ -0,0 +1,46 @@
+package logging
+
+import (
+	"context"
+	"github.com/ivanovaleksey/lendo/pkg/envelope"
+	"github.com/ivanovaleksey/lendo/pkg/models"
+	log "github.com/sirupsen/logrus"
+)
+
+type requestIDCtxKey struct{}
+
+// ContextWithRequestID returns a copy of ctx carrying the request ID.
+func ContextWithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDCtxKey{}, id)
+}
+
+// RequestIDFromContext returns the request ID of ctx or an empty string.
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDCtxKey{}).(string)
+	return id
+}
+
+// WithContext adds the request ID and the correlation ID of ctx to the logger.
+func WithContext(ctx context.Context, logger log.FieldLogger) log.FieldLogger {
+	fields := make(log.Fields, 2)
+	if id := RequestIDFromContext(ctx); id != "" {
+		fields["request_id"] = id
+	}
+	if id := envelope.CorrelationIDFromContext(ctx); id != "" {
+		fields["correlation_id"] = id
+	}
+	if len(fields) == 0 {
+		return logger
+	}
+	return logger.WithFields(fields)
+}
+
+// ApplicationFields describes the application, its personal data is masked by RedactHook.
+func ApplicationFields(application models.Application) log.Fields {
+	return log.Fields{
+		"application_id": application.ID.String(),
+		"first_name":     application.FirstName,
+		"last_name":      application.LastName,
+		"status":         application.Status,
+	}
+}
@@ -0,0 +1,46 @@
+// Package logging configures logrus and carries request and correlation IDs into log entries.
+package logging
+
+import (
+	log "github.com/sirupsen/logrus"
+)
+
+// Redacted replaces values of the redacted fields.
+const Redacted = "[REDACTED]"
+
+// Init configures the standard logger.
+func Init(cfg Config) {
+	log.SetLevel(cfg.Level)
+	switch cfg.Format {
+	case FormatJSON:
+		log.SetFormatter(&log.JSONFormatter{})
+	default:
+		log.SetFormatter(&log.TextFormatter{FullTimestamp: true})
+	}
+	if len(cfg.RedactFields) > 0 {
+		log.AddHook(NewRedactHook(cfg.RedactFields...))
+	}
+}
+
+// RedactHook masks values of the fields, so that personal data passed as fields doesn't get to the logs.
+type RedactHook struct {
+	fields []string
+}
+
+func NewRedactHook(fields ...string) *RedactHook {
+	return &RedactHook{fields: fields}
+}
+
+func (h *RedactHook) Levels() []log.Level {
+	return log.AllLevels
+}
+
+// Fire masks the fields of the entry, logrus fires hooks on a copy of the entry data.
+func (h *RedactHook) Fire(entry *log.Entry) error {
+	for _, field := range h.fields {
+		if value, ok := entry.Data[field]; ok && value != "" {
+			entry.Data[field] = Redacted
+		}
+	}
+	return nil
+}
@@ -0,0 +1,45 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestRedactHook(t *testing.T) {
+	logger, out := newTestLogger()
+	logger.AddHook(NewRedactHook("first_name", "last_name"))
+
+	entry := logger.WithFields(log.Fields{
+		"first_name": "John",
+		"last_name":  "",
+		"id":         "42",
+	})
+	entry.Info("application")
+
+	var logged map[string]interface{}
+	require.NoError(t, json.Unmarshal(out.Bytes(), &logged))
+	assert.Equal(t, Redacted, logged["first_name"])
+	assert.Equal(t, "", logged["last_name"])
+	assert.Equal(t, "42", logged["id"])
+	assert.Equal(t, "John", entry.Data["first_name"], "the entry must be kept as is")
+}
+
+func TestFormat_UnmarshalText(t *testing.T) {
+	var format Format
+	require.NoError(t, format.UnmarshalText([]byte("json")))
+	assert.Equal(t, FormatJSON, format)
+
+	assert.EqualError(t, format.UnmarshalText([]byte("xml")), `unknown format "xml"`)
+}
+
+func newTestLogger() (*log.Logger, *bytes.Buffer) {
+	var out bytes.Buffer
+	logger := log.New()
+	logger.SetOutput(&out)
+	logger.SetFormatter(&log.JSONFormatter{})
+	return logger, &out
+}
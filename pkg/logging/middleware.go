@@ -0,0 +1,57 @@
+package logging
+
+import (
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/ivanovaleksey/lendo/pkg/envelope"
+	uuid "github.com/satori/go.uuid"
+	log "github.com/sirupsen/logrus"
+	"net/http"
+	"time"
+)
+
+// RequestIDHeader is read from requests and set on responses.
+const RequestIDHeader = "X-Request-ID"
+
+// Middleware assigns a request ID, the passed one or a generated one, returns it in the response header
+// and logs handled requests. The request ID correlates the events caused by the request unless
+// a correlation ID is passed, so it has to go after envelope.Middleware.
+func Middleware(service string) func(http.Handler) http.Handler {
+	logger := log.WithField("component", service+".http")
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			id := r.Header.Get(RequestIDHeader)
+			if id == "" {
+				id = uuid.NewV4().String()
+			}
+			w.Header().Set(RequestIDHeader, id)
+
+			ctx := ContextWithRequestID(r.Context(), id)
+			if envelope.CorrelationIDFromContext(ctx) == "" {
+				ctx = envelope.ContextWithCorrelationID(ctx, id)
+			}
+			r = r.WithContext(ctx)
+
+			start := time.Now()
+			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+
+			next.ServeHTTP(ww, r)
+
+			route := r.URL.Path
+			if rctx := chi.RouteContext(ctx); rctx != nil && rctx.RoutePattern() != "" {
+				route = rctx.RoutePattern()
+			}
+			code := ww.Status()
+			if code == 0 {
+				code = http.StatusOK
+			}
+			WithContext(ctx, logger).WithFields(log.Fields{
+				"method":      r.Method,
+				"route":       route,
+				"status":      code,
+				"duration_ms": time.Since(start).Milliseconds(),
+			}).Info("request handled")
+		})
+	}
+}
@@ -0,0 +1,57 @@
+package logging
+
+import (
+	"context"
+	"github.com/go-chi/chi/v5"
+	"github.com/ivanovaleksey/lendo/pkg/envelope"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestMiddleware(t *testing.T) {
+	var ctx context.Context
+	router := chi.NewRouter()
+	router.Use(envelope.Middleware)
+	router.Use(Middleware("test"))
+	router.Get("/", func(w http.ResponseWriter, r *http.Request) {
+		ctx = r.Context()
+	})
+
+	t.Run("should generate request ID", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+		id := rec.Header().Get(RequestIDHeader)
+		assert.NotEmpty(t, id)
+		assert.Equal(t, id, RequestIDFromContext(ctx))
+		assert.Equal(t, id, envelope.CorrelationIDFromContext(ctx), "request ID should correlate events")
+	})
+
+	t.Run("should keep passed IDs", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set(RequestIDHeader, "request")
+		req.Header.Set(envelope.CorrelationIDHeader, "correlation")
+
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+
+		assert.Equal(t, "request", rec.Header().Get(RequestIDHeader))
+		assert.Equal(t, "request", RequestIDFromContext(ctx))
+		assert.Equal(t, "correlation", envelope.CorrelationIDFromContext(ctx))
+	})
+}
+
+func TestWithContext(t *testing.T) {
+	logger, _ := newTestLogger()
+
+	entry := WithContext(context.Background(), logger)
+	assert.Equal(t, logger, entry)
+
+	ctx := ContextWithRequestID(context.Background(), "request")
+	ctx = envelope.ContextWithCorrelationID(ctx, "correlation")
+	entry = WithContext(ctx, logger)
+	assert.Equal(t, log.Fields{"request_id": "request", "correlation_id": "correlation"}, entry.(*log.Entry).Data)
+}
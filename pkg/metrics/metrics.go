@@ -0,0 +1,57 @@
+// Package metrics contains Prometheus helpers shared by the services.
+// Metrics are registered in the default registry and exposed by Handler.
+package metrics
+
+import (
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const Namespace = "lendo"
+
+var httpRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+	Namespace: Namespace,
+	Subsystem: "http",
+	Name:      "request_duration_seconds",
+	Help:      "Duration of HTTP requests by route.",
+	Buckets:   prometheus.DefBuckets,
+}, []string{"service", "method", "route", "code"})
+
+// Handler exposes metrics of the default registry.
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
+
+// Middleware observes duration of requests handled by a chi router.
+// Requests are labeled by a route pattern rather than a path to keep cardinality low.
+func Middleware(service string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+
+			next.ServeHTTP(ww, r)
+
+			route := "unknown"
+			if rctx := chi.RouteContext(r.Context()); rctx != nil {
+				if pattern := rctx.RoutePattern(); pattern != "" {
+					route = pattern
+				}
+			}
+			code := ww.Status()
+			if code == 0 {
+				code = http.StatusOK
+			}
+
+			httpRequestDuration.
+				WithLabelValues(service, r.Method, route, strconv.Itoa(code)).
+				Observe(time.Since(start).Seconds())
+		})
+	}
+}
@@ -0,0 +1,37 @@
+package metrics
+
+import (
+	"github.com/go-chi/chi/v5"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	uuid "github.com/satori/go.uuid"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestMiddleware(t *testing.T) {
+	// metrics live in the global registry, a service of its own keeps the series apart from other runs
+	service := "test-" + uuid.NewV4().String()
+
+	router := chi.NewRouter()
+	router.Use(Middleware(service))
+	router.Get("/items/{id}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+
+	before := testutil.CollectAndCount(httpRequestDuration)
+	for _, id := range []string{"1", "2"} {
+		req := httptest.NewRequest(http.MethodGet, "/items/"+id, nil)
+		router.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	count := testutil.CollectAndCount(httpRequestDuration)
+	assert.Equal(t, before+1, count)
+
+	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	resp := httptest.NewRecorder()
+	Handler().ServeHTTP(resp, req)
+
+	assert.Contains(t, resp.Body.String(), `lendo_http_request_duration_seconds_count{code="404",method="GET",route="/items/{id}",service="`+service+`"} 2`)
+}
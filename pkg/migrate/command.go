@@ -0,0 +1,97 @@
+package migrate
+
+import (
+	"context"
+	"fmt"
+	"github.com/pkg/errors"
+	"io"
+	"strconv"
+)
+
+// Usage describes the arguments of Command.
+const Usage = "migrate up|down [N]|status"
+
+// Command runs a migrate subcommand of a service binary: "up" applies pending migrations,
+// "down [N]" rolls back N migrations, one by default, and "status" prints the schema version.
+func Command(ctx context.Context, m *Migrator, args []string, w io.Writer) error {
+	if len(args) == 0 {
+		return errors.Errorf("usage: %s", Usage)
+	}
+
+	switch args[0] {
+	case "up":
+		if len(args) > 1 {
+			return errors.Errorf("usage: %s", Usage)
+		}
+		applied, err := m.Up(ctx)
+		if err != nil {
+			return err
+		}
+		for _, migration := range applied {
+			fmt.Fprintf(w, "applied %d_%s\n", migration.Version, migration.Name)
+		}
+		if len(applied) == 0 {
+			fmt.Fprintln(w, "no change")
+		}
+	case "down":
+		steps, err := parseSteps(args[1:])
+		if err != nil {
+			return err
+		}
+		reverted, err := m.Down(ctx, steps)
+		if err != nil {
+			return err
+		}
+		for _, migration := range reverted {
+			fmt.Fprintf(w, "rolled back %d_%s\n", migration.Version, migration.Name)
+		}
+		if len(reverted) == 0 {
+			fmt.Fprintln(w, "no change")
+		}
+	case "status":
+		if len(args) > 1 {
+			return errors.Errorf("usage: %s", Usage)
+		}
+		status, err := m.Status(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "version: %d\n", status.Version)
+		fmt.Fprintf(w, "dirty: %t\n", status.Dirty)
+		fmt.Fprintf(w, "latest: %d\n", status.Latest)
+		for _, migration := range status.Pending {
+			fmt.Fprintf(w, "pending %d_%s\n", migration.Version, migration.Name)
+		}
+	default:
+		return errors.Errorf("unknown command %q, usage: %s", args[0], Usage)
+	}
+	return nil
+}
+
+// Ensure applies pending migrations when it's configured and checks the schema is of the version the binary expects.
+func Ensure(ctx context.Context, m *Migrator, cfg Config) error {
+	if cfg.Auto {
+		if _, err := m.Up(ctx); err != nil {
+			return errors.Wrap(err, "can't migrate")
+		}
+	}
+	if err := m.Check(ctx); err != nil {
+		return errors.Wrapf(err, "schema is not migrated, run %q", "migrate up")
+	}
+	return nil
+}
+
+func parseSteps(args []string) (int, error) {
+	switch len(args) {
+	case 0:
+		return 1, nil
+	case 1:
+		steps, err := strconv.Atoi(args[0])
+		if err != nil || steps < 1 {
+			return 0, errors.Errorf("invalid number of steps %q", args[0])
+		}
+		return steps, nil
+	default:
+		return 0, errors.Errorf("usage: %s", Usage)
+	}
+}
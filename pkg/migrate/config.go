@@ -0,0 +1,9 @@
+package migrate
+
+type Config struct {
+	// Auto applies pending migrations on startup, otherwise a service refuses to start unless they are applied.
+	Auto  bool   `envconfig:"auto"`
+	Table string `envconfig:"table" default:"schema_migrations"`
+	// Shared means the database is shared with another service, see SharedDir.
+	Shared bool `envconfig:"shared"`
+}
@@ -0,0 +1,354 @@
+// Package migrate applies SQL migrations embedded into a binary.
+//
+// Migrations are files named like "000001_init.up.sql" and "000001_init.down.sql". Each one runs in a transaction
+// together with the version update, under an advisory lock, so replicas migrating on startup don't race.
+// The version is kept in a table compatible with golang-migrate, so databases migrated by the migrate CLI carry on.
+//
+// Migrations touching objects of the whole database, like extensions, can't be applied as they are by services
+// sharing a database. Their variants for a shared database are put into the "shared" directory under the same names,
+// they replace the migrations of the root when the migrator is created WithShared.
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"github.com/ivanovaleksey/lendo/pkg/db"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"hash/fnv"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+)
+
+// DefaultTable is the version table of golang-migrate.
+const DefaultTable = "schema_migrations"
+
+// SharedDir holds the variants of migrations for a database shared by several services.
+const SharedDir = "shared"
+
+var fileRe = regexp.MustCompile(`^(\d+)_(.+)\.(up|down)\.sql$`)
+
+type Migration struct {
+	Version uint
+	Name    string
+	Up      string
+	Down    string
+}
+
+// Status is the schema version of a database compared to the migrations.
+type Status struct {
+	// Version is the applied version, zero when nothing is applied.
+	Version uint
+	// Dirty means a migration to Version failed halfway, which may only happen to golang-migrate.
+	Dirty bool
+	// Latest is the version of the last migration.
+	Latest  uint
+	Pending []Migration
+}
+
+type Migrator struct {
+	db         *db.DB
+	table      string
+	shared     bool
+	migrations []Migration
+}
+
+// New returns a migrator of the database with the migrations found in the root of fsys.
+func New(database *db.DB, fsys fs.FS, opts ...Option) (*Migrator, error) {
+	m := &Migrator{
+		db:    database,
+		table: DefaultTable,
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+
+	migrations, err := Parse(fsys)
+	if err != nil {
+		return nil, err
+	}
+	if m.shared {
+		migrations, err = overlayShared(migrations, fsys)
+		if err != nil {
+			return nil, err
+		}
+	}
+	m.migrations = migrations
+	return m, nil
+}
+
+// Parse reads migrations sorted by version, other files are ignored.
+func Parse(fsys fs.FS) ([]Migration, error) {
+	return parseDir(fsys, ".")
+}
+
+// overlayShared replaces the migrations with their variants of SharedDir, there may be none.
+func overlayShared(migrations []Migration, fsys fs.FS) ([]Migration, error) {
+	shared, err := parseDir(fsys, SharedDir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return migrations, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[uint]Migration, len(shared))
+	for _, m := range shared {
+		byVersion[m.Version] = m
+	}
+	for i, m := range migrations {
+		variant, ok := byVersion[m.Version]
+		if !ok {
+			continue
+		}
+		if variant.Name != m.Name {
+			return nil, errors.Errorf("shared migration %d_%s doesn't match %d_%s", variant.Version, variant.Name, m.Version, m.Name)
+		}
+		migrations[i] = variant
+		delete(byVersion, m.Version)
+	}
+	for _, variant := range byVersion {
+		return nil, errors.Errorf("shared migration %d_%s has no match", variant.Version, variant.Name)
+	}
+	return migrations, nil
+}
+
+func parseDir(fsys fs.FS, dir string) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, errors.Wrap(err, "can't read migrations")
+	}
+
+	byVersion := make(map[uint]*Migration)
+	for _, entry := range entries {
+		match := fileRe.FindStringSubmatch(entry.Name())
+		if entry.IsDir() || match == nil {
+			continue
+		}
+		version, err := strconv.ParseUint(match[1], 10, 64)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid version of %s", entry.Name())
+		}
+		data, err := fs.ReadFile(fsys, path.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, errors.Wrapf(err, "can't read %s", entry.Name())
+		}
+
+		m, ok := byVersion[uint(version)]
+		if !ok {
+			m = &Migration{Version: uint(version), Name: match[2]}
+			byVersion[m.Version] = m
+		}
+		if m.Name != match[2] {
+			return nil, errors.Errorf("migrations %q and %q have the same version", m.Name, match[2])
+		}
+		if match[3] == "up" {
+			m.Up = string(data)
+		} else {
+			m.Down = string(data)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" {
+			return nil, errors.Errorf("migration %d_%s has no up file", m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	return migrations, nil
+}
+
+// Latest returns the version of the last migration, it's the schema version the binary expects.
+func (m *Migrator) Latest() uint {
+	if len(m.migrations) == 0 {
+		return 0
+	}
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+// Status returns the schema version, it's read-only: a database without the version table is of version zero.
+func (m *Migrator) Status(ctx context.Context) (Status, error) {
+	var status Status
+	err := m.withConn(ctx, func(conn *sqlx.Conn) error {
+		var err error
+		status, err = m.status(ctx, conn)
+		return err
+	})
+	return status, err
+}
+
+// Check fails unless the schema is of the latest version.
+func (m *Migrator) Check(ctx context.Context) error {
+	status, err := m.Status(ctx)
+	if err != nil {
+		return err
+	}
+	if status.Dirty {
+		return errors.Errorf("schema is dirty at version %d", status.Version)
+	}
+	if status.Version != status.Latest {
+		return errors.Errorf("schema version is %d, expected %d", status.Version, status.Latest)
+	}
+	return nil
+}
+
+// Up applies the pending migrations and returns them.
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	var applied []Migration
+	err := m.withLock(ctx, func(conn *sqlx.Conn) error {
+		status, err := m.status(ctx, conn)
+		if err != nil {
+			return err
+		}
+		if status.Dirty {
+			return errors.Errorf("schema is dirty at version %d", status.Version)
+		}
+
+		for _, migration := range status.Pending {
+			if err := m.apply(ctx, conn, migration.Up, migration.Version); err != nil {
+				return errors.Wrapf(err, "can't apply %d_%s", migration.Version, migration.Name)
+			}
+			log.Infof("migration %d_%s is applied", migration.Version, migration.Name)
+			applied = append(applied, migration)
+		}
+		return nil
+	})
+	return applied, err
+}
+
+// Down rolls back the given number of the applied migrations and returns them.
+func (m *Migrator) Down(ctx context.Context, steps int) ([]Migration, error) {
+	var reverted []Migration
+	err := m.withLock(ctx, func(conn *sqlx.Conn) error {
+		status, err := m.status(ctx, conn)
+		if err != nil {
+			return err
+		}
+		if status.Dirty {
+			return errors.Errorf("schema is dirty at version %d", status.Version)
+		}
+
+		applied := m.applied(status.Version)
+		for i := len(applied) - 1; i >= 0 && len(reverted) < steps; i-- {
+			migration := applied[i]
+			if migration.Down == "" {
+				return errors.Errorf("migration %d_%s has no down file", migration.Version, migration.Name)
+			}
+			var prev uint
+			if i > 0 {
+				prev = applied[i-1].Version
+			}
+			if err := m.apply(ctx, conn, migration.Down, prev); err != nil {
+				return errors.Wrapf(err, "can't roll back %d_%s", migration.Version, migration.Name)
+			}
+			log.Infof("migration %d_%s is rolled back", migration.Version, migration.Name)
+			reverted = append(reverted, migration)
+		}
+		return nil
+	})
+	return reverted, err
+}
+
+func (m *Migrator) status(ctx context.Context, conn *sqlx.Conn) (Status, error) {
+	status := Status{Latest: m.Latest()}
+
+	var exists bool
+	err := conn.QueryRowxContext(ctx, "SELECT to_regclass($1) IS NOT NULL", m.quotedTable()).Scan(&exists)
+	if err != nil {
+		return Status{}, errors.Wrap(err, "can't check version table")
+	}
+	if exists {
+		err := conn.QueryRowxContext(ctx, "SELECT version, dirty FROM "+m.quotedTable()+" LIMIT 1").Scan(&status.Version, &status.Dirty)
+		if err != nil && err != sql.ErrNoRows {
+			return Status{}, errors.Wrap(err, "can't get schema version")
+		}
+	}
+
+	for _, migration := range m.migrations {
+		if migration.Version > status.Version {
+			status.Pending = append(status.Pending, migration)
+		}
+	}
+	return status, nil
+}
+
+// applied returns the migrations up to the version.
+func (m *Migrator) applied(version uint) []Migration {
+	var applied []Migration
+	for _, migration := range m.migrations {
+		if migration.Version <= version {
+			applied = append(applied, migration)
+		}
+	}
+	return applied
+}
+
+// apply runs the statements and sets the version, zero version means nothing is applied.
+func (m *Migrator) apply(ctx context.Context, conn *sqlx.Conn, statements string, version uint) error {
+	tx, err := conn.BeginTxx(ctx, nil)
+	if err != nil {
+		return errors.Wrap(err, "can't begin tx")
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, statements); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, "TRUNCATE "+m.quotedTable()); err != nil {
+		return errors.Wrap(err, "can't reset schema version")
+	}
+	if version > 0 {
+		_, err := tx.ExecContext(ctx, "INSERT INTO "+m.quotedTable()+" (version, dirty) VALUES ($1, false)", version)
+		if err != nil {
+			return errors.Wrap(err, "can't set schema version")
+		}
+	}
+	return tx.Commit()
+}
+
+// withConn runs fn on a dedicated connection.
+func (m *Migrator) withConn(ctx context.Context, fn func(conn *sqlx.Conn) error) error {
+	conn, err := m.db.Connx(ctx)
+	if err != nil {
+		return errors.Wrap(err, "can't get connection")
+	}
+	defer conn.Close()
+	return fn(conn)
+}
+
+// withLock runs fn holding an advisory lock of the version table, having the table created.
+func (m *Migrator) withLock(ctx context.Context, fn func(conn *sqlx.Conn) error) error {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte("migrate " + m.table))
+	key := int64(h.Sum64())
+
+	return m.withConn(ctx, func(conn *sqlx.Conn) error {
+		if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", key); err != nil {
+			return errors.Wrap(err, "can't acquire lock")
+		}
+		defer func() {
+			if _, err := conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", key); err != nil {
+				log.Error(errors.Wrap(err, "can't release lock"))
+			}
+		}()
+
+		query := "CREATE TABLE IF NOT EXISTS " + m.quotedTable() + " (version bigint NOT NULL PRIMARY KEY, dirty boolean NOT NULL)"
+		if _, err := conn.ExecContext(ctx, query); err != nil {
+			return errors.Wrap(err, "can't create version table")
+		}
+		return fn(conn)
+	})
+}
+
+func (m *Migrator) quotedTable() string {
+	return pq.QuoteIdentifier(m.table)
+}
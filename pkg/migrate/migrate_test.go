@@ -0,0 +1,142 @@
+package migrate
+
+import (
+	"bytes"
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"testing/fstest"
+)
+
+func TestParse(t *testing.T) {
+	t.Run("sorted by version", func(t *testing.T) {
+		fsys := fstest.MapFS{
+			"000010_second.up.sql":   {Data: []byte("up 10")},
+			"000010_second.down.sql": {Data: []byte("down 10")},
+			"000002_first.up.sql":    {Data: []byte("up 2")},
+			"README.md":              {Data: []byte("ignored")},
+		}
+
+		migrations, err := Parse(fsys)
+		require.NoError(t, err)
+		assert.Equal(t, []Migration{
+			{Version: 2, Name: "first", Up: "up 2"},
+			{Version: 10, Name: "second", Up: "up 10", Down: "down 10"},
+		}, migrations)
+	})
+
+	t.Run("same version", func(t *testing.T) {
+		fsys := fstest.MapFS{
+			"000001_first.up.sql":  {Data: []byte("up")},
+			"000001_second.up.sql": {Data: []byte("up")},
+		}
+
+		_, err := Parse(fsys)
+		assert.Error(t, err)
+	})
+
+	t.Run("no up file", func(t *testing.T) {
+		fsys := fstest.MapFS{
+			"000001_first.down.sql": {Data: []byte("down")},
+		}
+
+		_, err := Parse(fsys)
+		assert.EqualError(t, err, "migration 1_first has no up file")
+	})
+
+}
+
+func TestMigrator_Latest(t *testing.T) {
+	fsys := fstest.MapFS{
+		"000001_first.up.sql":  {Data: []byte("up 1")},
+		"000003_second.up.sql": {Data: []byte("up 3")},
+	}
+
+	m, err := New(nil, fsys)
+	require.NoError(t, err)
+	assert.EqualValues(t, 3, m.Latest())
+
+	m, err = New(nil, fstest.MapFS{})
+	require.NoError(t, err)
+	assert.EqualValues(t, 0, m.Latest())
+}
+
+func TestNew(t *testing.T) {
+	fsys := fstest.MapFS{
+		"000001_init.up.sql":          {Data: []byte("up 1")},
+		"000001_init.down.sql":        {Data: []byte("down 1")},
+		"000002_second.up.sql":        {Data: []byte("up 2")},
+		"shared/000001_init.up.sql":   {Data: []byte("shared up 1")},
+		"shared/000001_init.down.sql": {Data: []byte("shared down 1")},
+	}
+
+	t.Run("not shared", func(t *testing.T) {
+		m, err := New(nil, fsys)
+		require.NoError(t, err)
+		assert.Equal(t, []Migration{
+			{Version: 1, Name: "init", Up: "up 1", Down: "down 1"},
+			{Version: 2, Name: "second", Up: "up 2"},
+		}, m.migrations)
+	})
+
+	t.Run("shared", func(t *testing.T) {
+		m, err := New(nil, fsys, WithShared(true))
+		require.NoError(t, err)
+		assert.Equal(t, []Migration{
+			{Version: 1, Name: "init", Up: "shared up 1", Down: "shared down 1"},
+			{Version: 2, Name: "second", Up: "up 2"},
+		}, m.migrations)
+	})
+
+	t.Run("shared without variants", func(t *testing.T) {
+		m, err := New(nil, fstest.MapFS{"000001_init.up.sql": {Data: []byte("up 1")}}, WithShared(true))
+		require.NoError(t, err)
+		assert.Equal(t, []Migration{{Version: 1, Name: "init", Up: "up 1"}}, m.migrations)
+	})
+
+	t.Run("shared variant without migration", func(t *testing.T) {
+		fsys := fstest.MapFS{
+			"000001_init.up.sql":         {Data: []byte("up 1")},
+			"shared/000002_other.up.sql": {Data: []byte("shared up 2")},
+		}
+
+		_, err := New(nil, fsys, WithShared(true))
+		assert.EqualError(t, err, "shared migration 2_other has no match")
+	})
+
+	t.Run("shared variant of another name", func(t *testing.T) {
+		fsys := fstest.MapFS{
+			"000001_init.up.sql":         {Data: []byte("up 1")},
+			"shared/000001_other.up.sql": {Data: []byte("shared up 1")},
+		}
+
+		_, err := New(nil, fsys, WithShared(true))
+		assert.EqualError(t, err, "shared migration 1_other doesn't match 1_init")
+	})
+}
+
+func TestCommand(t *testing.T) {
+	m, err := New(nil, fstest.MapFS{})
+	require.NoError(t, err)
+
+	cases := []struct {
+		name string
+		args []string
+		err  string
+	}{
+		{name: "no command", args: nil, err: "usage: migrate up|down [N]|status"},
+		{name: "unknown command", args: []string{"drop"}, err: `unknown command "drop", usage: migrate up|down [N]|status`},
+		{name: "up with steps", args: []string{"up", "1"}, err: "usage: migrate up|down [N]|status"},
+		{name: "invalid steps", args: []string{"down", "all"}, err: `invalid number of steps "all"`},
+		{name: "zero steps", args: []string{"down", "0"}, err: `invalid number of steps "0"`},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var out bytes.Buffer
+			err := Command(context.Background(), m, c.args, &out)
+			assert.EqualError(t, err, c.err)
+			assert.Empty(t, out.String())
+		})
+	}
+}
@@ -0,0 +1,17 @@
+package migrate
+
+type Option func(*Migrator)
+
+// WithTable sets the version table, so that migrations of several services may share a database.
+func WithTable(table string) Option {
+	return func(m *Migrator) {
+		m.table = table
+	}
+}
+
+// WithShared makes the migrator use the variants of migrations for a shared database, see SharedDir.
+func WithShared(shared bool) Option {
+	return func(m *Migrator) {
+		m.shared = shared
+	}
+}
@@ -0,0 +1,13 @@
+package models
+
+// Types and current schema versions of events exchanged by the services.
+// Bump a version on any change of the wire format and give consumers an upcaster of the previous one.
+const (
+	// EventApplicationNew carries an Application submitted by a customer.
+	EventApplicationNew        = "lendo.application.new"
+	EventApplicationNewVersion = 1
+
+	// EventApplicationStatusChanged carries a StatusChange decided by the bank.
+	EventApplicationStatusChanged        = "lendo.application.status_changed"
+	EventApplicationStatusChangedVersion = 1
+)
@@ -0,0 +1,61 @@
+package models
+
+import (
+	"context"
+	"github.com/pkg/errors"
+	"net/http"
+	"strconv"
+)
+
+const (
+	SourceHeader   = "Lendo-Source"
+	PriorityHeader = "Lendo-Priority"
+)
+
+// Metadata describes where an application comes from. It's read from HTTP request headers
+// and passed to the registry in message headers along with the application.
+type Metadata struct {
+	// Source is a channel or a partner which submitted the application.
+	Source string
+	// Priority overrides the priority configured for the source, nil means it's not set.
+	Priority *int
+}
+
+type metadataCtxKey struct{}
+
+// ContextWithMetadata returns a copy of ctx carrying the application metadata.
+func ContextWithMetadata(ctx context.Context, md Metadata) context.Context {
+	return context.WithValue(ctx, metadataCtxKey{}, md)
+}
+
+// MetadataFromContext returns the metadata stored by ContextWithMetadata.
+func MetadataFromContext(ctx context.Context) (Metadata, bool) {
+	md, ok := ctx.Value(metadataCtxKey{}).(Metadata)
+	return md, ok
+}
+
+// ExtractMetadata reads the metadata from headers, missing headers are left empty.
+// The headers may only be trusted when the caller is authenticated.
+func ExtractMetadata(h http.Header) (Metadata, error) {
+	md := Metadata{
+		Source: h.Get(SourceHeader),
+	}
+	if value := h.Get(PriorityHeader); value != "" {
+		priority, err := strconv.Atoi(value)
+		if err != nil {
+			return Metadata{}, errors.Wrap(err, "invalid priority")
+		}
+		md.Priority = &priority
+	}
+	return md, nil
+}
+
+// Inject writes the metadata to headers.
+func (md Metadata) Inject(h http.Header) {
+	if md.Source != "" {
+		h.Set(SourceHeader, md.Source)
+	}
+	if md.Priority != nil {
+		h.Set(PriorityHeader, strconv.Itoa(*md.Priority))
+	}
+}
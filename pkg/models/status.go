@@ -8,3 +8,8 @@ const (
 	ApplicationStatusCompleted = "completed"
 	ApplicationStatusRejected  = "rejected"
 )
+
+// Final reports whether the bank has made its decision on the application.
+func (s ApplicationStatus) Final() bool {
+	return s == ApplicationStatusCompleted || s == ApplicationStatusRejected
+}
@@ -0,0 +1,57 @@
+package nats
+
+import (
+	"github.com/ivanovaleksey/lendo/pkg/bus"
+	"github.com/nats-io/nats.go"
+)
+
+// Bus is a bus.Bus over the client connection.
+type Bus struct {
+	client *Client
+}
+
+func NewBus(client *Client) *Bus {
+	return &Bus{client: client}
+}
+
+func (b *Bus) PublishMsg(msg *bus.Msg) error {
+	return b.client.PublishMsg(&nats.Msg{
+		Subject: msg.Subject,
+		Header:  msg.Header,
+		Data:    msg.Data,
+	})
+}
+
+func (b *Bus) QueueSubscribe(subject, queue string, handler bus.MsgHandler) (bus.Subscription, error) {
+	subs, err := b.client.QueueSubscribe(subject, queue, func(msg *nats.Msg) {
+		handler(&bus.Msg{
+			Subject: msg.Subject,
+			Header:  msg.Header,
+			Data:    msg.Data,
+			Acker:   acker{msg: msg},
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return subs, nil
+}
+
+// acker acknowledges JetStream messages, core NATS delivers a message at most once and doesn't expect a reply.
+type acker struct {
+	msg *nats.Msg
+}
+
+func (a acker) Ack() error {
+	if a.msg.Reply == "" {
+		return nil
+	}
+	return a.msg.Ack()
+}
+
+func (a acker) Nak() error {
+	if a.msg.Reply == "" {
+		return nil
+	}
+	return a.msg.Nak()
+}
@@ -1,7 +1,9 @@
 package nats
 
 import (
+	"context"
 	"github.com/nats-io/nats.go"
+	"github.com/pkg/errors"
 )
 
 type Client struct {
@@ -24,3 +26,29 @@ func (c *Client) Close() error {
 func (c *Client) ComponentName() string {
 	return "nats"
 }
+
+func (c *Client) CheckHealth(ctx context.Context) error {
+	if !c.Conn.IsConnected() {
+		return errors.Errorf("connection is %s", statusName(c.Conn.Status()))
+	}
+	return nil
+}
+
+func statusName(status nats.Status) string {
+	switch status {
+	case nats.DISCONNECTED:
+		return "disconnected"
+	case nats.CONNECTED:
+		return "connected"
+	case nats.CLOSED:
+		return "closed"
+	case nats.RECONNECTING:
+		return "reconnecting"
+	case nats.CONNECTING:
+		return "connecting"
+	case nats.DRAINING_SUBS, nats.DRAINING_PUBS:
+		return "draining"
+	default:
+		return "unknown"
+	}
+}
@@ -0,0 +1,51 @@
+package pb
+
+import (
+	"github.com/ivanovaleksey/lendo/pkg/models"
+	"github.com/pkg/errors"
+	uuid "github.com/satori/go.uuid"
+)
+
+func FromApplication(a models.Application) *Application {
+	return &Application{
+		Id:        a.ID.String(),
+		FirstName: a.FirstName,
+		LastName:  a.LastName,
+		Status:    string(a.Status),
+	}
+}
+
+func (x *Application) ToModel() (models.Application, error) {
+	id, err := uuid.FromString(x.GetId())
+	if err != nil {
+		return models.Application{}, errors.Wrap(err, "can't parse id")
+	}
+	a := models.Application{
+		NewApplication: models.NewApplication{
+			FirstName: x.GetFirstName(),
+			LastName:  x.GetLastName(),
+		},
+		ID:     id,
+		Status: models.ApplicationStatus(x.GetStatus()),
+	}
+	return a, nil
+}
+
+func FromStatusChange(c models.StatusChange) *StatusChange {
+	return &StatusChange{
+		Id:     c.ID.String(),
+		Status: string(c.Status),
+	}
+}
+
+func (x *StatusChange) ToModel() (models.StatusChange, error) {
+	id, err := uuid.FromString(x.GetId())
+	if err != nil {
+		return models.StatusChange{}, errors.Wrap(err, "can't parse id")
+	}
+	c := models.StatusChange{
+		ID:     id,
+		Status: models.ApplicationStatus(x.GetStatus()),
+	}
+	return c, nil
+}
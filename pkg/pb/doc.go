@@ -0,0 +1,6 @@
+// Package pb holds protobuf messages of the events exchanged by the services, generated from events.proto by
+//
+//	protoc --go_out=. --go_opt=paths=source_relative events.proto
+//
+// with protoc-gen-go v1.27.1. Don't edit events.pb.go, regenerate it.
+package pb
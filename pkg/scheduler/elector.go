@@ -0,0 +1,112 @@
+package scheduler
+
+import (
+	"context"
+	"github.com/ivanovaleksey/lendo/pkg/db"
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"hash/fnv"
+	"sync"
+	"time"
+)
+
+const releaseTimeout = 5 * time.Second
+
+// Elector elects a single leader among replicas.
+type Elector interface {
+	// Acquire tries to become the leader without blocking and tells whether it succeeded.
+	Acquire(ctx context.Context) (bool, error)
+	// Check fails when the leadership is lost.
+	Check(ctx context.Context) error
+	// Release gives up the leadership.
+	Release() error
+}
+
+// PGElector elects a leader with a Postgres session level advisory lock.
+// The lock is held by a dedicated connection, so it's released by Postgres once the leader is gone.
+type PGElector struct {
+	db  *db.DB
+	key int64
+
+	mu   sync.Mutex
+	conn *sqlx.Conn
+}
+
+// NewPGElector returns an elector among replicas using the same name.
+func NewPGElector(database *db.DB, name string) *PGElector {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(name))
+
+	return &PGElector{
+		db:  database,
+		key: int64(h.Sum64()),
+	}
+}
+
+func (e *PGElector) Acquire(ctx context.Context) (bool, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.conn != nil {
+		return true, nil
+	}
+
+	conn, err := e.db.Connx(ctx)
+	if err != nil {
+		return false, errors.Wrap(err, "can't get connection")
+	}
+
+	var acquired bool
+	if err := conn.GetContext(ctx, &acquired, "SELECT pg_try_advisory_lock($1)", e.key); err != nil {
+		_ = conn.Close()
+		return false, errors.Wrap(err, "can't acquire lock")
+	}
+	if !acquired {
+		return false, conn.Close()
+	}
+	e.conn = conn
+	return true, nil
+}
+
+func (e *PGElector) Check(ctx context.Context) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.conn == nil {
+		return errors.New("not a leader")
+	}
+	if _, err := e.conn.ExecContext(ctx, "SELECT 1"); err != nil {
+		if rErr := e.release(); rErr != nil {
+			log.Debugf("can't release lock: %v", rErr)
+		}
+		return errors.Wrap(err, "lock connection is lost")
+	}
+	return nil
+}
+
+func (e *PGElector) Release() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.conn == nil {
+		return nil
+	}
+	return e.release()
+}
+
+// release unlocks explicitly since a closed connection is kept in the pool.
+// The unlock fails when the connection is broken, but then the lock is gone together with the session.
+func (e *PGElector) release() error {
+	conn := e.conn
+	e.conn = nil
+
+	ctx, cancel := context.WithTimeout(context.Background(), releaseTimeout)
+	defer cancel()
+
+	_, err := conn.ExecContext(ctx, "SELECT pg_advisory_unlock($1)", e.key)
+	if cErr := conn.Close(); err == nil {
+		err = cErr
+	}
+	return err
+}
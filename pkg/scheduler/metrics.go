@@ -0,0 +1,36 @@
+package scheduler
+
+import (
+	"github.com/ivanovaleksey/lendo/pkg/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const (
+	outcomeSuccess = "success"
+	outcomeFailure = "failure"
+)
+
+var (
+	leader = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: metrics.Namespace,
+		Subsystem: "scheduler",
+		Name:      "leader",
+		Help:      "Whether the replica is the scheduler leader.",
+	})
+
+	taskDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metrics.Namespace,
+		Subsystem: "scheduler",
+		Name:      "task_duration_seconds",
+		Help:      "Duration of scheduled task runs by task.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"task"})
+
+	taskRuns = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metrics.Namespace,
+		Subsystem: "scheduler",
+		Name:      "task_runs_total",
+		Help:      "Number of scheduled task runs by task and outcome.",
+	}, []string{"task", "outcome"})
+)
@@ -0,0 +1,26 @@
+package scheduler
+
+import (
+	"time"
+)
+
+type Option func(*Scheduler)
+
+// WithTask schedules the task, names must be unique since they identify task runs in the store.
+func WithTask(name string, schedule Schedule, fn TaskFunc) Option {
+	return func(s *Scheduler) {
+		s.tasks = append(s.tasks, &task{
+			name:     name,
+			schedule: schedule,
+			fn:       fn,
+		})
+	}
+}
+
+// WithElectionInterval sets how often a follower tries to become the leader
+// and how often the leader checks it's still the one.
+func WithElectionInterval(d time.Duration) Option {
+	return func(s *Scheduler) {
+		s.electionInterval = d
+	}
+}
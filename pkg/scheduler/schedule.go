@@ -0,0 +1,169 @@
+package scheduler
+
+import (
+	"github.com/pkg/errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule tells when a task runs next.
+type Schedule interface {
+	// Next returns the first time after t when the task runs, zero time means never.
+	Next(t time.Time) time.Time
+}
+
+// Parse parses either an interval like "10m" or a cron expression like "*/10 * * * *".
+func Parse(spec string) (Schedule, error) {
+	if d, err := time.ParseDuration(spec); err == nil {
+		if d <= 0 {
+			return nil, errors.Errorf("non-positive interval %q", spec)
+		}
+		return Every(d), nil
+	}
+	return ParseCron(spec)
+}
+
+// Every returns a schedule running a task every d.
+func Every(d time.Duration) Schedule {
+	return interval(d)
+}
+
+type interval time.Duration
+
+func (i interval) Next(t time.Time) time.Time {
+	return t.Add(time.Duration(i))
+}
+
+type cronField struct {
+	name     string
+	min, max int
+}
+
+var cronFields = []cronField{
+	{name: "minute", min: 0, max: 59},
+	{name: "hour", min: 0, max: 23},
+	{name: "day of month", min: 1, max: 31},
+	{name: "month", min: 1, max: 12},
+	{name: "day of week", min: 0, max: 6},
+}
+
+// cron is a set of allowed values of every field, in the order of cronFields.
+type cron struct {
+	fields [5]map[int]bool
+	// When both day fields are restricted, a day matches either of them like in crontab.
+	anyDayOfMonth bool
+	anyDayOfWeek  bool
+}
+
+// ParseCron parses a standard five field cron expression: minute, hour, day of month, month and day of week.
+// A field is '*' or a comma separated list of values and ranges, both optionally followed by a '/step'.
+// Times are matched in the location of the time passed to Next, which is UTC for the Scheduler.
+func ParseCron(expr string) (Schedule, error) {
+	parts := strings.Fields(expr)
+	if len(parts) != len(cronFields) {
+		return nil, errors.Errorf("cron expression %q must have %d fields", expr, len(cronFields))
+	}
+
+	var c cron
+	for i, part := range parts {
+		values, err := parseCronField(part, cronFields[i])
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid cron expression %q", expr)
+		}
+		c.fields[i] = values
+	}
+	c.anyDayOfMonth = strings.HasPrefix(parts[2], "*")
+	c.anyDayOfWeek = strings.HasPrefix(parts[4], "*")
+	return c, nil
+}
+
+func parseCronField(s string, field cronField) (map[int]bool, error) {
+	values := make(map[int]bool)
+	for _, item := range strings.Split(s, ",") {
+		step := 1
+		if i := strings.Index(item, "/"); i >= 0 {
+			n, err := strconv.Atoi(item[i+1:])
+			if err != nil || n <= 0 {
+				return nil, errors.Errorf("invalid %s step %q", field.name, item)
+			}
+			step = n
+			item = item[:i]
+		}
+
+		from, to := field.min, field.max
+		switch {
+		case item == "*":
+		case strings.Contains(item, "-"):
+			bounds := strings.SplitN(item, "-", 2)
+			var err error
+			if from, err = strconv.Atoi(bounds[0]); err != nil {
+				return nil, errors.Errorf("invalid %s %q", field.name, item)
+			}
+			if to, err = strconv.Atoi(bounds[1]); err != nil {
+				return nil, errors.Errorf("invalid %s %q", field.name, item)
+			}
+		default:
+			n, err := strconv.Atoi(item)
+			if err != nil {
+				return nil, errors.Errorf("invalid %s %q", field.name, item)
+			}
+			from, to = n, n
+			if step > 1 {
+				to = field.max
+			}
+		}
+		if from < field.min || to > field.max || from > to {
+			return nil, errors.Errorf("%s %q is out of range %d-%d", field.name, item, field.min, field.max)
+		}
+
+		for v := from; v <= to; v += step {
+			values[v] = true
+		}
+	}
+	return values, nil
+}
+
+// maxCronYears limits the search of the next time for expressions like "0 0 31 2 *" which never match.
+const maxCronYears = 5
+
+func (c cron) Next(t time.Time) time.Time {
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(maxCronYears, 0, 0)
+
+	for t.Before(limit) {
+		if !c.fields[3][int(t.Month())] {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if !c.matchDay(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if !c.fields[1][t.Hour()] {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+			continue
+		}
+		if !c.fields[0][t.Minute()] {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
+
+func (c cron) matchDay(t time.Time) bool {
+	dayOfMonth := c.fields[2][t.Day()]
+	dayOfWeek := c.fields[4][int(t.Weekday())]
+	switch {
+	case c.anyDayOfMonth && c.anyDayOfWeek:
+		return true
+	case c.anyDayOfMonth:
+		return dayOfWeek
+	case c.anyDayOfWeek:
+		return dayOfMonth
+	default:
+		return dayOfMonth || dayOfWeek
+	}
+}
@@ -0,0 +1,55 @@
+package scheduler
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	now := time.Date(2021, time.April, 10, 12, 34, 56, 0, time.UTC)
+
+	cases := []struct {
+		spec string
+		next time.Time
+	}{
+		{spec: "10m", next: now.Add(10 * time.Minute)},
+		{spec: "* * * * *", next: time.Date(2021, time.April, 10, 12, 35, 0, 0, time.UTC)},
+		{spec: "*/15 * * * *", next: time.Date(2021, time.April, 10, 12, 45, 0, 0, time.UTC)},
+		{spec: "0 3 * * *", next: time.Date(2021, time.April, 11, 3, 0, 0, 0, time.UTC)},
+		{spec: "30 9-17/4 * * *", next: time.Date(2021, time.April, 10, 13, 30, 0, 0, time.UTC)},
+		{spec: "0 0 1 * *", next: time.Date(2021, time.May, 1, 0, 0, 0, 0, time.UTC)},
+		{spec: "0 0 * * 1", next: time.Date(2021, time.April, 12, 0, 0, 0, 0, time.UTC)},
+		{spec: "0 0 15 * 1", next: time.Date(2021, time.April, 12, 0, 0, 0, 0, time.UTC)},
+		{spec: "0 0 29 2 *", next: time.Date(2024, time.February, 29, 0, 0, 0, 0, time.UTC)},
+		{spec: "0 0 31 2 *", next: time.Time{}},
+	}
+	for _, c := range cases {
+		t.Run(c.spec, func(t *testing.T) {
+			schedule, err := Parse(c.spec)
+			require.NoError(t, err)
+			assert.Equal(t, c.next, schedule.Next(now))
+		})
+	}
+}
+
+func TestParse_Invalid(t *testing.T) {
+	specs := []string{
+		"",
+		"-1m",
+		"* * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"*/0 * * * *",
+		"5-1 * * * *",
+		"a * * * *",
+	}
+	for _, spec := range specs {
+		t.Run(spec, func(t *testing.T) {
+			_, err := Parse(spec)
+			assert.Error(t, err)
+		})
+	}
+}
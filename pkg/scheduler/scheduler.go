@@ -0,0 +1,239 @@
+// Package scheduler runs periodic tasks on a single replica out of many.
+// Replicas elect a leader and only the leader runs the tasks, the last run of every task is stored,
+// so that a new leader continues the schedule instead of starting it over.
+package scheduler
+
+import (
+	"context"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"sync"
+	"time"
+)
+
+const (
+	defaultElectionInterval = 10 * time.Second
+)
+
+type TaskFunc func(ctx context.Context) error
+
+type task struct {
+	name     string
+	schedule Schedule
+	fn       TaskFunc
+	next     time.Time
+}
+
+// Scheduler runs tasks while it's the leader. Tasks run one by one, in UTC.
+type Scheduler struct {
+	elector          Elector
+	store            Store
+	tasks            []*task
+	electionInterval time.Duration
+	logger           log.FieldLogger
+
+	mu       sync.Mutex
+	wg       sync.WaitGroup
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+func New(elector Elector, store Store, opts ...Option) *Scheduler {
+	s := &Scheduler{
+		elector:          elector,
+		store:            store,
+		electionInterval: defaultElectionInterval,
+		logger:           log.WithField("component", "scheduler"),
+		stop:             make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Run elects the leader and runs the tasks until ctx is done or the scheduler is closed.
+// A panic of a task stops the scheduler with an error, so that the supervisor may restart it.
+func (s *Scheduler) Run(ctx context.Context) (err error) {
+	if len(s.tasks) == 0 {
+		return errors.New("no tasks to schedule")
+	}
+
+	if !s.start() {
+		return nil
+	}
+	defer s.wg.Done()
+	defer func() {
+		if r := recover(); r != nil {
+			err = errors.Errorf("panic: %v", r)
+		}
+	}()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		select {
+		case <-s.stop:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-timer.C:
+			s.elect(ctx)
+			timer.Reset(s.electionInterval)
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+// elect tries to become the leader and leads until the leadership is lost or ctx is done.
+func (s *Scheduler) elect(ctx context.Context) {
+	ok, err := s.elector.Acquire(ctx)
+	if err != nil {
+		s.logger.Errorf("can't acquire leadership: %v", err)
+		return
+	}
+	if !ok {
+		return
+	}
+
+	s.logger.Info("became the leader")
+	leader.Set(1)
+	defer func() {
+		leader.Set(0)
+		if err := s.elector.Release(); err != nil {
+			s.logger.Errorf("can't release leadership: %v", err)
+		}
+	}()
+
+	if err := s.lead(ctx); err != nil {
+		s.logger.Errorf("stopped leading: %v", err)
+	}
+}
+
+func (s *Scheduler) lead(ctx context.Context) error {
+	if err := s.plan(ctx); err != nil {
+		return err
+	}
+
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			return nil
+		}
+
+		if err := s.elector.Check(ctx); err != nil {
+			return errors.Wrap(err, "leadership is lost")
+		}
+
+		now := s.now()
+		wait := s.electionInterval
+		for _, t := range s.tasks {
+			if t.next.IsZero() {
+				continue
+			}
+			if !t.next.After(now) {
+				s.runTask(ctx, t)
+				t.next = t.schedule.Next(s.now())
+			}
+			if t.next.IsZero() {
+				continue
+			}
+			if d := t.next.Sub(s.now()); d < wait {
+				wait = d
+			}
+		}
+		timer.Reset(wait)
+	}
+}
+
+// plan schedules the tasks after their last runs, a task which has never run is scheduled from now.
+func (s *Scheduler) plan(ctx context.Context) error {
+	runs, err := s.store.GetRuns(ctx)
+	if err != nil {
+		return errors.Wrap(err, "can't get runs")
+	}
+	lastRuns := make(map[string]time.Time, len(runs))
+	for _, run := range runs {
+		lastRuns[run.Task] = run.StartedAt.UTC()
+	}
+
+	now := s.now()
+	for _, t := range s.tasks {
+		last, ok := lastRuns[t.name]
+		if !ok {
+			last = now
+		}
+		t.next = t.schedule.Next(last)
+	}
+	return nil
+}
+
+func (s *Scheduler) runTask(ctx context.Context, t *task) {
+	logger := s.logger.WithField("task", t.name)
+	logger.Debug("running")
+
+	startedAt := s.now()
+	err := t.fn(ctx)
+	duration := time.Since(startedAt)
+
+	run := Run{
+		Task:       t.name,
+		StartedAt:  startedAt,
+		DurationMS: duration.Milliseconds(),
+	}
+	taskDuration.WithLabelValues(t.name).Observe(duration.Seconds())
+	if err != nil {
+		run.Error = err.Error()
+		taskRuns.WithLabelValues(t.name, outcomeFailure).Inc()
+		logger.Errorf("error: %v", err)
+	} else {
+		taskRuns.WithLabelValues(t.name, outcomeSuccess).Inc()
+	}
+
+	if err := s.store.SaveRun(ctx, run); err != nil {
+		logger.Errorf("can't save run: %v", err)
+	}
+}
+
+func (s *Scheduler) now() time.Time {
+	return time.Now().UTC()
+}
+
+// start registers a run unless the scheduler is closed.
+func (s *Scheduler) start() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	select {
+	case <-s.stop:
+		return false
+	default:
+	}
+	s.wg.Add(1)
+	return true
+}
+
+func (s *Scheduler) Close() error {
+	s.stopOnce.Do(func() { close(s.stop) })
+	// a Run starting concurrently either sees the stop or is waited for
+	s.mu.Lock()
+	s.mu.Unlock()
+	s.wg.Wait()
+	return nil
+}
+
+func (s *Scheduler) ComponentName() string {
+	return "scheduler"
+}
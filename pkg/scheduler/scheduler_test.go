@@ -0,0 +1,209 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestScheduler_Run(t *testing.T) {
+	t.Run("should run tasks when leader", func(t *testing.T) {
+		fx := newFixture(t)
+
+		fx.run(WithTask("purge", Every(10*time.Millisecond), fx.task.Run))
+
+		require.Eventually(t, func() bool { return fx.task.Runs() >= 2 }, time.Second, time.Millisecond)
+		fx.Finish()
+		runs := fx.store.Runs()
+		require.NotEmpty(t, runs)
+		assert.Equal(t, "purge", runs[0].Task)
+		assert.Empty(t, runs[0].Error)
+		assert.True(t, fx.elector.Released())
+	})
+
+	t.Run("should not run tasks when follower", func(t *testing.T) {
+		fx := newFixture(t)
+		fx.elector.leader = false
+
+		fx.run(WithTask("purge", Every(time.Millisecond), fx.task.Run))
+
+		time.Sleep(50 * time.Millisecond)
+		fx.Finish()
+		assert.Zero(t, fx.task.Runs())
+	})
+
+	t.Run("should continue schedule after last run", func(t *testing.T) {
+		fx := newFixture(t)
+		fx.store.runs = []Run{{Task: "purge", StartedAt: time.Now().Add(-time.Hour)}}
+
+		fx.run(WithTask("purge", Every(time.Minute), fx.task.Run))
+
+		require.Eventually(t, func() bool { return fx.task.Runs() == 1 }, time.Second, time.Millisecond)
+		time.Sleep(50 * time.Millisecond)
+		fx.Finish()
+		assert.Equal(t, 1, fx.task.Runs())
+	})
+
+	t.Run("should record task error", func(t *testing.T) {
+		fx := newFixture(t)
+		fx.task.err = errors.New("boom")
+
+		fx.run(WithTask("purge", Every(10*time.Millisecond), fx.task.Run))
+
+		require.Eventually(t, func() bool { return fx.task.Runs() >= 1 }, time.Second, time.Millisecond)
+		fx.Finish()
+		runs := fx.store.Runs()
+		require.NotEmpty(t, runs)
+		assert.Equal(t, "boom", runs[0].Error)
+	})
+
+	t.Run("should stop running tasks when leadership is lost", func(t *testing.T) {
+		fx := newFixture(t)
+		fx.elector.checkErr = errors.New("boom")
+
+		fx.run(WithTask("purge", Every(10*time.Millisecond), fx.task.Run))
+
+		require.Eventually(t, fx.elector.Released, time.Second, time.Millisecond)
+		fx.Finish()
+		assert.Zero(t, fx.task.Runs())
+	})
+
+	t.Run("should fail when task panics", func(t *testing.T) {
+		fx := newFixture(t)
+		fx.task.panics = true
+
+		fx.run(WithTask("purge", Every(10*time.Millisecond), fx.task.Run))
+
+		select {
+		case err := <-fx.errs:
+			assert.EqualError(t, err, "panic: boom")
+		case <-time.After(time.Second):
+			t.Fatal("scheduler didn't fail")
+		}
+		assert.True(t, fx.elector.Released())
+		require.NoError(t, fx.scheduler.Close())
+	})
+
+	t.Run("when there are no tasks", func(t *testing.T) {
+		fx := newFixture(t)
+
+		err := New(fx.elector, fx.store).Run(context.Background())
+
+		assert.Error(t, err)
+	})
+}
+
+type fixture struct {
+	t *testing.T
+
+	elector *fakeElector
+	store   *fakeStore
+	task    *fakeTask
+
+	scheduler *Scheduler
+	errs      chan error
+}
+
+func newFixture(t *testing.T) *fixture {
+	return &fixture{
+		t:       t,
+		elector: &fakeElector{leader: true},
+		store:   &fakeStore{},
+		task:    &fakeTask{},
+	}
+}
+
+func (fx *fixture) run(opts ...Option) {
+	opts = append([]Option{WithElectionInterval(5 * time.Millisecond)}, opts...)
+	fx.scheduler = New(fx.elector, fx.store, opts...)
+	fx.errs = make(chan error, 1)
+	go func() {
+		fx.errs <- fx.scheduler.Run(context.Background())
+	}()
+}
+
+func (fx *fixture) Finish() {
+	require.NoError(fx.t, fx.scheduler.Close())
+	require.NoError(fx.t, <-fx.errs)
+}
+
+type fakeElector struct {
+	leader   bool
+	checkErr error
+
+	mu       sync.Mutex
+	released bool
+}
+
+func (e *fakeElector) Acquire(ctx context.Context) (bool, error) {
+	return e.leader, nil
+}
+
+func (e *fakeElector) Check(ctx context.Context) error {
+	return e.checkErr
+}
+
+func (e *fakeElector) Release() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.released = true
+	return nil
+}
+
+func (e *fakeElector) Released() bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.released
+}
+
+type fakeStore struct {
+	mu   sync.Mutex
+	runs []Run
+}
+
+func (s *fakeStore) GetRuns(ctx context.Context) ([]Run, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Run(nil), s.runs...), nil
+}
+
+func (s *fakeStore) SaveRun(ctx context.Context, run Run) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.runs = append(s.runs, run)
+	return nil
+}
+
+func (s *fakeStore) Runs() []Run {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Run(nil), s.runs...)
+}
+
+type fakeTask struct {
+	err    error
+	panics bool
+
+	mu   sync.Mutex
+	runs int
+}
+
+func (f *fakeTask) Run(ctx context.Context) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.runs++
+	if f.panics {
+		panic("boom")
+	}
+	return f.err
+}
+
+func (f *fakeTask) Runs() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.runs
+}
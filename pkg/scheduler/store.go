@@ -0,0 +1,64 @@
+package scheduler
+
+import (
+	"context"
+	"github.com/ivanovaleksey/lendo/pkg/db"
+	"time"
+)
+
+const tableName = "scheduled_tasks"
+
+// Run is the last run of a task.
+type Run struct {
+	Task       string    `db:"task" json:"task"`
+	StartedAt  time.Time `db:"last_run_at" json:"last_run_at"`
+	DurationMS int64     `db:"last_duration_ms" json:"last_duration_ms"`
+	Error      string    `db:"last_error" json:"last_error"`
+}
+
+// Store keeps the last run of every task, so that a new leader continues the schedule.
+type Store interface {
+	GetRuns(ctx context.Context) ([]Run, error)
+	SaveRun(ctx context.Context, run Run) error
+}
+
+// PGStore keeps runs in the scheduled_tasks table, which must be created by the service migrations.
+type PGStore struct {
+	db *db.DB
+}
+
+func NewPGStore(database *db.DB) *PGStore {
+	return &PGStore{
+		db: database,
+	}
+}
+
+func (s *PGStore) GetRuns(ctx context.Context) ([]Run, error) {
+	const query = `
+		SELECT task, last_run_at, last_duration_ms, last_error
+		FROM ` + tableName + `
+		ORDER BY task
+	`
+
+	items := make([]Run, 0)
+	err := s.db.SelectContext(ctx, &items, query)
+	if err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+func (s *PGStore) SaveRun(ctx context.Context, run Run) error {
+	const query = `
+		INSERT INTO ` + tableName + ` (task, last_run_at, last_duration_ms, last_error)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (task) DO UPDATE
+		SET last_run_at = excluded.last_run_at,
+			last_duration_ms = excluded.last_duration_ms,
+			last_error = excluded.last_error,
+			updated_at = now()
+	`
+
+	_, err := s.db.ExecContext(ctx, query, run.Task, run.StartedAt, run.DurationMS, run.Error)
+	return err
+}
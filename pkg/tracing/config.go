@@ -0,0 +1,16 @@
+package tracing
+
+const (
+	ExporterNone   = "none"
+	ExporterStdout = "stdout"
+	ExporterOTLP   = "otlp"
+)
+
+type Config struct {
+	// Exporter is one of none, stdout or otlp.
+	Exporter string `default:"none"`
+	// Endpoint is a host:port of an OTLP/HTTP collector.
+	Endpoint    string  `default:"localhost:4318"`
+	Insecure    bool    `default:"true"`
+	SampleRatio float64 `split_words:"true" default:"1"`
+}
@@ -0,0 +1,76 @@
+package tracing
+
+import (
+	"context"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestMiddleware(t *testing.T) {
+	exporter := NewTestProvider()
+
+	router := chi.NewRouter()
+	router.Use(Middleware("test"))
+	router.Get("/items/{id}", func(w http.ResponseWriter, r *http.Request) {
+		assert.True(t, trace.SpanContextFromContext(r.Context()).IsValid())
+		w.WriteHeader(http.StatusNotFound)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/items/1", nil)
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 1)
+	assert.Equal(t, "GET /items/{id}", spans[0].Name)
+	assert.Equal(t, trace.SpanKindServer, spans[0].SpanKind)
+}
+
+func TestBus(t *testing.T) {
+	exporter := NewTestProvider()
+
+	ctx, parent := Tracer().Start(context.Background(), "parent")
+	header := make(http.Header)
+
+	_, pubSpan := StartPublish(ctx, "applications.new", header)
+	End(pubSpan, nil)
+	parent.End()
+
+	require.NotEmpty(t, header.Get("traceparent"))
+
+	ctx, subSpan := StartConsume(context.Background(), "applications.new", header)
+	End(subSpan, nil)
+
+	assert.Equal(t, parent.SpanContext().TraceID(), trace.SpanContextFromContext(ctx).TraceID())
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 3)
+	assert.Equal(t, "applications.new send", spans[0].Name)
+	assert.Equal(t, "applications.new process", spans[2].Name)
+	assert.Equal(t, pubSpan.SpanContext().SpanID(), spans[2].Parent.SpanID())
+}
+
+func TestInjectExtract(t *testing.T) {
+	NewTestProvider()
+
+	t.Run("without span", func(t *testing.T) {
+		carrier := Inject(context.Background())
+
+		assert.Nil(t, carrier)
+	})
+
+	t.Run("with span", func(t *testing.T) {
+		ctx, span := Tracer().Start(context.Background(), "test")
+		defer span.End()
+
+		carrier := Inject(ctx)
+		got := trace.SpanContextFromContext(Extract(context.Background(), carrier))
+
+		assert.Equal(t, span.SpanContext().TraceID(), got.TraceID())
+		assert.Equal(t, span.SpanContext().SpanID(), got.SpanID())
+	})
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	apiPubSub "github.com/ivanovaleksey/lendo/api/pubsub/applications"
+	"github.com/ivanovaleksey/lendo/api/repos/applications"
+	"github.com/ivanovaleksey/lendo/pkg/closer"
+	"github.com/ivanovaleksey/lendo/pkg/component"
+	"github.com/ivanovaleksey/lendo/pkg/db"
+	"github.com/ivanovaleksey/lendo/pkg/envelope"
+	"github.com/ivanovaleksey/lendo/pkg/logging"
+	"github.com/ivanovaleksey/lendo/pkg/metrics"
+	"github.com/ivanovaleksey/lendo/pkg/nats"
+	"github.com/ivanovaleksey/lendo/pkg/scheduler"
+	"github.com/ivanovaleksey/lendo/reconciler"
+	"github.com/ivanovaleksey/lendo/reconciler/config"
+	registryPubSub "github.com/ivanovaleksey/lendo/registry/pubsub/applications"
+	"github.com/ivanovaleksey/lendo/registry/repos/jobs"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"net/http"
+	"os"
+	"syscall"
+	"time"
+)
+
+func main() {
+	once := flag.Bool("once", false, "reconcile once, print the report and exit")
+	dryRun := flag.Bool("dry-run", false, "only report discrepancies without repairing them")
+	flag.Parse()
+
+	ctx := context.Background()
+
+	cfg, err := config.New()
+	if err != nil {
+		log.Fatal(err)
+	}
+	logging.Init(cfg.Log)
+
+	if err := runApps(ctx, cfg, *once, *dryRun); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func runApps(ctx context.Context, cfg config.Config, once, dryRun bool) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	apiDB, err := db.New(cfg.APIDB)
+	if err != nil {
+		return errors.Wrap(err, "can't create api db")
+	}
+	defer apiDB.Close()
+
+	registryDB, err := db.New(cfg.RegistryDB)
+	if err != nil {
+		return errors.Wrap(err, "can't create registry db")
+	}
+	defer registryDB.Close()
+
+	natsClient, err := nats.New(cfg.NATS)
+	if err != nil {
+		return errors.Wrap(err, "can't create nats client")
+	}
+	natsBus := nats.NewBus(natsClient)
+	defer natsClient.Close()
+
+	opts := []reconciler.Option{
+		reconciler.WithGrace(cfg.Grace),
+		reconciler.WithBatchSize(cfg.BatchSize),
+		reconciler.WithDryRun(dryRun),
+	}
+	rec := reconciler.New(
+		applicationsRepo.New(apiDB),
+		jobsRepo.New(registryDB),
+		apiPubSub.NewPub(natsBus, envelope.WithFormat(cfg.Events.Format)),
+		registryPubSub.NewPub(natsBus, envelope.WithFormat(cfg.Events.Format)),
+		opts...,
+	)
+
+	if once {
+		report, err := rec.Reconcile(ctx)
+		if err != nil {
+			return errors.Wrap(err, "can't reconcile")
+		}
+		return json.NewEncoder(os.Stdout).Encode(report)
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", metrics.Handler())
+	if cfg.AdminToken != "" {
+		mux.Handle(reconciler.ReportPath, rec.ReportHandler(cfg.AdminToken))
+	}
+	srv := http.Server{
+		Addr:    cfg.Addr,
+		Handler: mux,
+	}
+
+	appCloser := closer.New(closer.WithSignals(syscall.SIGTERM, syscall.SIGINT))
+	appCloser.Add(closer.PhaseIngress, func() error {
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		return srv.Shutdown(shutdownCtx)
+	})
+
+	// replicas elect a leader on the registry db, so that reconciliation runs once per interval
+	s := scheduler.New(
+		scheduler.NewPGElector(registryDB, "reconciler"),
+		scheduler.NewPGStore(registryDB),
+		scheduler.WithTask("reconcile", scheduler.Every(cfg.Interval), rec.Task),
+	)
+	supervisor := component.NewSupervisor(appCloser)
+	closure := supervisor.Run(ctx, s, component.WithRestartPolicy(component.FailFast))
+	appCloser.Add(closer.PhaseWorkers, closure)
+
+	go func() {
+		log.Debugf("starting server on %s", cfg.Addr)
+		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Errorf("server error: %v", err)
+			appCloser.CloseAll()
+		}
+	}()
+
+	appCloser.Wait()
+	return nil
+}
@@ -0,0 +1,35 @@
+package config
+
+import (
+	"github.com/ivanovaleksey/lendo/pkg/db"
+	"github.com/ivanovaleksey/lendo/pkg/envelope"
+	"github.com/ivanovaleksey/lendo/pkg/logging"
+	"github.com/ivanovaleksey/lendo/pkg/nats"
+	"github.com/kelseyhightower/envconfig"
+	"time"
+)
+
+type Config struct {
+	Addr string `default:":8000"`
+	// AdminToken protects the report endpoint, the endpoint is disabled when it's empty.
+	AdminToken string          `envconfig:"admin_token"`
+	APIDB      db.Config       `envconfig:"api_db"`
+	RegistryDB db.Config       `envconfig:"registry_db"`
+	Events     envelope.Config `envconfig:"events"`
+	Log        logging.Config  `envconfig:"log"`
+	NATS       nats.Config     `envconfig:"nats"`
+	// Interval is how often reconciliation runs in the scheduled mode.
+	Interval time.Duration `default:"10m"`
+	// Grace is how old an application must be to be reconciled, so that in-flight messages are not republished.
+	Grace     time.Duration `default:"5m"`
+	BatchSize int           `envconfig:"batch_size" default:"100"`
+}
+
+func New() (Config, error) {
+	var cfg Config
+	err := envconfig.Process("lendo", &cfg)
+	if err != nil {
+		return Config{}, err
+	}
+	return cfg, err
+}
@@ -0,0 +1,71 @@
+package reconciler
+
+import (
+	"crypto/subtle"
+	"github.com/go-chi/render"
+	"github.com/pkg/errors"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// ReportPath serves a report of a dry run, so that discrepancies are inspected without access to the databases.
+const ReportPath = "/report"
+
+// ReportHandler reconciles in the dry run mode on every request and renders the report.
+// The grace query parameter overrides the grace period of the reconciler.
+// Requests are authorized by the bearer token, every request is unauthorized when the token is empty.
+func (r *Reconciler) ReportHandler(token string) http.Handler {
+	const prefix = "Bearer "
+
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		header := req.Header.Get("Authorization")
+		value := strings.TrimPrefix(header, prefix)
+		if token == "" || header == value || subtle.ConstantTimeCompare([]byte(value), []byte(token)) != 1 {
+			render.Render(w, req, errorResponse(http.StatusUnauthorized, errors.New("invalid admin token")))
+			return
+		}
+		if req.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		dryRun := *r
+		dryRun.dryRun = true
+		if value := req.URL.Query().Get("grace"); value != "" {
+			grace, err := time.ParseDuration(value)
+			if err != nil {
+				render.Render(w, req, errorResponse(http.StatusBadRequest, err))
+				return
+			}
+			dryRun.grace = grace
+		}
+
+		report, err := dryRun.Reconcile(req.Context())
+		if err != nil {
+			render.Render(w, req, errorResponse(http.StatusInternalServerError, err))
+			return
+		}
+		render.JSON(w, req, report)
+	})
+}
+
+// ErrorResponse has the shape of the api and registry errors, so that clients decode them the same way.
+type ErrorResponse struct {
+	HTTPCode int    `json:"-"`
+	Error    string `json:"error"`
+	Debug    string `json:"debug"`
+}
+
+func (e ErrorResponse) Render(w http.ResponseWriter, r *http.Request) error {
+	render.Status(r, e.HTTPCode)
+	return nil
+}
+
+func errorResponse(code int, err error) render.Renderer {
+	return ErrorResponse{
+		HTTPCode: code,
+		Error:    strings.ToLower(http.StatusText(code)),
+		Debug:    err.Error(),
+	}
+}
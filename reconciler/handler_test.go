@@ -0,0 +1,78 @@
+package reconciler
+
+import (
+	"encoding/json"
+	"github.com/ivanovaleksey/lendo/pkg/models"
+	registryModels "github.com/ivanovaleksey/lendo/registry/models"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	uuid "github.com/satori/go.uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+const adminToken = "secret"
+
+func TestReconciler_ReportHandler(t *testing.T) {
+	t.Run("should report without repairing", func(t *testing.T) {
+		fx := newFixture(t)
+		defer fx.Finish()
+
+		application := fx.buildApplication(models.ApplicationStatusNew)
+		fx.expectBatches([]models.Application{application})
+		fx.jobs.On("GetLatestByApplicationIDs", fx.ctx, []uuid.UUID{application.ID}).Return([]registryModels.Job{}, nil)
+		missingJobs := testutil.ToFloat64(discrepancies.WithLabelValues(kindMissingJob))
+
+		resp := fx.report(ReportPath+"?grace=1m", adminToken)
+
+		require.Equal(t, http.StatusOK, resp.Code)
+		var report Report
+		require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &report))
+		assert.Equal(t, Report{Checked: 1, MissingJobs: 1}, report)
+		assert.False(t, fx.reconciler.dryRun)
+		assert.Equal(t, missingJobs, testutil.ToFloat64(discrepancies.WithLabelValues(kindMissingJob)), "metrics are left to the task")
+	})
+
+	t.Run("when token is invalid", func(t *testing.T) {
+		fx := newFixture(t)
+		defer fx.Finish()
+
+		resp := fx.report(ReportPath, "wrong")
+
+		require.Equal(t, http.StatusUnauthorized, resp.Code)
+		var body ErrorResponse
+		require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &body))
+		assert.Equal(t, "unauthorized", body.Error)
+	})
+
+	t.Run("when token is not configured", func(t *testing.T) {
+		fx := newFixture(t)
+		defer fx.Finish()
+
+		req := httptest.NewRequest(http.MethodGet, ReportPath, nil)
+		resp := httptest.NewRecorder()
+		fx.reconciler.ReportHandler("").ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusUnauthorized, resp.Code)
+	})
+
+	t.Run("when grace is invalid", func(t *testing.T) {
+		fx := newFixture(t)
+		defer fx.Finish()
+
+		resp := fx.report(ReportPath+"?grace=soon", adminToken)
+
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+	})
+}
+
+func (fx *fixture) report(target, token string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, target, nil).WithContext(fx.ctx)
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp := httptest.NewRecorder()
+	fx.reconciler.ReportHandler(adminToken).ServeHTTP(resp, req)
+	return resp
+}
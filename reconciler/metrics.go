@@ -0,0 +1,29 @@
+package reconciler
+
+import (
+	"github.com/ivanovaleksey/lendo/pkg/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const (
+	kindMissingJob     = "missing_job"
+	kindStatusMismatch = "status_mismatch"
+	kindFailedJob      = "failed_job"
+)
+
+var (
+	discrepancies = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metrics.Namespace,
+		Subsystem: "reconciler",
+		Name:      "discrepancies",
+		Help:      "Number of discrepancies found by the last reconciliation by kind.",
+	}, []string{"kind"})
+
+	repairFailures = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: metrics.Namespace,
+		Subsystem: "reconciler",
+		Name:      "repair_failures_total",
+		Help:      "Number of discrepancies which could not be repaired.",
+	})
+)
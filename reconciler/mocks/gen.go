@@ -0,0 +1,6 @@
+//go:generate mockery --dir .. --output . --name Applications --filename applications.mock.go
+//go:generate mockery --dir .. --output . --name Jobs --filename jobs.mock.go
+//go:generate mockery --dir .. --output . --name NewNotifier --filename new_notifier.mock.go
+//go:generate mockery --dir .. --output . --name ChangeNotifier --filename change_notifier.mock.go
+
+package mocks
@@ -0,0 +1,27 @@
+package reconciler
+
+import (
+	"time"
+)
+
+type Option func(*Reconciler)
+
+// WithGrace sets how old an application must be to be reconciled.
+func WithGrace(d time.Duration) Option {
+	return func(r *Reconciler) {
+		r.grace = d
+	}
+}
+
+func WithBatchSize(n int) Option {
+	return func(r *Reconciler) {
+		r.batchSize = n
+	}
+}
+
+// WithDryRun makes the reconciler only report discrepancies without repairing them.
+func WithDryRun(dryRun bool) Option {
+	return func(r *Reconciler) {
+		r.dryRun = dryRun
+	}
+}
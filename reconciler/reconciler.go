@@ -0,0 +1,196 @@
+// Package reconciler repairs drift between api applications and registry jobs,
+// which is possible since messages between the services are fire-and-forget.
+package reconciler
+
+import (
+	"context"
+	"github.com/ivanovaleksey/lendo/pkg/models"
+	registryModels "github.com/ivanovaleksey/lendo/registry/models"
+	"github.com/pkg/errors"
+	uuid "github.com/satori/go.uuid"
+	log "github.com/sirupsen/logrus"
+	"time"
+)
+
+const (
+	defaultGrace     = 5 * time.Minute
+	defaultBatchSize = 100
+)
+
+type Applications interface {
+	GetUnfinished(ctx context.Context, createdBefore time.Time, after uuid.UUID, limit int) ([]models.Application, error)
+}
+
+type Jobs interface {
+	GetLatestByApplicationIDs(ctx context.Context, ids []uuid.UUID) ([]registryModels.Job, error)
+}
+
+// NewNotifier publishes applications.new events consumed by the registry.
+type NewNotifier interface {
+	NewApplication(ctx context.Context, application models.Application) error
+}
+
+// ChangeNotifier publishes applications.changed events consumed by the api.
+type ChangeNotifier interface {
+	ApplicationStatusChanged(ctx context.Context, change models.StatusChange) error
+}
+
+// Report counts discrepancies found by a reconciliation.
+type Report struct {
+	Checked int `json:"checked"`
+	// MissingJobs is a number of new applications the registry has no job for.
+	MissingJobs int `json:"missing_jobs"`
+	// StatusMismatches is a number of applications whose status differs from the final status in the registry.
+	StatusMismatches int `json:"status_mismatches"`
+	// FailedJobs is a number of applications whose latest job has failed, they need an operator.
+	FailedJobs int `json:"failed_jobs"`
+
+	Republished int `json:"republished"`
+	Reemitted   int `json:"reemitted"`
+	Failed      int `json:"failed"`
+}
+
+// Reconciler compares unfinished api applications with the latest registry jobs:
+// a new application without a job is published to the registry again,
+// an application whose job is done is notified about the final status again,
+// an application whose job has failed is only reported.
+type Reconciler struct {
+	applications   Applications
+	jobs           Jobs
+	newNotifier    NewNotifier
+	changeNotifier ChangeNotifier
+
+	grace     time.Duration
+	batchSize int
+	dryRun    bool
+	logger    log.FieldLogger
+}
+
+func New(applications Applications, jobs Jobs, newNotifier NewNotifier, changeNotifier ChangeNotifier, opts ...Option) *Reconciler {
+	r := &Reconciler{
+		applications:   applications,
+		jobs:           jobs,
+		newNotifier:    newNotifier,
+		changeNotifier: changeNotifier,
+		grace:          defaultGrace,
+		batchSize:      defaultBatchSize,
+		logger:         log.WithField("component", "reconciler"),
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// Reconcile checks all the unfinished applications older than the grace period in batches.
+func (r *Reconciler) Reconcile(ctx context.Context) (Report, error) {
+	var (
+		report Report
+		after  = uuid.Nil
+		before = time.Now().UTC().Add(-r.grace)
+	)
+	for {
+		applications, err := r.applications.GetUnfinished(ctx, before, after, r.batchSize)
+		if err != nil {
+			return report, errors.Wrap(err, "can't get applications")
+		}
+		if len(applications) == 0 {
+			break
+		}
+
+		if err := r.reconcileBatch(ctx, applications, &report); err != nil {
+			return report, err
+		}
+		after = applications[len(applications)-1].ID
+	}
+	return report, nil
+}
+
+func (r *Reconciler) reconcileBatch(ctx context.Context, applications []models.Application, report *Report) error {
+	ids := make([]uuid.UUID, 0, len(applications))
+	for _, application := range applications {
+		ids = append(ids, application.ID)
+	}
+
+	jobs, err := r.jobs.GetLatestByApplicationIDs(ctx, ids)
+	if err != nil {
+		return errors.Wrap(err, "can't get jobs")
+	}
+	jobsByApplication := make(map[uuid.UUID]registryModels.Job, len(jobs))
+	registryApplications := make(map[uuid.UUID]models.Application, len(jobs))
+	for _, job := range jobs {
+		application, err := job.Application()
+		if err != nil {
+			return errors.Wrapf(err, "can't decode application of job %s", job.ID)
+		}
+		jobsByApplication[application.ID] = job
+		registryApplications[application.ID] = application
+	}
+
+	for _, application := range applications {
+		report.Checked++
+		logger := r.logger.WithField("application_id", application.ID.String())
+
+		job, ok := jobsByApplication[application.ID]
+		registryStatus := registryApplications[application.ID].Status
+		switch {
+		case !ok && application.Status == models.ApplicationStatusNew:
+			report.MissingJobs++
+			logger.Info("application has no job")
+			if r.dryRun {
+				continue
+			}
+			if err := r.newNotifier.NewApplication(ctx, application); err != nil {
+				report.Failed++
+				repairFailures.Inc()
+				logger.Errorf("can't republish application: %v", err)
+				continue
+			}
+			report.Republished++
+
+		case ok && job.Status == registryModels.JobStatusDone && registryStatus != application.Status:
+			report.StatusMismatches++
+			logger.Infof("application is %s, but registry has %s", application.Status, registryStatus)
+			if r.dryRun {
+				continue
+			}
+			change := models.StatusChange{
+				ID:     application.ID,
+				Status: registryStatus,
+			}
+			if err := r.changeNotifier.ApplicationStatusChanged(ctx, change); err != nil {
+				report.Failed++
+				repairFailures.Inc()
+				logger.Errorf("can't re-emit status change: %v", err)
+				continue
+			}
+			report.Reemitted++
+
+		case ok && job.Status == registryModels.JobStatusFailed:
+			report.FailedJobs++
+			logger.Warnf("application job %s has failed", job.ID)
+		}
+	}
+	return nil
+}
+
+// Task reconciles once, logs the report and exports the discrepancies, it's run by the scheduler.
+// Reports served on demand don't touch the metrics, they may be made with another grace period.
+func (r *Reconciler) Task(ctx context.Context) error {
+	report, err := r.Reconcile(ctx)
+	if err != nil {
+		return err
+	}
+	discrepancies.WithLabelValues(kindMissingJob).Set(float64(report.MissingJobs))
+	discrepancies.WithLabelValues(kindStatusMismatch).Set(float64(report.StatusMismatches))
+	discrepancies.WithLabelValues(kindFailedJob).Set(float64(report.FailedJobs))
+
+	r.logger.WithFields(log.Fields{
+		"checked":           report.Checked,
+		"missing_jobs":      report.MissingJobs,
+		"status_mismatches": report.StatusMismatches,
+		"failed_jobs":       report.FailedJobs,
+		"failed":            report.Failed,
+	}).Info("reconciled")
+	return nil
+}
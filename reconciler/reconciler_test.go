@@ -0,0 +1,223 @@
+package reconciler
+
+import (
+	"context"
+	"github.com/brianvoe/gofakeit"
+	"github.com/ivanovaleksey/lendo/pkg/models"
+	"github.com/ivanovaleksey/lendo/reconciler/mocks"
+	registryModels "github.com/ivanovaleksey/lendo/registry/models"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	uuid "github.com/satori/go.uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestReconciler_Reconcile(t *testing.T) {
+	t.Run("should republish application without job", func(t *testing.T) {
+		fx := newFixture(t)
+		defer fx.Finish()
+
+		application := fx.buildApplication(models.ApplicationStatusNew)
+		fx.expectBatches([]models.Application{application})
+		fx.jobs.On("GetLatestByApplicationIDs", fx.ctx, []uuid.UUID{application.ID}).Return([]registryModels.Job{}, nil)
+		fx.newNotifier.On("NewApplication", fx.ctx, application).Return(nil)
+
+		report, err := fx.reconciler.Reconcile(fx.ctx)
+
+		require.NoError(t, err)
+		assert.Equal(t, Report{Checked: 1, MissingJobs: 1, Republished: 1}, report)
+	})
+
+	t.Run("should re-emit final status", func(t *testing.T) {
+		fx := newFixture(t)
+		defer fx.Finish()
+
+		application := fx.buildApplication(models.ApplicationStatusPending)
+		job := fx.buildJob(application, registryModels.JobStatusDone, models.ApplicationStatusCompleted)
+		fx.expectBatches([]models.Application{application})
+		fx.jobs.On("GetLatestByApplicationIDs", fx.ctx, []uuid.UUID{application.ID}).Return([]registryModels.Job{job}, nil)
+
+		change := models.StatusChange{ID: application.ID, Status: models.ApplicationStatusCompleted}
+		fx.changeNotifier.On("ApplicationStatusChanged", fx.ctx, change).Return(nil)
+
+		report, err := fx.reconciler.Reconcile(fx.ctx)
+
+		require.NoError(t, err)
+		assert.Equal(t, Report{Checked: 1, StatusMismatches: 1, Reemitted: 1}, report)
+	})
+
+	t.Run("should skip consistent applications", func(t *testing.T) {
+		fx := newFixture(t)
+		defer fx.Finish()
+
+		pending := fx.buildApplication(models.ApplicationStatusPending)
+		inProgress := fx.buildApplication(models.ApplicationStatusPending)
+		jobs := []registryModels.Job{
+			fx.buildJob(pending, registryModels.JobStatusDone, models.ApplicationStatusPending),
+			fx.buildJob(inProgress, registryModels.JobStatusPending, models.ApplicationStatusCompleted),
+		}
+		fx.expectBatches([]models.Application{pending, inProgress})
+		fx.jobs.On("GetLatestByApplicationIDs", fx.ctx, []uuid.UUID{pending.ID, inProgress.ID}).Return(jobs, nil)
+
+		report, err := fx.reconciler.Reconcile(fx.ctx)
+
+		require.NoError(t, err)
+		assert.Equal(t, Report{Checked: 2}, report)
+	})
+
+	t.Run("should report failed jobs", func(t *testing.T) {
+		fx := newFixture(t)
+		defer fx.Finish()
+
+		application := fx.buildApplication(models.ApplicationStatusPending)
+		job := fx.buildJob(application, registryModels.JobStatusFailed, models.ApplicationStatusPending)
+		fx.expectBatches([]models.Application{application})
+		fx.jobs.On("GetLatestByApplicationIDs", fx.ctx, []uuid.UUID{application.ID}).Return([]registryModels.Job{job}, nil)
+
+		report, err := fx.reconciler.Reconcile(fx.ctx)
+
+		require.NoError(t, err)
+		assert.Equal(t, Report{Checked: 1, FailedJobs: 1}, report)
+	})
+
+	t.Run("should go through batches", func(t *testing.T) {
+		fx := newFixture(t, WithBatchSize(1))
+		defer fx.Finish()
+
+		first := fx.buildApplication(models.ApplicationStatusNew)
+		second := fx.buildApplication(models.ApplicationStatusNew)
+		fx.expectBatches([]models.Application{first}, []models.Application{second})
+		fx.jobs.On("GetLatestByApplicationIDs", fx.ctx, mock.Anything).Return([]registryModels.Job{}, nil).Twice()
+		fx.newNotifier.On("NewApplication", fx.ctx, mock.Anything).Return(nil).Twice()
+
+		report, err := fx.reconciler.Reconcile(fx.ctx)
+
+		require.NoError(t, err)
+		assert.Equal(t, Report{Checked: 2, MissingJobs: 2, Republished: 2}, report)
+	})
+
+	t.Run("should only report in dry run", func(t *testing.T) {
+		fx := newFixture(t, WithDryRun(true))
+		defer fx.Finish()
+
+		application := fx.buildApplication(models.ApplicationStatusNew)
+		fx.expectBatches([]models.Application{application})
+		fx.jobs.On("GetLatestByApplicationIDs", fx.ctx, []uuid.UUID{application.ID}).Return([]registryModels.Job{}, nil)
+
+		report, err := fx.reconciler.Reconcile(fx.ctx)
+
+		require.NoError(t, err)
+		assert.Equal(t, Report{Checked: 1, MissingJobs: 1}, report)
+	})
+
+	t.Run("should count failed repairs", func(t *testing.T) {
+		fx := newFixture(t)
+		defer fx.Finish()
+
+		application := fx.buildApplication(models.ApplicationStatusNew)
+		fx.expectBatches([]models.Application{application})
+		fx.jobs.On("GetLatestByApplicationIDs", fx.ctx, []uuid.UUID{application.ID}).Return([]registryModels.Job{}, nil)
+		fx.newNotifier.On("NewApplication", fx.ctx, application).Return(errors.New(gofakeit.Sentence(3)))
+
+		report, err := fx.reconciler.Reconcile(fx.ctx)
+
+		require.NoError(t, err)
+		assert.Equal(t, Report{Checked: 1, MissingJobs: 1, Failed: 1}, report)
+	})
+
+	t.Run("when cannot get jobs", func(t *testing.T) {
+		fx := newFixture(t)
+		defer fx.Finish()
+
+		application := fx.buildApplication(models.ApplicationStatusNew)
+		fx.applications.On("GetUnfinished", fx.ctx, mock.Anything, uuid.Nil, defaultBatchSize).Return([]models.Application{application}, nil)
+		fx.jobs.On("GetLatestByApplicationIDs", fx.ctx, mock.Anything).Return(nil, errors.New(gofakeit.Sentence(3)))
+
+		_, err := fx.reconciler.Reconcile(fx.ctx)
+
+		assert.Error(t, err)
+	})
+}
+
+func TestReconciler_Task(t *testing.T) {
+	t.Run("should export discrepancies", func(t *testing.T) {
+		fx := newFixture(t)
+		defer fx.Finish()
+
+		application := fx.buildApplication(models.ApplicationStatusPending)
+		job := fx.buildJob(application, registryModels.JobStatusFailed, models.ApplicationStatusPending)
+		fx.expectBatches([]models.Application{application})
+		fx.jobs.On("GetLatestByApplicationIDs", fx.ctx, []uuid.UUID{application.ID}).Return([]registryModels.Job{job}, nil)
+
+		err := fx.reconciler.Task(fx.ctx)
+
+		require.NoError(t, err)
+		assert.Equal(t, float64(1), testutil.ToFloat64(discrepancies.WithLabelValues(kindFailedJob)))
+		assert.Equal(t, float64(0), testutil.ToFloat64(discrepancies.WithLabelValues(kindMissingJob)))
+	})
+}
+
+type fixture struct {
+	t   *testing.T
+	ctx context.Context
+
+	applications   *mocks.Applications
+	jobs           *mocks.Jobs
+	newNotifier    *mocks.NewNotifier
+	changeNotifier *mocks.ChangeNotifier
+
+	reconciler *Reconciler
+}
+
+func newFixture(t *testing.T, opts ...Option) *fixture {
+	fx := &fixture{
+		t:              t,
+		ctx:            context.Background(),
+		applications:   &mocks.Applications{},
+		jobs:           &mocks.Jobs{},
+		newNotifier:    &mocks.NewNotifier{},
+		changeNotifier: &mocks.ChangeNotifier{},
+	}
+	fx.reconciler = New(fx.applications, fx.jobs, fx.newNotifier, fx.changeNotifier, opts...)
+	return fx
+}
+
+func (fx *fixture) Finish() {
+	fx.applications.AssertExpectations(fx.t)
+	fx.jobs.AssertExpectations(fx.t)
+	fx.newNotifier.AssertExpectations(fx.t)
+	fx.changeNotifier.AssertExpectations(fx.t)
+}
+
+// expectBatches makes applications returned in the given batches followed by an empty one.
+func (fx *fixture) expectBatches(batches ...[]models.Application) {
+	after := uuid.Nil
+	for _, batch := range batches {
+		fx.applications.On("GetUnfinished", fx.ctx, mock.Anything, after, fx.reconciler.batchSize).Return(batch, nil).Once()
+		after = batch[len(batch)-1].ID
+	}
+	fx.applications.On("GetUnfinished", fx.ctx, mock.Anything, after, fx.reconciler.batchSize).Return([]models.Application{}, nil).Once()
+}
+
+func (fx *fixture) buildApplication(status models.ApplicationStatus) models.Application {
+	return models.Application{
+		NewApplication: models.NewApplication{
+			FirstName: gofakeit.FirstName(),
+			LastName:  gofakeit.LastName(),
+		},
+		ID:     uuid.NewV4(),
+		Status: status,
+	}
+}
+
+func (fx *fixture) buildJob(application models.Application, status registryModels.JobStatus, bankStatus models.ApplicationStatus) registryModels.Job {
+	application.Status = bankStatus
+	job, err := registryModels.NewApplicationJob(application)
+	require.NoError(fx.t, err)
+	job.ID = uuid.NewV4()
+	job.Status = status
+	return job
+}
@@ -0,0 +1,24 @@
+package app
+
+import (
+	"crypto/subtle"
+	"github.com/go-chi/render"
+	"github.com/ivanovaleksey/lendo/registry/responses"
+	"github.com/pkg/errors"
+	"net/http"
+	"strings"
+)
+
+func (app *App) verifyAdminToken(next http.Handler) http.Handler {
+	const prefix = "Bearer "
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header := r.Header.Get("Authorization")
+		token := strings.TrimPrefix(header, prefix)
+		if header == token || subtle.ConstantTimeCompare([]byte(token), []byte(app.cfg.Admin.Token)) != 1 {
+			render.Render(w, r, responses.ErrUnauthorized(errors.New("invalid admin token")))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
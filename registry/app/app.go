@@ -0,0 +1,79 @@
+package app
+
+import (
+	"github.com/go-chi/chi/v5"
+	"github.com/ivanovaleksey/lendo/pkg/envelope"
+	"github.com/ivanovaleksey/lendo/pkg/health"
+	"github.com/ivanovaleksey/lendo/pkg/logging"
+	"github.com/ivanovaleksey/lendo/pkg/metrics"
+	"github.com/ivanovaleksey/lendo/pkg/tracing"
+	"github.com/ivanovaleksey/lendo/registry/config"
+	"net/http"
+)
+
+// App is an HTTP surface of the registry.
+type App struct {
+	cfg    config.Config
+	router chi.Router
+
+	callbacksSrv  CallbacksService
+	exchangesRepo ExchangesRepo
+	jobsSrv       JobsService
+	health        *health.Health
+}
+
+func New(cfg config.Config, opts ...Option) *App {
+	app := &App{
+		cfg: cfg,
+	}
+	for _, opt := range opts {
+		opt(app)
+	}
+	app.initRouter()
+	return app
+}
+
+func (app *App) initRouter() {
+	router := chi.NewRouter()
+	router.Use(metrics.Middleware("registry"))
+	router.Use(tracing.Middleware("registry"))
+	router.Use(envelope.Middleware)
+	router.Use(logging.Middleware("registry"))
+
+	router.Handle("/metrics", metrics.Handler())
+	if app.health != nil {
+		router.Get("/healthz", app.health.LivenessHandler())
+		router.Get("/readyz", app.health.ReadinessHandler())
+	}
+
+	if app.cfg.Bank.Callback.Secret != "" {
+		router.Route("/callbacks", func(r chi.Router) {
+			r.With(app.verifyBankSignature).Post("/bank", app.BankCallback())
+		})
+	}
+	if app.cfg.Admin.Token != "" {
+		router.Route("/admin", func(r chi.Router) {
+			r.Use(app.verifyAdminToken)
+
+			if app.exchangesRepo != nil {
+				r.Get("/applications/{id}/exchanges", app.GetApplicationExchanges())
+			}
+			if app.jobsSrv != nil {
+				r.Route("/jobs", func(r chi.Router) {
+					r.Get("/", app.GetJobs())
+					r.Post("/retry-failed", app.RetryFailedJobs())
+					r.Get("/{id}", app.GetJob())
+					r.Post("/{id}/retry", app.ControlJob(app.jobsSrv.Retry))
+					r.Post("/{id}/cancel", app.ControlJob(app.jobsSrv.Cancel))
+					r.Post("/{id}/fail", app.ControlJob(app.jobsSrv.Fail))
+					r.Post("/{id}/requeue", app.ControlJob(app.jobsSrv.Requeue))
+				})
+			}
+		})
+	}
+	app.router = router
+}
+
+func (app *App) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
+	app.router.ServeHTTP(writer, request)
+}
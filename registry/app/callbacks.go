@@ -0,0 +1,81 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"github.com/go-chi/render"
+	"github.com/ivanovaleksey/lendo/registry/bank"
+	"github.com/ivanovaleksey/lendo/registry/responses"
+	"github.com/ivanovaleksey/lendo/registry/services/callbacks"
+	"github.com/pkg/errors"
+	uuid "github.com/satori/go.uuid"
+	"io/ioutil"
+	"net/http"
+	"time"
+)
+
+const maxCallbackSize = 1 << 20
+
+type CallbacksService interface {
+	Apply(ctx context.Context, callback bank.Callback) error
+}
+
+// BankCallback applies an application status pushed by a bank partner.
+// Unknown applications are reported with 404, so the partner can retry later. Only a final status
+// is accepted, the job is done once it's applied.
+func (app *App) BankCallback() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		defer r.Body.Close()
+
+		ctx := r.Context()
+
+		var callback bank.Callback
+		err := json.NewDecoder(r.Body).Decode(&callback)
+		if err != nil {
+			render.Render(w, r, responses.ErrBadRequest(err))
+			return
+		}
+		if callback.ApplicationID == uuid.Nil || callback.Status == "" {
+			render.Render(w, r, responses.ErrBadRequest(errors.New("application_id and status are required")))
+			return
+		}
+		if !callback.Status.Final() {
+			render.Render(w, r, responses.ErrBadRequest(errors.Errorf("status must be completed or rejected, got %q", callback.Status)))
+			return
+		}
+
+		err = app.callbacksSrv.Apply(ctx, callback)
+		switch {
+		case err == callbacksSrv.ErrJobNotFound:
+			render.Render(w, r, responses.ErrNotFound(err))
+			return
+		case err != nil:
+			render.Render(w, r, responses.ErrInternal(err))
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func (app *App) verifyBankSignature(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, maxCallbackSize))
+		if err != nil {
+			render.Render(w, r, responses.ErrBadRequest(err))
+			return
+		}
+		r.Body.Close()
+
+		cfg := app.cfg.Bank.Callback
+		err = bank.Verify(cfg.Secret, r.Header.Get(bank.TimestampHeader), r.Header.Get(bank.SignatureHeader), body, cfg.Tolerance, time.Now())
+		if err != nil {
+			render.Render(w, r, responses.ErrUnauthorized(err))
+			return
+		}
+
+		r.Body = ioutil.NopCloser(bytes.NewReader(body))
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,106 @@
+package app
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"github.com/brianvoe/gofakeit"
+	commonModels "github.com/ivanovaleksey/lendo/pkg/models"
+	"github.com/ivanovaleksey/lendo/registry/bank"
+	callbacksSrv "github.com/ivanovaleksey/lendo/registry/services/callbacks"
+	uuid "github.com/satori/go.uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestApp_BankCallback(t *testing.T) {
+	callback := bank.Callback{
+		ApplicationID: uuid.NewV4(),
+		Status:        commonModels.ApplicationStatusCompleted,
+	}
+	body, _ := json.Marshal(callback)
+
+	t.Run("with invalid signature", func(t *testing.T) {
+		fx := newFixture(t)
+		defer fx.Finish()
+
+		req := fx.newCallbackRequest(body)
+		req.Header.Set(bank.SignatureHeader, "invalid")
+
+		resp := fx.do(req)
+
+		assert.Equal(t, http.StatusUnauthorized, resp.Code)
+	})
+
+	t.Run("when job does not exist", func(t *testing.T) {
+		fx := newFixture(t)
+		defer fx.Finish()
+
+		fx.callbacksSrv.On("Apply", mock.Anything, callback).Return(callbacksSrv.ErrJobNotFound)
+
+		resp := fx.do(fx.newCallbackRequest(body))
+
+		assert.Equal(t, http.StatusNotFound, resp.Code)
+	})
+
+	t.Run("when cannot apply callback", func(t *testing.T) {
+		fx := newFixture(t)
+		defer fx.Finish()
+
+		fx.callbacksSrv.On("Apply", mock.Anything, callback).Return(errors.New(gofakeit.Sentence(3)))
+
+		resp := fx.do(fx.newCallbackRequest(body))
+
+		assert.Equal(t, http.StatusInternalServerError, resp.Code)
+	})
+
+	t.Run("with invalid body", func(t *testing.T) {
+		fx := newFixture(t)
+		defer fx.Finish()
+
+		resp := fx.do(fx.newCallbackRequest([]byte(`{}`)))
+
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+	})
+
+	t.Run("with status which is not final", func(t *testing.T) {
+		for _, status := range []string{commonModels.ApplicationStatusNew, commonModels.ApplicationStatusPending, gofakeit.Word()} {
+			fx := newFixture(t)
+
+			body, _ := json.Marshal(bank.Callback{
+				ApplicationID: callback.ApplicationID,
+				Status:        commonModels.ApplicationStatus(status),
+			})
+			resp := fx.do(fx.newCallbackRequest(body))
+
+			assert.Equal(t, http.StatusBadRequest, resp.Code, status)
+			fx.Finish()
+		}
+	})
+
+	t.Run("when everything is fine", func(t *testing.T) {
+		fx := newFixture(t)
+		defer fx.Finish()
+
+		fx.callbacksSrv.On("Apply", mock.Anything, callback).Return(nil)
+
+		resp := fx.do(fx.newCallbackRequest(body))
+
+		assert.Equal(t, http.StatusNoContent, resp.Code)
+	})
+}
+
+func (fx *fixture) newCallbackRequest(body []byte) *http.Request {
+	req, err := http.NewRequest(http.MethodPost, "/callbacks/bank", bytes.NewReader(body))
+	require.NoError(fx.t, err)
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set(bank.TimestampHeader, timestamp)
+	req.Header.Set(bank.SignatureHeader, bank.Sign(fx.secret, timestamp, body))
+	return req
+}
@@ -0,0 +1,35 @@
+package app
+
+import (
+	"context"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+	"github.com/ivanovaleksey/lendo/registry/models"
+	"github.com/ivanovaleksey/lendo/registry/responses"
+	uuid "github.com/satori/go.uuid"
+	"net/http"
+)
+
+type ExchangesRepo interface {
+	GetByApplicationID(ctx context.Context, id uuid.UUID) ([]models.BankExchange, error)
+}
+
+// GetApplicationExchanges lists bank exchanges recorded for the application in chronological order.
+func (app *App) GetApplicationExchanges() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := uuid.FromString(chi.URLParam(r, "id"))
+		if err != nil {
+			render.Render(w, r, responses.ErrBadRequest(err))
+			return
+		}
+
+		exchanges, err := app.exchangesRepo.GetByApplicationID(r.Context(), id)
+		if err != nil {
+			render.Render(w, r, responses.ErrInternal(err))
+			return
+		}
+
+		resp := responses.GetExchangesResponse{Items: exchanges}
+		render.Render(w, r, resp)
+	}
+}
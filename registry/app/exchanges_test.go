@@ -0,0 +1,147 @@
+package app
+
+import (
+	"encoding/json"
+	"github.com/brianvoe/gofakeit"
+	"github.com/ivanovaleksey/lendo/registry/app/mocks"
+	"github.com/ivanovaleksey/lendo/registry/bank"
+	"github.com/ivanovaleksey/lendo/registry/config"
+	"github.com/ivanovaleksey/lendo/registry/models"
+	"github.com/ivanovaleksey/lendo/registry/responses"
+	"github.com/pkg/errors"
+	uuid "github.com/satori/go.uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestApp_AdminToken(t *testing.T) {
+	t.Run("without token", func(t *testing.T) {
+		fx := newFixture(t)
+		defer fx.Finish()
+
+		req, err := http.NewRequest(http.MethodGet, "/admin/applications/"+uuid.NewV4().String()+"/exchanges", nil)
+		require.NoError(t, err)
+
+		resp := fx.do(req)
+
+		assert.Equal(t, http.StatusUnauthorized, resp.Code)
+	})
+
+	t.Run("with invalid token", func(t *testing.T) {
+		fx := newFixture(t)
+		defer fx.Finish()
+
+		req, err := http.NewRequest(http.MethodGet, "/admin/applications/"+uuid.NewV4().String()+"/exchanges", nil)
+		require.NoError(t, err)
+		req.Header.Set("Authorization", "Bearer invalid")
+
+		resp := fx.do(req)
+
+		assert.Equal(t, http.StatusUnauthorized, resp.Code)
+	})
+}
+
+func TestApp_GetApplicationExchanges(t *testing.T) {
+	t.Run("should return exchanges", func(t *testing.T) {
+		fx := newFixture(t)
+		defer fx.Finish()
+
+		id := uuid.NewV4()
+		exchanges := []models.BankExchange{
+			{ID: uuid.NewV4(), ApplicationID: &id, Method: http.MethodPost, URL: "http://bank/api/applications", ResponseStatus: 201},
+			{ID: uuid.NewV4(), ApplicationID: &id, Method: http.MethodGet, URL: "http://bank/api/jobs", ResponseStatus: 200},
+		}
+		fx.exchangesRepo.On("GetByApplicationID", mock.Anything, id).Return(exchanges, nil).Once()
+
+		resp := fx.do(fx.newAdminRequest(http.MethodGet, "/admin/applications/"+id.String()+"/exchanges", nil))
+
+		require.Equal(t, http.StatusOK, resp.Code)
+		var body responses.GetExchangesResponse
+		require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &body))
+		require.Len(t, body.Items, 2)
+		assert.Equal(t, exchanges[0].ID, body.Items[0].ID)
+		assert.Equal(t, exchanges[1].URL, body.Items[1].URL)
+	})
+
+	t.Run("with invalid id", func(t *testing.T) {
+		fx := newFixture(t)
+		defer fx.Finish()
+
+		resp := fx.do(fx.newAdminRequest(http.MethodGet, "/admin/applications/invalid/exchanges", nil))
+
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+	})
+
+	t.Run("when repo fails", func(t *testing.T) {
+		fx := newFixture(t)
+		defer fx.Finish()
+
+		id := uuid.NewV4()
+		fx.exchangesRepo.On("GetByApplicationID", mock.Anything, id).Return(nil, errors.New("db error")).Once()
+
+		resp := fx.do(fx.newAdminRequest(http.MethodGet, "/admin/applications/"+id.String()+"/exchanges", nil))
+
+		assert.Equal(t, http.StatusInternalServerError, resp.Code)
+	})
+}
+
+type fixture struct {
+	t          *testing.T
+	secret     string
+	adminToken string
+
+	callbacksSrv  *mocks.CallbacksService
+	exchangesRepo *mocks.ExchangesRepo
+	jobsSrv       *mocks.JobsService
+
+	app *App
+}
+
+func newFixture(t *testing.T) *fixture {
+	fx := &fixture{
+		t:             t,
+		secret:        gofakeit.Password(true, true, true, false, false, 32),
+		adminToken:    gofakeit.Password(true, true, true, false, false, 32),
+		callbacksSrv:  &mocks.CallbacksService{},
+		exchangesRepo: &mocks.ExchangesRepo{},
+		jobsSrv:       &mocks.JobsService{},
+	}
+
+	var cfg config.Config
+	cfg.Bank.Callback = bank.CallbackConfig{
+		Secret:    fx.secret,
+		Tolerance: time.Minute,
+	}
+	cfg.Admin.Token = fx.adminToken
+	fx.app = New(cfg,
+		WithCallbacksSrv(fx.callbacksSrv),
+		WithExchangesRepo(fx.exchangesRepo),
+		WithJobsSrv(fx.jobsSrv),
+	)
+	return fx
+}
+
+func (fx *fixture) Finish() {
+	fx.callbacksSrv.AssertExpectations(fx.t)
+	fx.exchangesRepo.AssertExpectations(fx.t)
+	fx.jobsSrv.AssertExpectations(fx.t)
+}
+
+func (fx *fixture) newAdminRequest(method, url string, body io.Reader) *http.Request {
+	req, err := http.NewRequest(method, url, body)
+	require.NoError(fx.t, err)
+	req.Header.Set("Authorization", "Bearer "+fx.adminToken)
+	return req
+}
+
+func (fx *fixture) do(req *http.Request) *httptest.ResponseRecorder {
+	resp := httptest.NewRecorder()
+	fx.app.ServeHTTP(resp, req)
+	return resp
+}
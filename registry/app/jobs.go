@@ -0,0 +1,164 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+	"github.com/ivanovaleksey/lendo/registry/models"
+	"github.com/ivanovaleksey/lendo/registry/responses"
+	"github.com/ivanovaleksey/lendo/registry/services/jobs"
+	"github.com/pkg/errors"
+	uuid "github.com/satori/go.uuid"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+type JobsService interface {
+	GetList(ctx context.Context, params jobsSrv.GetListParams) ([]models.Job, int, error)
+	GetByID(ctx context.Context, id uuid.UUID) (models.JobDetails, error)
+	Retry(ctx context.Context, id uuid.UUID) error
+	Cancel(ctx context.Context, id uuid.UUID) error
+	Fail(ctx context.Context, id uuid.UUID) error
+	Requeue(ctx context.Context, id uuid.UUID) error
+	RetryFailed(ctx context.Context, from, to time.Time) (int64, error)
+}
+
+// GetJobs lists jobs filtered by status, age and attempts.
+func (app *App) GetJobs() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		query := r.URL.Query()
+
+		params := jobsSrv.GetListParams{
+			Status: models.JobStatus(query.Get("status")),
+		}
+		if value := query.Get("older_than"); value != "" {
+			age, err := time.ParseDuration(value)
+			if err != nil {
+				render.Render(w, r, responses.ErrBadRequest(err))
+				return
+			}
+			params.CreatedBefore = time.Now().UTC().Add(-age)
+		}
+		for name, dst := range map[string]*int{
+			"min_attempts": &params.MinAttempts,
+			"offset":       &params.Offset,
+			"limit":        &params.Limit,
+		} {
+			value := query.Get(name)
+			if value == "" {
+				continue
+			}
+			num, err := strconv.Atoi(value)
+			if err != nil {
+				render.Render(w, r, responses.ErrBadRequest(errors.Wrap(err, name)))
+				return
+			}
+			*dst = num
+		}
+
+		jobs, total, err := app.jobsSrv.GetList(ctx, params)
+		if err != nil {
+			render.Render(w, r, responses.ErrInternal(err))
+			return
+		}
+
+		resp := responses.GetJobsResponse{
+			Items: jobs,
+			Total: total,
+		}
+		render.Render(w, r, resp)
+	}
+}
+
+// GetJob shows a job with its payload and history.
+func (app *App) GetJob() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		id, err := uuid.FromString(chi.URLParam(r, "id"))
+		if err != nil {
+			render.Render(w, r, responses.ErrBadRequest(err))
+			return
+		}
+
+		details, err := app.jobsSrv.GetByID(ctx, id)
+		switch {
+		case err == jobsSrv.ErrJobNotFound:
+			render.Render(w, r, responses.ErrNotFound(err))
+			return
+		case err != nil:
+			render.Render(w, r, responses.ErrInternal(err))
+			return
+		}
+
+		render.Render(w, r, responses.GetJobResponse{JobDetails: details})
+	}
+}
+
+// ControlJob applies an operator action to a job.
+func (app *App) ControlJob(action func(ctx context.Context, id uuid.UUID) error) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		id, err := uuid.FromString(chi.URLParam(r, "id"))
+		if err != nil {
+			render.Render(w, r, responses.ErrBadRequest(err))
+			return
+		}
+
+		err = action(ctx, id)
+		switch {
+		case err == jobsSrv.ErrJobNotFound:
+			render.Render(w, r, responses.ErrNotFound(err))
+			return
+		case errors.Cause(err) == jobsSrv.ErrInvalidTransition:
+			render.Render(w, r, responses.ErrConflict(err))
+			return
+		case err != nil:
+			render.Render(w, r, responses.ErrInternal(err))
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+type RetryFailedJobsParams struct {
+	From time.Time `json:"from"`
+	To   time.Time `json:"to"`
+}
+
+// RetryFailedJobs requeues jobs which failed within a time range.
+func (app *App) RetryFailedJobs() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		defer r.Body.Close()
+
+		ctx := r.Context()
+
+		var params RetryFailedJobsParams
+		err := json.NewDecoder(r.Body).Decode(&params)
+		if err != nil {
+			render.Render(w, r, responses.ErrBadRequest(err))
+			return
+		}
+		if params.To.IsZero() {
+			params.To = time.Now()
+		}
+
+		// jobs keep their timestamps in UTC
+		count, err := app.jobsSrv.RetryFailed(ctx, params.From.UTC(), params.To.UTC())
+		switch {
+		case err == jobsSrv.ErrInvalidRange:
+			render.Render(w, r, responses.ErrBadRequest(err))
+			return
+		case err != nil:
+			render.Render(w, r, responses.ErrInternal(err))
+			return
+		}
+
+		render.Render(w, r, responses.RetryFailedJobsResponse{Count: count})
+	}
+}
@@ -0,0 +1,162 @@
+package app
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/ivanovaleksey/lendo/registry/models"
+	"github.com/ivanovaleksey/lendo/registry/responses"
+	jobsSrv "github.com/ivanovaleksey/lendo/registry/services/jobs"
+	"github.com/pkg/errors"
+	uuid "github.com/satori/go.uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestApp_GetJobs(t *testing.T) {
+	t.Run("with invalid filter", func(t *testing.T) {
+		fx := newFixture(t)
+		defer fx.Finish()
+
+		resp := fx.do(fx.newAdminRequest(http.MethodGet, "/admin/jobs?min_attempts=many", nil))
+
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+	})
+
+	t.Run("should pass filter", func(t *testing.T) {
+		fx := newFixture(t)
+		defer fx.Finish()
+
+		jobs := []models.Job{{ID: uuid.NewV4(), Status: models.JobStatusPending, Attempts: 3}}
+		params := mock.MatchedBy(func(params jobsSrv.GetListParams) bool {
+			age := time.Since(params.CreatedBefore)
+			return params.Status == models.JobStatusPending &&
+				params.MinAttempts == 3 &&
+				params.Limit == 5 &&
+				age >= time.Hour && age < time.Hour+time.Minute
+		})
+		fx.jobsSrv.On("GetList", mock.Anything, params).Return(jobs, 1, nil)
+
+		resp := fx.do(fx.newAdminRequest(http.MethodGet, "/admin/jobs?status=pending&older_than=1h&min_attempts=3&limit=5", nil))
+
+		require.Equal(t, http.StatusOK, resp.Code)
+		var body responses.GetJobsResponse
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+		assert.Equal(t, 1, body.Total)
+		require.Len(t, body.Items, 1)
+		assert.Equal(t, jobs[0].ID, body.Items[0].ID)
+	})
+}
+
+func TestApp_GetJob(t *testing.T) {
+	t.Run("when job does not exist", func(t *testing.T) {
+		fx := newFixture(t)
+		defer fx.Finish()
+
+		id := uuid.NewV4()
+		fx.jobsSrv.On("GetByID", mock.Anything, id).Return(models.JobDetails{}, jobsSrv.ErrJobNotFound)
+
+		resp := fx.do(fx.newAdminRequest(http.MethodGet, "/admin/jobs/"+id.String(), nil))
+
+		assert.Equal(t, http.StatusNotFound, resp.Code)
+	})
+
+	t.Run("should return job details", func(t *testing.T) {
+		fx := newFixture(t)
+		defer fx.Finish()
+
+		details := models.JobDetails{
+			Job:       models.Job{ID: uuid.NewV4(), Status: models.JobStatusFailed, LastError: "bank is down"},
+			Exchanges: []models.BankExchange{{ID: uuid.NewV4(), ResponseStatus: http.StatusBadGateway}},
+			Events:    []models.JobEvent{{ID: uuid.NewV4(), Handler: string(models.JobStatusNew), Error: "bank is down"}},
+		}
+		fx.jobsSrv.On("GetByID", mock.Anything, details.ID).Return(details, nil)
+
+		resp := fx.do(fx.newAdminRequest(http.MethodGet, "/admin/jobs/"+details.ID.String(), nil))
+
+		require.Equal(t, http.StatusOK, resp.Code)
+		var body responses.GetJobResponse
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+		assert.Equal(t, details.LastError, body.LastError)
+		assert.Equal(t, details.Exchanges[0].ID, body.Exchanges[0].ID)
+		assert.Equal(t, details.Events[0].ID, body.Events[0].ID)
+	})
+}
+
+func TestApp_ControlJob(t *testing.T) {
+	for action, method := range map[string]string{
+		"retry":   "Retry",
+		"cancel":  "Cancel",
+		"fail":    "Fail",
+		"requeue": "Requeue",
+	} {
+		t.Run(action, func(t *testing.T) {
+			fx := newFixture(t)
+			defer fx.Finish()
+
+			id := uuid.NewV4()
+			fx.jobsSrv.On(method, mock.Anything, id).Return(nil)
+
+			resp := fx.do(fx.newAdminRequest(http.MethodPost, "/admin/jobs/"+id.String()+"/"+action, nil))
+
+			assert.Equal(t, http.StatusNoContent, resp.Code)
+		})
+	}
+
+	t.Run("when transition is invalid", func(t *testing.T) {
+		fx := newFixture(t)
+		defer fx.Finish()
+
+		id := uuid.NewV4()
+		fx.jobsSrv.On("Cancel", mock.Anything, id).Return(errors.Wrap(jobsSrv.ErrInvalidTransition, "job is done"))
+
+		resp := fx.do(fx.newAdminRequest(http.MethodPost, "/admin/jobs/"+id.String()+"/cancel", nil))
+
+		assert.Equal(t, http.StatusConflict, resp.Code)
+	})
+}
+
+func TestApp_RetryFailedJobs(t *testing.T) {
+	t.Run("with invalid range", func(t *testing.T) {
+		fx := newFixture(t)
+		defer fx.Finish()
+
+		fx.jobsSrv.On("RetryFailed", mock.Anything, mock.Anything, mock.Anything).Return(int64(0), jobsSrv.ErrInvalidRange)
+
+		body := []byte(`{"from": "2021-04-02T00:00:00Z", "to": "2021-04-01T00:00:00Z"}`)
+		resp := fx.do(fx.newAdminRequest(http.MethodPost, "/admin/jobs/retry-failed", bytes.NewReader(body)))
+
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+	})
+
+	t.Run("should requeue failed jobs", func(t *testing.T) {
+		fx := newFixture(t)
+		defer fx.Finish()
+
+		from := time.Date(2021, 4, 1, 0, 0, 0, 0, time.UTC)
+		to := from.Add(24 * time.Hour)
+		fx.jobsSrv.On("RetryFailed", mock.Anything, from, to).Return(int64(2), nil)
+
+		body := []byte(`{"from": "2021-04-01T00:00:00Z", "to": "2021-04-02T00:00:00Z"}`)
+		resp := fx.do(fx.newAdminRequest(http.MethodPost, "/admin/jobs/retry-failed", bytes.NewReader(body)))
+
+		require.Equal(t, http.StatusOK, resp.Code)
+		assert.JSONEq(t, `{"count": 2}`, resp.Body.String())
+	})
+	t.Run("should pass range in UTC", func(t *testing.T) {
+		fx := newFixture(t)
+		defer fx.Finish()
+
+		from := time.Date(2021, 4, 1, 0, 0, 0, 0, time.UTC)
+		to := from.Add(24 * time.Hour)
+		fx.jobsSrv.On("RetryFailed", mock.Anything, from, to).Return(int64(0), nil)
+
+		body := []byte(`{"from": "2021-04-01T02:00:00+02:00", "to": "2021-04-02T02:00:00+02:00"}`)
+		resp := fx.do(fx.newAdminRequest(http.MethodPost, "/admin/jobs/retry-failed", bytes.NewReader(body)))
+
+		assert.Equal(t, http.StatusOK, resp.Code)
+	})
+}
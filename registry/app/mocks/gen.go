@@ -0,0 +1,5 @@
+//go:generate mockery --dir .. --output . --name CallbacksService --filename callbacks_service.mock.go
+//go:generate mockery --dir .. --output . --name ExchangesRepo --filename exchanges_repo.mock.go
+//go:generate mockery --dir .. --output . --name JobsService --filename jobs_service.mock.go
+
+package mocks
@@ -0,0 +1,31 @@
+package app
+
+import (
+	"github.com/ivanovaleksey/lendo/pkg/health"
+)
+
+type Option func(*App)
+
+func WithCallbacksSrv(srv CallbacksService) Option {
+	return func(app *App) {
+		app.callbacksSrv = srv
+	}
+}
+
+func WithExchangesRepo(repo ExchangesRepo) Option {
+	return func(app *App) {
+		app.exchangesRepo = repo
+	}
+}
+
+func WithJobsSrv(srv JobsService) Option {
+	return func(app *App) {
+		app.jobsSrv = srv
+	}
+}
+
+func WithHealth(h *health.Health) Option {
+	return func(app *App) {
+		app.health = h
+	}
+}
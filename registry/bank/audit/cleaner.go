@@ -0,0 +1,39 @@
+package audit
+
+import (
+	"context"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"time"
+)
+
+const CleanInterval = time.Hour
+
+type Purger interface {
+	DeleteBefore(ctx context.Context, before time.Time) (int64, error)
+}
+
+// Cleaner removes exchanges which are older than the retention period, it's run by the scheduler.
+type Cleaner struct {
+	purger    Purger
+	retention time.Duration
+	logger    log.FieldLogger
+}
+
+func NewCleaner(purger Purger, retention time.Duration) *Cleaner {
+	c := &Cleaner{
+		purger:    purger,
+		retention: retention,
+		logger:    log.WithField("component", "bank.audit.cleaner"),
+	}
+	return c
+}
+
+func (c *Cleaner) Clean(ctx context.Context) error {
+	num, err := c.purger.DeleteBefore(ctx, time.Now().UTC().Add(-c.retention))
+	if err != nil {
+		return errors.Wrap(err, "can't delete exchanges")
+	}
+	c.logger.Debugf("deleted %d exchanges", num)
+	return nil
+}
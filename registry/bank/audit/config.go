@@ -0,0 +1,10 @@
+package audit
+
+import "time"
+
+type Config struct {
+	Enabled       bool          `default:"true"`
+	RedactHeaders []string      `split_words:"true" default:"Authorization,Cookie,Set-Cookie,X-Api-Key"`
+	MaskFields    []string      `split_words:"true" default:"first_name,last_name"`
+	Retention     time.Duration `default:"720h"`
+}
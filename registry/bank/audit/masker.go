@@ -0,0 +1,89 @@
+package audit
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/ivanovaleksey/lendo/registry/models"
+	"net/http"
+	"strings"
+)
+
+const (
+	redacted = "[REDACTED]"
+	masked   = "***"
+)
+
+// Masker hides sensitive headers and PII fields of JSON bodies before they are persisted.
+type Masker struct {
+	headers map[string]struct{}
+	fields  map[string]struct{}
+}
+
+func NewMasker(headers []string, fields []string) *Masker {
+	m := &Masker{
+		headers: make(map[string]struct{}, len(headers)),
+		fields:  make(map[string]struct{}, len(fields)),
+	}
+	for _, h := range headers {
+		m.headers[http.CanonicalHeaderKey(strings.TrimSpace(h))] = struct{}{}
+	}
+	for _, f := range fields {
+		m.fields[strings.ToLower(strings.TrimSpace(f))] = struct{}{}
+	}
+	return m
+}
+
+func (m *Masker) MaskHeaders(h http.Header) models.Headers {
+	res := make(models.Headers, len(h))
+	for key, values := range h {
+		if _, ok := m.headers[http.CanonicalHeaderKey(key)]; ok {
+			res[key] = []string{redacted}
+			continue
+		}
+		res[key] = append([]string(nil), values...)
+	}
+	return res
+}
+
+// MaskBody replaces values of the configured fields at any depth of a JSON document.
+// Bodies which are not valid JSON are returned as is.
+func (m *Masker) MaskBody(data []byte) []byte {
+	if len(m.fields) == 0 || len(bytes.TrimSpace(data)) == 0 {
+		return data
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	var doc interface{}
+	if err := dec.Decode(&doc); err != nil {
+		return data
+	}
+
+	res, err := json.Marshal(m.mask(doc))
+	if err != nil {
+		return data
+	}
+	return res
+}
+
+func (m *Masker) mask(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for key, value := range v {
+			if _, ok := m.fields[strings.ToLower(key)]; ok {
+				v[key] = masked
+				continue
+			}
+			v[key] = m.mask(value)
+		}
+		return v
+	case []interface{}:
+		for i, value := range v {
+			v[i] = m.mask(value)
+		}
+		return v
+	default:
+		return v
+	}
+}
@@ -0,0 +1,55 @@
+package audit
+
+import (
+	"github.com/ivanovaleksey/lendo/registry/models"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"testing"
+)
+
+func TestMasker_MaskHeaders(t *testing.T) {
+	m := NewMasker([]string{"authorization", " X-Api-Key"}, nil)
+
+	headers := http.Header{
+		"Authorization": []string{"Bearer token"},
+		"X-Api-Key":     []string{"secret"},
+		"Content-Type":  []string{"application/json"},
+	}
+
+	got := m.MaskHeaders(headers)
+
+	expected := models.Headers{
+		"Authorization": []string{redacted},
+		"X-Api-Key":     []string{redacted},
+		"Content-Type":  []string{"application/json"},
+	}
+	assert.Equal(t, expected, got)
+	assert.Equal(t, "Bearer token", headers.Get("Authorization"))
+}
+
+func TestMasker_MaskBody(t *testing.T) {
+	m := NewMasker(nil, []string{"first_name", "Last_Name"})
+
+	t.Run("should mask fields at any depth", func(t *testing.T) {
+		body := `{"id":"1","first_name":"John","items":[{"last_name":"Doe","age":42}]}`
+
+		got := m.MaskBody([]byte(body))
+
+		expected := `{"first_name":"***","id":"1","items":[{"age":42,"last_name":"***"}]}`
+		assert.JSONEq(t, expected, string(got))
+	})
+
+	t.Run("should keep non json body", func(t *testing.T) {
+		body := []byte("internal server error")
+
+		got := m.MaskBody(body)
+
+		assert.Equal(t, body, got)
+	})
+
+	t.Run("should keep empty body", func(t *testing.T) {
+		got := m.MaskBody(nil)
+
+		assert.Empty(t, got)
+	})
+}
@@ -0,0 +1,4 @@
+//go:generate mockery --dir .. --output . --name Recorder --filename recorder.mock.go
+//go:generate mockery --dir .. --output . --name Purger --filename purger.mock.go
+
+package mocks
@@ -0,0 +1,122 @@
+package audit
+
+import (
+	"bytes"
+	"context"
+	"github.com/ivanovaleksey/lendo/registry/models"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"time"
+)
+
+const recordTimeout = 3 * time.Second
+
+type Recorder interface {
+	CreateExchange(ctx context.Context, item models.BankExchange) error
+}
+
+// Transport is an http.RoundTripper which records every exchange with a bank partner.
+// The job being processed is taken from the request context, see models.ContextWithJob.
+type Transport struct {
+	next     http.RoundTripper
+	recorder Recorder
+	masker   *Masker
+	logger   log.FieldLogger
+}
+
+func NewTransport(next http.RoundTripper, recorder Recorder, cfg Config) *Transport {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	t := &Transport{
+		next:     next,
+		recorder: recorder,
+		masker:   NewMasker(cfg.RedactHeaders, cfg.MaskFields),
+		logger:   log.WithField("component", "bank.audit"),
+	}
+	return t
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	reqBody, err := readRequestBody(req)
+	if err != nil {
+		return nil, errors.Wrap(err, "can't read request body")
+	}
+
+	item := models.BankExchange{
+		Method:         req.Method,
+		URL:            req.URL.String(),
+		RequestHeaders: t.masker.MaskHeaders(req.Header),
+		RequestBody:    string(t.masker.MaskBody(reqBody)),
+	}
+	if job, ok := models.JobFromContext(req.Context()); ok {
+		jobID := job.ID
+		item.JobID = &jobID
+		if application, err := job.Application(); err == nil {
+			item.ApplicationID = &application.ID
+		}
+	}
+
+	start := time.Now()
+	resp, err := t.next.RoundTrip(req)
+	if err == nil {
+		var respBody []byte
+		respBody, err = ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		resp.Body = ioutil.NopCloser(bytes.NewReader(respBody))
+
+		item.ResponseStatus = resp.StatusCode
+		item.ResponseHeaders = t.masker.MaskHeaders(resp.Header)
+		item.ResponseBody = string(t.masker.MaskBody(respBody))
+	}
+	item.LatencyMS = time.Since(start).Milliseconds()
+	if err != nil {
+		item.Error = err.Error()
+	}
+
+	t.record(item)
+
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// record uses its own context so that exchanges of cancelled requests are still persisted.
+func (t *Transport) record(item models.BankExchange) {
+	ctx, cancel := context.WithTimeout(context.Background(), recordTimeout)
+	defer cancel()
+
+	if err := t.recorder.CreateExchange(ctx, item); err != nil {
+		t.logger.Errorf("can't record exchange: %v", err)
+	}
+}
+
+func readRequestBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+
+	var body io.ReadCloser
+	if req.GetBody != nil {
+		var err error
+		body, err = req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		data, err := ioutil.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		req.Body = ioutil.NopCloser(bytes.NewReader(data))
+		return data, nil
+	}
+	defer body.Close()
+
+	return ioutil.ReadAll(body)
+}
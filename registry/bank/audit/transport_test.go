@@ -0,0 +1,155 @@
+package audit
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"github.com/brianvoe/gofakeit"
+	commonModels "github.com/ivanovaleksey/lendo/pkg/models"
+	"github.com/ivanovaleksey/lendo/registry/bank/audit/mocks"
+	"github.com/ivanovaleksey/lendo/registry/models"
+	uuid "github.com/satori/go.uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestTransport_RoundTrip(t *testing.T) {
+	application := commonModels.Application{
+		ID: uuid.NewV4(),
+	}
+	job, err := models.NewApplicationJob(application)
+	require.NoError(t, err)
+	job.ID = uuid.NewV4()
+
+	t.Run("should record exchange", func(t *testing.T) {
+		fx := newFixture(t)
+		defer fx.Finish()
+
+		reqBody := `{"first_name":"` + gofakeit.FirstName() + `"}`
+		respBody := `{"status":"pending"}`
+
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			data, err := ioutil.ReadAll(r.Body)
+			require.NoError(t, err)
+			require.Equal(t, reqBody, string(data))
+			require.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+
+			w.WriteHeader(http.StatusCreated)
+			_, err = w.Write([]byte(respBody))
+			require.NoError(t, err)
+		}))
+		defer srv.Close()
+
+		var recorded models.BankExchange
+		fx.recorder.On("CreateExchange", mock.Anything, mock.AnythingOfType("models.BankExchange")).
+			Run(func(args mock.Arguments) {
+				recorded = args.Get(1).(models.BankExchange)
+			}).
+			Return(nil)
+
+		ctx := models.ContextWithJob(fx.ctx, job)
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, srv.URL+"/api/applications", bytes.NewBufferString(reqBody))
+		require.NoError(t, err)
+		req.Header.Set("Authorization", "Bearer token")
+
+		resp, err := fx.client.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		data, err := ioutil.ReadAll(resp.Body)
+		require.NoError(t, err)
+		assert.Equal(t, respBody, string(data))
+
+		assert.Equal(t, http.MethodPost, recorded.Method)
+		assert.Equal(t, srv.URL+"/api/applications", recorded.URL)
+		assert.Equal(t, []string{redacted}, recorded.RequestHeaders["Authorization"])
+		assert.JSONEq(t, `{"first_name":"***"}`, recorded.RequestBody)
+		assert.Equal(t, http.StatusCreated, recorded.ResponseStatus)
+		assert.JSONEq(t, respBody, recorded.ResponseBody)
+		assert.Empty(t, recorded.Error)
+		require.NotNil(t, recorded.JobID)
+		assert.Equal(t, job.ID, *recorded.JobID)
+		require.NotNil(t, recorded.ApplicationID)
+		assert.Equal(t, application.ID, *recorded.ApplicationID)
+	})
+
+	t.Run("should record transport error", func(t *testing.T) {
+		fx := newFixture(t)
+		defer fx.Finish()
+
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+		srv.Close()
+
+		var recorded models.BankExchange
+		fx.recorder.On("CreateExchange", mock.Anything, mock.AnythingOfType("models.BankExchange")).
+			Run(func(args mock.Arguments) {
+				recorded = args.Get(1).(models.BankExchange)
+			}).
+			Return(nil)
+
+		req, err := http.NewRequestWithContext(fx.ctx, http.MethodGet, srv.URL+"/api/jobs", nil)
+		require.NoError(t, err)
+
+		_, err = fx.client.Do(req)
+
+		require.Error(t, err)
+		assert.NotEmpty(t, recorded.Error)
+		assert.Zero(t, recorded.ResponseStatus)
+		assert.Nil(t, recorded.JobID)
+	})
+
+	t.Run("should not fail when cannot record", func(t *testing.T) {
+		fx := newFixture(t)
+		defer fx.Finish()
+
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		}))
+		defer srv.Close()
+
+		fx.recorder.On("CreateExchange", mock.Anything, mock.AnythingOfType("models.BankExchange")).
+			Return(errors.New(gofakeit.Sentence(3)))
+
+		req, err := http.NewRequestWithContext(fx.ctx, http.MethodGet, srv.URL+"/api/jobs", nil)
+		require.NoError(t, err)
+
+		resp, err := fx.client.Do(req)
+
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+	})
+}
+
+type fixture struct {
+	t   *testing.T
+	ctx context.Context
+
+	recorder *mocks.Recorder
+	client   *http.Client
+}
+
+func newFixture(t *testing.T) *fixture {
+	fx := &fixture{
+		t:        t,
+		ctx:      context.Background(),
+		recorder: &mocks.Recorder{},
+	}
+	cfg := Config{
+		RedactHeaders: []string{"Authorization"},
+		MaskFields:    []string{"first_name"},
+	}
+	fx.client = &http.Client{
+		Transport: NewTransport(nil, fx.recorder, cfg),
+	}
+	return fx
+}
+
+func (fx *fixture) Finish() {
+	fx.recorder.AssertExpectations(fx.t)
+}
@@ -0,0 +1,61 @@
+package bank
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"github.com/ivanovaleksey/lendo/pkg/models"
+	uuid "github.com/satori/go.uuid"
+	"strconv"
+	"time"
+)
+
+// Headers a bank partner sends with status callbacks.
+const (
+	SignatureHeader = "X-Bank-Signature"
+	TimestampHeader = "X-Bank-Timestamp"
+)
+
+var (
+	ErrInvalidSignature = errors.New("invalid signature")
+	ErrExpiredSignature = errors.New("expired signature")
+)
+
+// Callback is a notification a bank partner pushes when an assessment finishes.
+type Callback struct {
+	ApplicationID uuid.UUID                `json:"application_id"`
+	Status        models.ApplicationStatus `json:"status"`
+}
+
+// Sign returns a hex encoded HMAC-SHA256 of the timestamp and the body.
+func Sign(secret string, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify checks the callback signature and that the timestamp (unix seconds)
+// is within the tolerance, so that captured callbacks can't be replayed.
+func Verify(secret string, timestamp string, signature string, body []byte, tolerance time.Duration, now time.Time) error {
+	sec, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+
+	expected := Sign(secret, timestamp, body)
+	if !hmac.Equal([]byte(expected), []byte(signature)) {
+		return ErrInvalidSignature
+	}
+
+	diff := now.Sub(time.Unix(sec, 0))
+	if diff < 0 {
+		diff = -diff
+	}
+	if tolerance > 0 && diff > tolerance {
+		return ErrExpiredSignature
+	}
+	return nil
+}
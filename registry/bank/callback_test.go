@@ -0,0 +1,58 @@
+package bank
+
+import (
+	"github.com/brianvoe/gofakeit"
+	"github.com/stretchr/testify/assert"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestVerify(t *testing.T) {
+	const tolerance = 5 * time.Minute
+
+	secret := gofakeit.Password(true, true, true, false, false, 32)
+	body := []byte(`{"status":"completed"}`)
+	now := time.Now()
+	timestamp := strconv.FormatInt(now.Unix(), 10)
+
+	t.Run("with valid signature", func(t *testing.T) {
+		signature := Sign(secret, timestamp, body)
+
+		err := Verify(secret, timestamp, signature, body, tolerance, now)
+
+		assert.NoError(t, err)
+	})
+
+	t.Run("with another secret", func(t *testing.T) {
+		signature := Sign(secret+"x", timestamp, body)
+
+		err := Verify(secret, timestamp, signature, body, tolerance, now)
+
+		assert.Equal(t, ErrInvalidSignature, err)
+	})
+
+	t.Run("with modified body", func(t *testing.T) {
+		signature := Sign(secret, timestamp, body)
+
+		err := Verify(secret, timestamp, signature, []byte(`{"status":"rejected"}`), tolerance, now)
+
+		assert.Equal(t, ErrInvalidSignature, err)
+	})
+
+	t.Run("with invalid timestamp", func(t *testing.T) {
+		signature := Sign(secret, "now", body)
+
+		err := Verify(secret, "now", signature, body, tolerance, now)
+
+		assert.Equal(t, ErrInvalidSignature, err)
+	})
+
+	t.Run("with expired timestamp", func(t *testing.T) {
+		signature := Sign(secret, timestamp, body)
+
+		err := Verify(secret, timestamp, signature, body, tolerance, now.Add(tolerance+time.Second))
+
+		assert.Equal(t, ErrExpiredSignature, err)
+	})
+}
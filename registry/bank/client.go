@@ -15,16 +15,23 @@ import (
 
 // Client interacts with a bank system.
 type Client struct {
-	cfg        Config
-	httpClient *http.Client
+	cfg          Config
+	httpClient   *http.Client
+	healthClient *http.Client
 }
 
-func NewClient(cfg Config) *Client {
+func NewClient(cfg Config, opts ...Option) *Client {
 	client := &Client{
 		cfg: cfg,
 		httpClient: &http.Client{
 			Timeout: 3 * time.Second,
 		},
+		healthClient: &http.Client{
+			Timeout: 3 * time.Second,
+		},
+	}
+	for _, opt := range opts {
+		opt(client)
 	}
 	return client
 }
@@ -36,6 +43,13 @@ type createApplicationBody struct {
 }
 
 func (client *Client) CreateApplication(ctx context.Context, application models.Application) (models.ApplicationStatus, error) {
+	start := time.Now()
+	status, err := client.createApplication(ctx, application)
+	observe("create_application", start, err)
+	return status, err
+}
+
+func (client *Client) createApplication(ctx context.Context, application models.Application) (models.ApplicationStatus, error) {
 	const methodPath = "/api/applications"
 
 	reqURL, err := url.Parse(client.cfg.URL)
@@ -87,6 +101,13 @@ func (client *Client) CreateApplication(ctx context.Context, application models.
 }
 
 func (client *Client) GetApplicationStatus(ctx context.Context, id uuid.UUID) (models.ApplicationStatus, error) {
+	start := time.Now()
+	status, err := client.getApplicationStatus(ctx, id)
+	observe("get_application_status", start, err)
+	return status, err
+}
+
+func (client *Client) getApplicationStatus(ctx context.Context, id uuid.UUID) (models.ApplicationStatus, error) {
 	const methodPath = "/api/jobs"
 
 	reqURL, err := url.Parse(client.cfg.URL)
@@ -132,3 +153,23 @@ func (client *Client) GetApplicationStatus(ctx context.Context, id uuid.UUID) (m
 		return "", Error{Code: code}
 	}
 }
+
+// CheckHealth reports whether the bank is reachable. Any HTTP response is considered healthy.
+// A dedicated HTTP client is used, so that health checks are not recorded as bank exchanges.
+func (client *Client) CheckHealth(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, client.cfg.URL, nil)
+	if err != nil {
+		return errors.Wrap(err, "can't create request")
+	}
+
+	resp, err := client.healthClient.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "can't do request")
+	}
+	resp.Body.Close()
+	return nil
+}
+
+func (client *Client) ComponentName() string {
+	return "bank"
+}
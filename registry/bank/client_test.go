@@ -230,6 +230,33 @@ func TestImpl_GetApplicationStatus(t *testing.T) {
 	})
 }
 
+func TestImpl_CheckHealth(t *testing.T) {
+	t.Run("when bank responds", func(t *testing.T) {
+		fx := newFixture(t)
+		defer fx.Finish()
+
+		serverMock := fx.newServerMock(func(w http.ResponseWriter, r *http.Request) {
+			require.Equal(t, "HEAD", r.Method)
+			w.WriteHeader(http.StatusNotFound)
+		})
+		defer serverMock.Close()
+
+		err := fx.client.CheckHealth(fx.ctx)
+		assert.NoError(t, err)
+	})
+
+	t.Run("when bank is unreachable", func(t *testing.T) {
+		fx := newFixture(t)
+		defer fx.Finish()
+
+		serverMock := fx.newServerMock(func(w http.ResponseWriter, r *http.Request) {})
+		serverMock.Close()
+
+		err := fx.client.CheckHealth(fx.ctx)
+		assert.Error(t, err)
+	})
+}
+
 type fixture struct {
 	t   *testing.T
 	ctx context.Context
@@ -1,5 +1,17 @@
 package bank
 
+import "time"
+
 type Config struct {
-	URL string `required:"true"`
+	// Name identifies the bank partner, an application is submitted to a bank by a single job.
+	Name     string         `default:"default"`
+	URL      string         `required:"true"`
+	Callback CallbackConfig `envconfig:"callback"`
+}
+
+// CallbackConfig configures status callbacks pushed by a bank partner.
+// Callbacks are disabled unless Secret is set.
+type CallbackConfig struct {
+	Secret    string
+	Tolerance time.Duration `default:"5m"`
 }
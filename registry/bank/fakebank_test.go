@@ -0,0 +1,139 @@
+package bank
+
+import (
+	"context"
+	fakebank "github.com/ivanovaleksey/lendo/fakebank/app"
+	fakebankConfig "github.com/ivanovaleksey/lendo/fakebank/config"
+	"github.com/ivanovaleksey/lendo/pkg/models"
+	uuid "github.com/satori/go.uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestClient_FakeBank drives the client through the whole assessment against the fake bank partner.
+func TestClient_FakeBank(t *testing.T) {
+	for _, outcome := range []models.ApplicationStatus{models.ApplicationStatusCompleted, models.ApplicationStatusRejected} {
+		t.Run("should assess application as "+string(outcome), func(t *testing.T) {
+			fx := newFakeBankFixture(t)
+			fx.bank.SetOutcome("Doe", outcome)
+			application := fx.buildApplication("Doe")
+
+			status, err := fx.client.CreateApplication(fx.ctx, application)
+			require.NoError(t, err)
+			assert.EqualValues(t, models.ApplicationStatusPending, status)
+
+			status, err = fx.client.GetApplicationStatus(fx.ctx, application.ID)
+			require.NoError(t, err)
+			assert.EqualValues(t, models.ApplicationStatusPending, status)
+
+			fx.advance(time.Minute)
+
+			status, err = fx.client.GetApplicationStatus(fx.ctx, application.ID)
+			require.NoError(t, err)
+			assert.Equal(t, outcome, status)
+		})
+	}
+
+	t.Run("should give the same outcome with the same seed", func(t *testing.T) {
+		var outcomes [2]models.ApplicationStatus
+		for i := range outcomes {
+			fx := newFakeBankFixture(t)
+			application := fx.buildApplication("Roe")
+
+			_, err := fx.client.CreateApplication(fx.ctx, application)
+			require.NoError(t, err)
+			fx.advance(time.Minute)
+
+			outcomes[i], err = fx.client.GetApplicationStatus(fx.ctx, application.ID)
+			require.NoError(t, err)
+		}
+		assert.NotEqual(t, models.ApplicationStatus(models.ApplicationStatusPending), outcomes[0])
+		assert.Equal(t, outcomes[0], outcomes[1])
+	})
+
+	t.Run("should return injected errors", func(t *testing.T) {
+		fx := newFakeBankFixture(t)
+		application := fx.buildApplication("Doe")
+
+		fx.bank.InjectError("/api/applications", http.StatusServiceUnavailable, 1)
+		_, err := fx.client.CreateApplication(fx.ctx, application)
+		require.Equal(t, Error{Code: http.StatusServiceUnavailable}, err)
+
+		status, err := fx.client.CreateApplication(fx.ctx, application)
+		require.NoError(t, err)
+		assert.EqualValues(t, models.ApplicationStatusPending, status)
+
+		fx.bank.InjectError("/api/jobs", http.StatusInternalServerError, 1)
+		_, err = fx.client.GetApplicationStatus(fx.ctx, application.ID)
+		require.Equal(t, Error{Code: http.StatusInternalServerError}, err)
+
+		status, err = fx.client.GetApplicationStatus(fx.ctx, application.ID)
+		require.NoError(t, err)
+		assert.EqualValues(t, models.ApplicationStatusPending, status)
+	})
+
+	t.Run("when application already exists", func(t *testing.T) {
+		fx := newFakeBankFixture(t)
+		application := fx.buildApplication("Doe")
+
+		_, err := fx.client.CreateApplication(fx.ctx, application)
+		require.NoError(t, err)
+
+		_, err = fx.client.CreateApplication(fx.ctx, application)
+		require.Error(t, err)
+		assert.Equal(t, http.StatusBadRequest, err.(Error).Code)
+	})
+}
+
+type fakeBankFixture struct {
+	ctx context.Context
+
+	mu  sync.Mutex
+	now time.Time
+
+	bank   *fakebank.App
+	client *Client
+}
+
+func newFakeBankFixture(t *testing.T) *fakeBankFixture {
+	fx := &fakeBankFixture{
+		ctx: context.Background(),
+		now: time.Now(),
+	}
+	cfg := fakebankConfig.Config{
+		MinDelay: 5 * time.Second,
+		MaxDelay: 20 * time.Second,
+		Seed:     42,
+	}
+	fx.bank = fakebank.New(cfg, fakebank.WithClock(fx.clock))
+	fx.client = NewClient(Config{URL: "http://fakebank"}, WithTransport(fx.bank.Transport()))
+	return fx
+}
+
+func (fx *fakeBankFixture) clock() time.Time {
+	fx.mu.Lock()
+	defer fx.mu.Unlock()
+	return fx.now
+}
+
+// advance moves the clock of the fake bank, so that assessments complete without waiting.
+func (fx *fakeBankFixture) advance(d time.Duration) {
+	fx.mu.Lock()
+	defer fx.mu.Unlock()
+	fx.now = fx.now.Add(d)
+}
+
+func (fx *fakeBankFixture) buildApplication(lastName string) models.Application {
+	return models.Application{
+		NewApplication: models.NewApplication{
+			FirstName: "John",
+			LastName:  lastName,
+		},
+		ID:     uuid.NewV4(),
+		Status: models.ApplicationStatusNew,
+	}
+}
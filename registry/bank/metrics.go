@@ -0,0 +1,41 @@
+package bank
+
+import (
+	"github.com/ivanovaleksey/lendo/pkg/metrics"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"strconv"
+	"time"
+)
+
+var (
+	requestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metrics.Namespace,
+		Subsystem: "bank",
+		Name:      "request_duration_seconds",
+		Help:      "Duration of bank partner calls.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method"})
+
+	requestErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metrics.Namespace,
+		Subsystem: "bank",
+		Name:      "errors_total",
+		Help:      "Number of failed bank partner calls by response code, 0 is used for transport errors.",
+	}, []string{"method", "code"})
+)
+
+func observe(method string, start time.Time, err error) {
+	requestDuration.WithLabelValues(method).Observe(time.Since(start).Seconds())
+	if err == nil {
+		return
+	}
+
+	code := 0
+	var bankErr Error
+	if errors.As(err, &bankErr) {
+		code = bankErr.Code
+	}
+	requestErrors.WithLabelValues(method, strconv.Itoa(code)).Inc()
+}
@@ -0,0 +1,12 @@
+package bank
+
+import "net/http"
+
+type Option func(*Client)
+
+// WithTransport replaces the transport used for bank requests, e.g. to record exchanges.
+func WithTransport(rt http.RoundTripper) Option {
+	return func(c *Client) {
+		c.httpClient.Transport = rt
+	}
+}
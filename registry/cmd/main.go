@@ -2,92 +2,158 @@ package main
 
 import (
 	"context"
+	"github.com/ivanovaleksey/lendo/pkg/cli"
 	"github.com/ivanovaleksey/lendo/pkg/closer"
 	"github.com/ivanovaleksey/lendo/pkg/component"
 	"github.com/ivanovaleksey/lendo/pkg/db"
+	"github.com/ivanovaleksey/lendo/pkg/health"
+	"github.com/ivanovaleksey/lendo/pkg/logging"
+	"github.com/ivanovaleksey/lendo/pkg/migrate"
 	"github.com/ivanovaleksey/lendo/pkg/nats"
-	"github.com/ivanovaleksey/lendo/registry/bank"
+	"github.com/ivanovaleksey/lendo/pkg/tracing"
 	"github.com/ivanovaleksey/lendo/registry/config"
+	"github.com/ivanovaleksey/lendo/registry/migrations"
 	"github.com/ivanovaleksey/lendo/registry/poller"
-	"github.com/ivanovaleksey/lendo/registry/pubsub/applications"
 	"github.com/ivanovaleksey/lendo/registry/repos/jobs"
+	"github.com/ivanovaleksey/lendo/registry/server"
 	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
 	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"os"
 	"syscall"
 )
 
 func main() {
-	log.SetLevel(log.DebugLevel)
-
-	ctx := context.Background()
-
-	cfg, err := config.New()
-	if err != nil {
+	if err := newRootCmd().ExecuteContext(context.Background()); err != nil {
 		log.Fatal(err)
 	}
+}
+
+func newRootCmd() *cobra.Command {
+	var cfg config.Config
+	serve := func(role server.Role) func(cmd *cobra.Command) error {
+		return func(cmd *cobra.Command) error {
+			if err := cli.LoadConfig(cmd, &cfg); err != nil {
+				return err
+			}
+			return runApps(cmd.Context(), cfg, role)
+		}
+	}
+
+	root := cli.NewRoot("registry", &cfg, serve(server.RoleAll))
+	root.AddCommand(
+		cli.NewServeCommand("serve", "Consume new applications and poll jobs", &cfg, serve(server.RoleAll)),
+		cli.NewServeCommand("consume-only", "Consume new applications without polling jobs", &cfg, serve(server.RoleConsumer)),
+		cli.NewServeCommand("poll-only", "Poll jobs and run scheduled tasks without consuming new applications", &cfg, serve(server.RolePoller)),
+		newMigrateCmd(),
+		cli.NewConfigCommand(&cfg),
+		cli.NewVersionCommand(),
+	)
+	return root
+}
 
-	if err := runApps(ctx, cfg); err != nil {
-		log.Error(err)
+func newMigrateCmd() *cobra.Command {
+	var cfg config.MigrateConfig
+	cmd := &cobra.Command{
+		Use:   migrate.Usage,
+		Short: "Apply or roll back the schema migrations",
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := cli.LoadConfig(cmd, &cfg); err != nil {
+				return err
+			}
+			return runMigrate(cmd.Context(), cfg, args)
+		},
 	}
+	cli.BindFlags(cmd.Flags(), &cfg)
+	return cmd
 }
 
-func runApps(ctx context.Context, cfg config.Config) error {
+func runApps(ctx context.Context, cfg config.Config, role server.Role) error {
+	logging.Init(cfg.Log)
+
 	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
 
-	db, err := db.New(cfg.DB)
+	tracer, err := tracing.Init(ctx, "registry", cfg.Tracing)
+	if err != nil {
+		return errors.Wrap(err, "can't init tracing")
+	}
+
+	database, err := db.New(cfg.DB)
 	if err != nil {
 		return errors.Wrap(err, "can't create db")
 	}
 
+	prometheus.MustRegister(
+		database.Collector("registry"),
+		poller.NewJobsCollector(jobsRepo.New(database)),
+	)
+
 	natsClient, err := nats.New(cfg.NATS)
 	if err != nil {
 		return errors.Wrap(err, "can't create nats client")
 	}
 
-	appCloser := closer.New(syscall.SIGTERM, syscall.SIGINT)
-	appCloser.Add(func() error {
+	h := health.New()
+	h.AddLiveness(natsClient)
+	h.AddReadiness(database)
+
+	appCloser := closer.New(
+		closer.WithSignals(syscall.SIGTERM, syscall.SIGINT),
+		closer.WithDeadline(server.ShutdownDeadline(cfg)),
+	)
+	appCloser.Add(closer.PhaseIngress, func() error {
+		h.Shutdown()
+		return nil
+	})
+	appCloser.Add(closer.PhaseWorkers, func() error {
 		cancel()
 		return nil
 	})
 
-	{
-		repo := jobsRepo.New(db)
-		handler := applicationsPubSub.NewNewApplicationHandler(repo)
+	supervisor := component.NewSupervisor(appCloser)
+	h.AddLiveness(supervisor)
 
-		opts := []nats.ConsumerOption{
-			nats.WithClient(natsClient),
-			nats.WithQueue("registry"),
-			nats.WithSubject("applications.new"),
-			nats.WithHandler(handler),
-			nats.WithComponentName("consumer.applications.new"),
-		}
-		closure := component.Run(ctx, nats.NewConsumer(opts...))
-		appCloser.Add(closure)
-	}
-
-	{
-		bankClient := bank.NewClient(cfg.Bank)
-		repo := jobsRepo.New(db)
-		pub := applicationsPubSub.NewPub(natsClient)
-
-		opts := []poller.Option{
-			poller.WithDB(db),
-			poller.WithBank(bankClient),
-			poller.WithRepo(repo),
-			poller.WithNotifier(pub),
-		}
-		closure := component.Run(ctx, poller.New(opts...))
-		appCloser.Add(closure)
+	err = server.Start(ctx, cfg, server.Infra{
+		DB:         database,
+		Bus:        nats.NewBus(natsClient),
+		Closer:     appCloser,
+		Supervisor: supervisor,
+		Health:     h,
+	}, server.WithRole(role))
+	if err != nil {
+		return err
 	}
 
-	appCloser.Add(func() error {
-		return component.Close(natsClient, component.CloseDelay)
+	appCloser.Add(closer.PhasePublishers, func() error {
+		return component.Close(natsClient, 0)
 	})
-	appCloser.Add(func() error {
-		return component.Close(db, component.CloseDelay)
+	appCloser.Add(closer.PhaseInfra, func() error {
+		return component.Close(database, 0)
+	})
+	appCloser.Add(closer.PhaseInfra, func() error {
+		return component.Close(tracer, 0)
 	})
 
 	appCloser.Wait()
 	return nil
 }
+
+// runMigrate runs the migrate subcommand, it only needs the DB settings.
+func runMigrate(ctx context.Context, cfg config.MigrateConfig, args []string) error {
+	logging.Init(cfg.Log)
+
+	database, err := db.New(cfg.DB)
+	if err != nil {
+		return errors.Wrap(err, "can't create db")
+	}
+	defer database.Close()
+
+	migrator, err := migrations.New(database, cfg.Migrate)
+	if err != nil {
+		return errors.Wrap(err, "can't create migrator")
+	}
+	return migrate.Command(ctx, migrator, args, os.Stdout)
+}
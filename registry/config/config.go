@@ -2,15 +2,59 @@ package config
 
 import (
 	"github.com/ivanovaleksey/lendo/pkg/db"
+	"github.com/ivanovaleksey/lendo/pkg/envelope"
+	"github.com/ivanovaleksey/lendo/pkg/logging"
+	"github.com/ivanovaleksey/lendo/pkg/migrate"
 	"github.com/ivanovaleksey/lendo/pkg/nats"
+	"github.com/ivanovaleksey/lendo/pkg/tracing"
 	"github.com/ivanovaleksey/lendo/registry/bank"
+	"github.com/ivanovaleksey/lendo/registry/bank/audit"
 	"github.com/kelseyhightower/envconfig"
+	"time"
 )
 
 type Config struct {
-	Bank bank.Config `envconfig:"bank"`
-	DB   db.Config   `envconfig:"db"`
-	NATS nats.Config `envconfig:"nats"`
+	Addr      string          `default:":8000"`
+	Admin     AdminConfig     `envconfig:"admin"`
+	Bank      bank.Config     `envconfig:"bank"`
+	BankAudit audit.Config    `envconfig:"bank_audit"`
+	DB        db.Config       `envconfig:"db"`
+	Events    envelope.Config `envconfig:"events"`
+	Jobs      JobsConfig      `envconfig:"jobs"`
+	Log       logging.Config  `envconfig:"log"`
+	Migrate   migrate.Config  `envconfig:"migrate"`
+	NATS      nats.Config     `envconfig:"nats"`
+	Poller    PollerConfig    `envconfig:"poller"`
+	Scheduler SchedulerConfig `envconfig:"scheduler"`
+	Tracing   tracing.Config  `envconfig:"tracing"`
+}
+
+type AdminConfig struct {
+	// Token protects the admin API, the API is disabled when it's empty.
+	Token string `envconfig:"token"`
+}
+
+type JobsConfig struct {
+	// SourcePriorities sets a priority of jobs by their source unless it's passed in a message,
+	// e.g. "web:10,bulk:-10". Jobs of unlisted sources have zero priority.
+	SourcePriorities map[string]int `envconfig:"source_priorities"`
+}
+
+type PollerConfig struct {
+	// DrainTimeout is how long workers may finish their in-flight jobs on shutdown.
+	DrainTimeout time.Duration `envconfig:"drain_timeout" default:"10s"`
+	// MaxAttempts is how many times a job may fail before it's moved to a 'failed' status, zero means no limit.
+	MaxAttempts int `envconfig:"max_attempts" default:"20"`
+}
+
+// SchedulerConfig configures maintenance tasks, a schedule is either an interval like "10m" or a cron expression.
+type SchedulerConfig struct {
+	// PendingTimeout rejects applications of jobs which have been pending for longer, zero disables the task.
+	PendingTimeout         time.Duration `envconfig:"pending_timeout" default:"168h"`
+	PendingTimeoutSchedule string        `envconfig:"pending_timeout_schedule" default:"*/10 * * * *"`
+	// DoneRetention removes jobs done longer ago together with their events, zero disables the task.
+	DoneRetention         time.Duration `envconfig:"done_retention"`
+	DoneRetentionSchedule string        `envconfig:"done_retention_schedule" default:"0 3 * * *"`
 }
 
 func New() (Config, error) {
@@ -21,3 +65,10 @@ func New() (Config, error) {
 	}
 	return cfg, err
 }
+
+// MigrateConfig is what the migrate subcommand needs, so that it runs without the rest of the settings.
+type MigrateConfig struct {
+	DB      db.Config      `envconfig:"db"`
+	Log     logging.Config `envconfig:"log"`
+	Migrate migrate.Config `envconfig:"migrate"`
+}
@@ -0,0 +1,77 @@
+// Package maintenance contains the registry tasks run by the scheduler.
+package maintenance
+
+import (
+	"context"
+	"github.com/ivanovaleksey/lendo/pkg/models"
+	"github.com/pkg/errors"
+	uuid "github.com/satori/go.uuid"
+	log "github.com/sirupsen/logrus"
+	"time"
+)
+
+// PendingTimeoutError is the last error of jobs rejected by TimeoutPending.
+const PendingTimeoutError = "timed out waiting for bank decision"
+
+type JobsRepo interface {
+	RejectPendingBefore(ctx context.Context, before time.Time, reason string) ([]uuid.UUID, error)
+	DeleteDoneBefore(ctx context.Context, before time.Time) (int64, error)
+}
+
+// Notifier publishes applications.changed events consumed by the api.
+type Notifier interface {
+	ApplicationStatusChanged(ctx context.Context, change models.StatusChange) error
+}
+
+type Jobs struct {
+	repo           JobsRepo
+	notifier       Notifier
+	pendingTimeout time.Duration
+	doneRetention  time.Duration
+	logger         log.FieldLogger
+}
+
+func NewJobs(repo JobsRepo, notifier Notifier, pendingTimeout, doneRetention time.Duration) *Jobs {
+	return &Jobs{
+		repo:           repo,
+		notifier:       notifier,
+		pendingTimeout: pendingTimeout,
+		doneRetention:  doneRetention,
+		logger:         log.WithField("component", "maintenance.jobs"),
+	}
+}
+
+// TimeoutPending rejects applications which have been pending in the bank for longer than the timeout
+// and notifies the api, otherwise they would stay pending there forever.
+// The jobs are done anyway, a notification which can't be sent is logged.
+func (j *Jobs) TimeoutPending(ctx context.Context) error {
+	// jobs keep their timestamps in UTC
+	ids, err := j.repo.RejectPendingBefore(ctx, time.Now().UTC().Add(-j.pendingTimeout), PendingTimeoutError)
+	if err != nil {
+		return errors.Wrap(err, "can't reject pending jobs")
+	}
+	if len(ids) > 0 {
+		j.logger.Infof("rejected %d timed out pending jobs", len(ids))
+	}
+
+	for _, id := range ids {
+		change := models.StatusChange{
+			ID:     id,
+			Status: models.ApplicationStatusRejected,
+		}
+		if err := j.notifier.ApplicationStatusChanged(ctx, change); err != nil {
+			j.logger.WithField("application_id", id.String()).Errorf("can't notify about timed out application: %v", err)
+		}
+	}
+	return nil
+}
+
+// PurgeDone removes jobs done longer than the retention period ago.
+func (j *Jobs) PurgeDone(ctx context.Context) error {
+	num, err := j.repo.DeleteDoneBefore(ctx, time.Now().UTC().Add(-j.doneRetention))
+	if err != nil {
+		return errors.Wrap(err, "can't delete done jobs")
+	}
+	j.logger.Debugf("deleted %d done jobs", num)
+	return nil
+}
@@ -0,0 +1,117 @@
+package maintenance
+
+import (
+	"context"
+	"github.com/brianvoe/gofakeit"
+	"github.com/ivanovaleksey/lendo/pkg/models"
+	"github.com/ivanovaleksey/lendo/registry/maintenance/mocks"
+	"github.com/pkg/errors"
+	uuid "github.com/satori/go.uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"testing"
+	"time"
+)
+
+func TestJobs_TimeoutPending(t *testing.T) {
+	t.Run("should reject applications pending for longer than timeout", func(t *testing.T) {
+		fx := newFixture(t)
+		defer fx.Finish()
+
+		ids := []uuid.UUID{uuid.NewV4(), uuid.NewV4()}
+		fx.repo.On("RejectPendingBefore", fx.ctx, fx.before(fx.pendingTimeout), PendingTimeoutError).Return(ids, nil)
+		for _, id := range ids {
+			change := models.StatusChange{ID: id, Status: models.ApplicationStatusRejected}
+			fx.notifier.On("ApplicationStatusChanged", fx.ctx, change).Return(nil)
+		}
+
+		err := fx.jobs.TimeoutPending(fx.ctx)
+
+		assert.NoError(t, err)
+	})
+
+	t.Run("should notify about every application when notifier fails", func(t *testing.T) {
+		fx := newFixture(t)
+		defer fx.Finish()
+
+		ids := []uuid.UUID{uuid.NewV4(), uuid.NewV4()}
+		fx.repo.On("RejectPendingBefore", fx.ctx, mock.Anything, mock.Anything).Return(ids, nil)
+		fx.notifier.On("ApplicationStatusChanged", fx.ctx, mock.Anything).Return(errors.New(gofakeit.Sentence(3))).Twice()
+
+		err := fx.jobs.TimeoutPending(fx.ctx)
+
+		assert.NoError(t, err)
+	})
+
+	t.Run("when repo fails", func(t *testing.T) {
+		fx := newFixture(t)
+		defer fx.Finish()
+
+		fx.repo.On("RejectPendingBefore", fx.ctx, mock.Anything, mock.Anything).Return(nil, errors.New(gofakeit.Sentence(3)))
+
+		err := fx.jobs.TimeoutPending(fx.ctx)
+
+		assert.Error(t, err)
+	})
+}
+
+func TestJobs_PurgeDone(t *testing.T) {
+	t.Run("should delete jobs done before retention", func(t *testing.T) {
+		fx := newFixture(t)
+		defer fx.Finish()
+
+		fx.repo.On("DeleteDoneBefore", fx.ctx, fx.before(fx.doneRetention)).Return(int64(3), nil)
+
+		err := fx.jobs.PurgeDone(fx.ctx)
+
+		assert.NoError(t, err)
+	})
+
+	t.Run("when repo fails", func(t *testing.T) {
+		fx := newFixture(t)
+		defer fx.Finish()
+
+		fx.repo.On("DeleteDoneBefore", fx.ctx, mock.Anything).Return(int64(0), errors.New(gofakeit.Sentence(3)))
+
+		err := fx.jobs.PurgeDone(fx.ctx)
+
+		assert.Error(t, err)
+	})
+}
+
+type fixture struct {
+	t   *testing.T
+	ctx context.Context
+
+	repo           *mocks.JobsRepo
+	notifier       *mocks.Notifier
+	pendingTimeout time.Duration
+	doneRetention  time.Duration
+
+	jobs *Jobs
+}
+
+func newFixture(t *testing.T) *fixture {
+	fx := &fixture{
+		t:              t,
+		ctx:            context.Background(),
+		repo:           &mocks.JobsRepo{},
+		notifier:       &mocks.Notifier{},
+		pendingTimeout: 24 * time.Hour,
+		doneRetention:  30 * 24 * time.Hour,
+	}
+	fx.jobs = NewJobs(fx.repo, fx.notifier, fx.pendingTimeout, fx.doneRetention)
+	return fx
+}
+
+func (fx *fixture) Finish() {
+	fx.repo.AssertExpectations(fx.t)
+	fx.notifier.AssertExpectations(fx.t)
+}
+
+// before matches a UTC time about d ago.
+func (fx *fixture) before(d time.Duration) interface{} {
+	return mock.MatchedBy(func(before time.Time) bool {
+		return before.Location() == time.UTC && time.Since(before)-d < time.Minute && time.Since(before) >= d
+	})
+}
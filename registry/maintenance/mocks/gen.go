@@ -0,0 +1,4 @@
+//go:generate mockery --dir .. --output . --name JobsRepo --filename jobs_repo.mock.go
+//go:generate mockery --dir .. --output . --name Notifier --filename notifier.mock.go
+
+package mocks
@@ -0,0 +1,21 @@
+CREATE TABLE bank_exchanges (
+    id               UUID    NOT NULL DEFAULT gen_random_uuid(),
+    job_id           UUID,
+    application_id   UUID,
+    method           TEXT    NOT NULL,
+    url              TEXT    NOT NULL,
+    request_headers  JSON    NOT NULL,
+    request_body     TEXT    NOT NULL,
+    response_status  INTEGER NOT NULL,
+    response_headers JSON    NOT NULL,
+    response_body    TEXT    NOT NULL,
+    error            TEXT    NOT NULL,
+    latency_ms       BIGINT  NOT NULL,
+
+    created_at       TIMESTAMP NOT NULL DEFAULT now(),
+
+    PRIMARY KEY (id)
+);
+
+CREATE INDEX bank_exchanges_application_id_idx ON bank_exchanges USING btree (application_id, created_at);
+CREATE INDEX bank_exchanges_created_at_idx ON bank_exchanges USING btree (created_at);
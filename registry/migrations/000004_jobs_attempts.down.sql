@@ -0,0 +1,6 @@
+DROP INDEX jobs_run_at_idx;
+
+ALTER TABLE jobs
+    DROP COLUMN attempts,
+    DROP COLUMN last_error,
+    DROP COLUMN run_at;
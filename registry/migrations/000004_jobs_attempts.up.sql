@@ -0,0 +1,6 @@
+ALTER TABLE jobs
+    ADD COLUMN attempts   INTEGER   NOT NULL DEFAULT 0,
+    ADD COLUMN last_error TEXT      NOT NULL DEFAULT '',
+    ADD COLUMN run_at     TIMESTAMP NOT NULL DEFAULT now();
+
+CREATE INDEX jobs_run_at_idx ON jobs USING btree (run_at) WHERE status IN ('new', 'pending');
@@ -0,0 +1,15 @@
+CREATE TABLE job_events (
+    id          UUID    NOT NULL DEFAULT gen_random_uuid(),
+    job_id      UUID    NOT NULL,
+    handler     TEXT    NOT NULL,
+    bank_status TEXT    NOT NULL,
+    error       TEXT    NOT NULL,
+    duration_ms BIGINT  NOT NULL,
+    worker_id   INTEGER NOT NULL,
+
+    created_at  TIMESTAMP NOT NULL DEFAULT clock_timestamp(),
+
+    PRIMARY KEY (id)
+);
+
+CREATE INDEX job_events_job_id_idx ON job_events USING btree (job_id, created_at);
@@ -0,0 +1,12 @@
+DROP INDEX jobs_source_claimed_at_idx;
+DROP INDEX jobs_source_priority_idx;
+DROP INDEX jobs_priority_idx;
+
+CREATE INDEX jobs_run_at_idx ON jobs USING btree (run_at) WHERE status IN ('new', 'pending');
+
+DROP TABLE job_sources;
+
+ALTER TABLE jobs
+    DROP COLUMN priority,
+    DROP COLUMN source,
+    DROP COLUMN claimed_at;
@@ -0,0 +1,19 @@
+ALTER TABLE jobs
+    ADD COLUMN priority   INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN source     TEXT    NOT NULL DEFAULT 'default',
+    ADD COLUMN claimed_at TIMESTAMP;
+
+CREATE TABLE job_sources (
+    source     TEXT      NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT now(),
+
+    PRIMARY KEY (source)
+);
+
+INSERT INTO job_sources (source) VALUES ('default');
+
+DROP INDEX jobs_run_at_idx;
+
+CREATE INDEX jobs_priority_idx ON jobs USING btree (priority DESC, run_at) WHERE status IN ('new', 'pending');
+CREATE INDEX jobs_source_priority_idx ON jobs USING btree (source, priority DESC, run_at) WHERE status IN ('new', 'pending');
+CREATE INDEX jobs_source_claimed_at_idx ON jobs USING btree (source, claimed_at DESC) WHERE claimed_at IS NOT NULL;
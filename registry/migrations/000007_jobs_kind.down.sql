@@ -0,0 +1,12 @@
+DROP INDEX jobs_application_id_idx;
+DROP INDEX jobs_source_priority_idx;
+DROP INDEX jobs_priority_idx;
+
+CREATE INDEX jobs_priority_idx ON jobs USING btree (priority DESC, run_at) WHERE status IN ('new', 'pending');
+CREATE INDEX jobs_source_priority_idx ON jobs USING btree (source, priority DESC, run_at) WHERE status IN ('new', 'pending');
+
+DELETE FROM jobs WHERE kind <> 'application';
+
+ALTER TABLE jobs DROP COLUMN kind;
+
+ALTER TABLE jobs RENAME COLUMN payload TO application;
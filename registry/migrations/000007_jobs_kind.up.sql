@@ -0,0 +1,11 @@
+ALTER TABLE jobs RENAME COLUMN application TO payload;
+
+ALTER TABLE jobs ADD COLUMN kind TEXT NOT NULL DEFAULT 'application';
+ALTER TABLE jobs ALTER COLUMN kind DROP DEFAULT;
+
+DROP INDEX jobs_priority_idx;
+DROP INDEX jobs_source_priority_idx;
+
+CREATE INDEX jobs_priority_idx ON jobs USING btree (kind, priority DESC, run_at) WHERE status IN ('new', 'pending');
+CREATE INDEX jobs_source_priority_idx ON jobs USING btree (source, kind, priority DESC, run_at) WHERE status IN ('new', 'pending');
+CREATE INDEX jobs_application_id_idx ON jobs USING btree ((payload->>'id'), created_at) WHERE kind = 'application';
@@ -0,0 +1,10 @@
+CREATE TABLE scheduled_tasks (
+    task             TEXT      NOT NULL,
+    last_run_at      TIMESTAMP NOT NULL,
+    last_duration_ms BIGINT    NOT NULL,
+    last_error       TEXT      NOT NULL,
+
+    updated_at       TIMESTAMP NOT NULL DEFAULT now(),
+
+    PRIMARY KEY (task)
+);
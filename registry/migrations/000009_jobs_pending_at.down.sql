@@ -0,0 +1,3 @@
+DROP INDEX jobs_pending_at_idx;
+
+ALTER TABLE jobs DROP COLUMN pending_at;
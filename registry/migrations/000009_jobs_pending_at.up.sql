@@ -0,0 +1,6 @@
+ALTER TABLE jobs ADD COLUMN pending_at TIMESTAMP;
+
+-- the transition time of jobs pending before the column is unknown, the last update is the closest to it
+UPDATE jobs SET pending_at = updated_at WHERE status = 'pending';
+
+CREATE INDEX jobs_pending_at_idx ON jobs USING btree (pending_at) WHERE status = 'pending';
@@ -0,0 +1,7 @@
+DROP INDEX jobs_application_id_bank_key;
+
+CREATE INDEX jobs_application_id_idx ON jobs USING btree ((payload->>'id'), created_at) WHERE kind = 'application';
+
+ALTER TABLE jobs
+    DROP COLUMN application_id,
+    DROP COLUMN bank;
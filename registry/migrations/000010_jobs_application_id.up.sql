@@ -0,0 +1,17 @@
+ALTER TABLE jobs
+    ADD COLUMN application_id UUID,
+    ADD COLUMN bank           TEXT NOT NULL DEFAULT 'default';
+
+-- only the latest job of an application is kept unique, older duplicates have no application ID
+UPDATE jobs
+SET application_id = (payload->>'id')::uuid
+WHERE id IN (
+    SELECT DISTINCT ON (payload->>'id') id
+    FROM jobs
+    WHERE kind = 'application'
+    ORDER BY payload->>'id', created_at DESC
+);
+
+DROP INDEX jobs_application_id_idx;
+
+CREATE UNIQUE INDEX jobs_application_id_bank_key ON jobs USING btree (application_id, bank);
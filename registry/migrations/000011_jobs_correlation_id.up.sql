@@ -0,0 +1 @@
+ALTER TABLE jobs ADD COLUMN correlation_id TEXT NOT NULL DEFAULT '';
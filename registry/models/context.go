@@ -0,0 +1,16 @@
+package models
+
+import "context"
+
+type jobCtxKey struct{}
+
+// ContextWithJob returns a copy of ctx carrying the job being processed.
+func ContextWithJob(ctx context.Context, job Job) context.Context {
+	return context.WithValue(ctx, jobCtxKey{}, job)
+}
+
+// JobFromContext returns the job stored by ContextWithJob.
+func JobFromContext(ctx context.Context) (Job, bool) {
+	job, ok := ctx.Value(jobCtxKey{}).(Job)
+	return job, ok
+}
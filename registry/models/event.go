@@ -0,0 +1,27 @@
+package models
+
+import (
+	"github.com/ivanovaleksey/lendo/pkg/models"
+	uuid "github.com/satori/go.uuid"
+	"time"
+)
+
+const (
+	// JobEventHandlerCallback is a handler of events recorded for bank callbacks,
+	// the poller handlers are named after the job kind and status they handle, e.g. "application.new".
+	JobEventHandlerCallback = "callback"
+)
+
+// JobEvent is a single handler invocation for a job.
+type JobEvent struct {
+	ID      uuid.UUID `json:"id" db:"id"`
+	JobID   uuid.UUID `json:"job_id" db:"job_id"`
+	Handler string    `json:"handler" db:"handler"`
+	// BankStatus is an application status seen in the bank, empty when the handler failed.
+	BankStatus models.ApplicationStatus `json:"bank_status" db:"bank_status"`
+	Error      string                   `json:"error" db:"error"`
+	DurationMS int64                    `json:"duration_ms" db:"duration_ms"`
+	// WorkerID is zero for events recorded outside of the poller.
+	WorkerID  int       `json:"worker_id" db:"worker_id"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
@@ -0,0 +1,45 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	uuid "github.com/satori/go.uuid"
+	"net/http"
+	"time"
+)
+
+// BankExchange is a single recorded HTTP request to a bank partner
+// together with its response.
+type BankExchange struct {
+	ID              uuid.UUID  `json:"id" db:"id"`
+	JobID           *uuid.UUID `json:"job_id" db:"job_id"`
+	ApplicationID   *uuid.UUID `json:"application_id" db:"application_id"`
+	Method          string     `json:"method" db:"method"`
+	URL             string     `json:"url" db:"url"`
+	RequestHeaders  Headers    `json:"request_headers" db:"request_headers"`
+	RequestBody     string     `json:"request_body" db:"request_body"`
+	ResponseStatus  int        `json:"response_status" db:"response_status"`
+	ResponseHeaders Headers    `json:"response_headers" db:"response_headers"`
+	ResponseBody    string     `json:"response_body" db:"response_body"`
+	Error           string     `json:"error" db:"error"`
+	LatencyMS       int64      `json:"latency_ms" db:"latency_ms"`
+	CreatedAt       time.Time  `json:"created_at" db:"created_at"`
+}
+
+type Headers http.Header
+
+func (h Headers) Value() (driver.Value, error) {
+	if h == nil {
+		return []byte("{}"), nil
+	}
+	return json.Marshal(h)
+}
+
+func (h *Headers) Scan(src interface{}) error {
+	data, ok := src.([]byte)
+	if !ok {
+		return errors.New("expected []byte")
+	}
+	return json.Unmarshal(data, h)
+}
@@ -1,12 +1,145 @@
 package models
 
 import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"github.com/ivanovaleksey/lendo/pkg/models"
 	uuid "github.com/satori/go.uuid"
+	"time"
 )
 
 type Job struct {
-	ID          uuid.UUID          `json:"id"`
-	Application models.Application `json:"application"`
-	Status      JobStatus          `json:"status"`
+	ID   uuid.UUID `json:"id"`
+	Kind JobKind   `json:"kind" db:"kind"`
+	// Payload is the kind specific data, e.g. an application for JobKindApplication.
+	Payload Payload `json:"payload" db:"payload"`
+	// ApplicationID is set for application jobs, an application is submitted to a bank by a single job.
+	ApplicationID *uuid.UUID `json:"application_id,omitempty" db:"application_id"`
+	Bank          string     `json:"bank" db:"bank"`
+	Status        JobStatus  `json:"status"`
+	// Priority orders jobs of all sources, higher goes first.
+	Priority int `json:"priority" db:"priority"`
+	// Source is a channel or a partner the application came from, sources take turns to be processed.
+	Source string `json:"source" db:"source"`
+	// TraceContext links job processing to the trace of the request which created the job.
+	TraceContext TraceContext `json:"-" db:"trace_context"`
+	// CorrelationID is shared with the events of the request which created the job, job logs and events carry it.
+	CorrelationID string `json:"correlation_id,omitempty" db:"correlation_id"`
+	// Attempts is a number of failed handler invocations.
+	Attempts  int       `json:"attempts" db:"attempts"`
+	LastError string    `json:"last_error" db:"last_error"`
+	RunAt     time.Time `json:"run_at" db:"run_at"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// NewApplicationJob returns a new job of the application kind.
+func NewApplicationJob(application models.Application) (Job, error) {
+	job := Job{
+		Kind:          JobKindApplication,
+		ApplicationID: &application.ID,
+		Status:        JobStatusNew,
+	}
+	err := job.SetApplication(application)
+	return job, err
+}
+
+// Application decodes the payload of an application job.
+func (j Job) Application() (models.Application, error) {
+	var application models.Application
+	if j.Kind != JobKindApplication {
+		return application, fmt.Errorf("job of %q kind has no application", j.Kind)
+	}
+	err := json.Unmarshal(j.Payload, &application)
+	return application, err
+}
+
+// SetApplication makes the application a payload of the job.
+func (j *Job) SetApplication(application models.Application) error {
+	data, err := json.Marshal(application)
+	if err != nil {
+		return err
+	}
+	j.Payload = data
+	return nil
+}
+
+// Payload is raw JSON stored as is.
+type Payload []byte
+
+func (p Payload) MarshalJSON() ([]byte, error) {
+	if p == nil {
+		return []byte("null"), nil
+	}
+	return p, nil
+}
+
+func (p *Payload) UnmarshalJSON(data []byte) error {
+	*p = append((*p)[:0], data...)
+	return nil
+}
+
+func (p Payload) Value() (driver.Value, error) {
+	if p == nil {
+		return []byte("{}"), nil
+	}
+	return []byte(p), nil
+}
+
+func (p *Payload) Scan(src interface{}) error {
+	data, ok := src.([]byte)
+	if !ok {
+		return errors.New("expected []byte")
+	}
+	*p = append(Payload(nil), data...)
+	return nil
+}
+
+// DefaultJobSource is a source of applications which came without one.
+const DefaultJobSource = "default"
+
+// DefaultBank is a bank of jobs created without one.
+const DefaultBank = "default"
+
+// JobDetails is a job together with its history.
+type JobDetails struct {
+	Job
+	Exchanges []BankExchange `json:"exchanges"`
+	// Events is a timeline of handler invocations.
+	Events []JobEvent `json:"events"`
+}
+
+type TraceContext map[string]string
+
+func (c TraceContext) Value() (driver.Value, error) {
+	if c == nil {
+		return []byte("{}"), nil
+	}
+	return json.Marshal(c)
+}
+
+func (c *TraceContext) Scan(src interface{}) error {
+	data, ok := src.([]byte)
+	if !ok {
+		return errors.New("expected []byte")
+	}
+	var m map[string]string
+	if err := json.Unmarshal(data, &m); err != nil {
+		return err
+	}
+	if len(m) == 0 {
+		m = nil
+	}
+	*c = m
+	return nil
+}
+
+// JobStats aggregates jobs in a status.
+type JobStats struct {
+	Status JobStatus `db:"status"`
+	Count  int       `db:"count"`
+	// OldestAge is an age of the oldest job in seconds.
+	OldestAge float64 `db:"oldest_age"`
 }
@@ -0,0 +1,9 @@
+package models
+
+// JobKind is a type of work a job describes. Every kind has its own payload and handlers.
+type JobKind string
+
+const (
+	// JobKindApplication registers an application in a bank system and polls it for the final status.
+	JobKindApplication JobKind = "application"
+)
@@ -0,0 +1,15 @@
+package models
+
+type PaginationParams struct {
+	Limit  int `json:"limit"`
+	Offset int `json:"offset"`
+}
+
+func (params PaginationParams) GetLimit() int {
+	const defaultLimit = 10
+
+	if params.Limit > 0 {
+		return params.Limit
+	}
+	return defaultLimit
+}
@@ -6,4 +6,7 @@ const (
 	JobStatusNew     JobStatus = "new"
 	JobStatusPending JobStatus = "pending"
 	JobStatusDone    JobStatus = "done"
+	// JobStatusFailed is set when a job runs out of attempts or is failed by an operator.
+	JobStatusFailed    JobStatus = "failed"
+	JobStatusCancelled JobStatus = "cancelled"
 )
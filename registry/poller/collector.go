@@ -0,0 +1,65 @@
+package poller
+
+import (
+	"context"
+	"github.com/ivanovaleksey/lendo/pkg/metrics"
+	"github.com/ivanovaleksey/lendo/registry/models"
+	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
+	"time"
+)
+
+const collectTimeout = 3 * time.Second
+
+type StatsRepo interface {
+	GetStats(ctx context.Context) ([]models.JobStats, error)
+}
+
+// JobsCollector exposes the job backlog. Stats are queried on every scrape,
+// so they are shared by all the registry replicas.
+type JobsCollector struct {
+	repo   StatsRepo
+	logger log.FieldLogger
+
+	count     *prometheus.Desc
+	oldestAge *prometheus.Desc
+}
+
+func NewJobsCollector(repo StatsRepo) *JobsCollector {
+	c := &JobsCollector{
+		repo:   repo,
+		logger: log.WithField("component", "jobs.collector"),
+		count: prometheus.NewDesc(
+			prometheus.BuildFQName(metrics.Namespace, "jobs", "count"),
+			"Number of jobs by status.",
+			[]string{"status"}, nil,
+		),
+		oldestAge: prometheus.NewDesc(
+			prometheus.BuildFQName(metrics.Namespace, "jobs", "oldest_age_seconds"),
+			"Age of the oldest job by status.",
+			[]string{"status"}, nil,
+		),
+	}
+	return c
+}
+
+func (c *JobsCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.count
+	ch <- c.oldestAge
+}
+
+func (c *JobsCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), collectTimeout)
+	defer cancel()
+
+	stats, err := c.repo.GetStats(ctx)
+	if err != nil {
+		c.logger.Errorf("can't get job stats: %v", err)
+		return
+	}
+
+	for _, s := range stats {
+		ch <- prometheus.MustNewConstMetric(c.count, prometheus.GaugeValue, float64(s.Count), string(s.Status))
+		ch <- prometheus.MustNewConstMetric(c.oldestAge, prometheus.GaugeValue, s.OldestAge, string(s.Status))
+	}
+}
@@ -0,0 +1,51 @@
+package poller_test
+
+import (
+	"errors"
+	"github.com/ivanovaleksey/lendo/registry/models"
+	"github.com/ivanovaleksey/lendo/registry/poller"
+	"github.com/ivanovaleksey/lendo/registry/poller/mocks"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"strings"
+	"testing"
+)
+
+func TestJobsCollector_Collect(t *testing.T) {
+	t.Run("should expose stats by status", func(t *testing.T) {
+		repo := &mocks.StatsRepo{}
+		defer repo.AssertExpectations(t)
+
+		stats := []models.JobStats{
+			{Status: models.JobStatusNew, Count: 3, OldestAge: 12.5},
+			{Status: models.JobStatusPending, Count: 1, OldestAge: 60},
+		}
+		repo.On("GetStats", mock.Anything).Return(stats, nil)
+
+		expected := `
+			# HELP lendo_jobs_count Number of jobs by status.
+			# TYPE lendo_jobs_count gauge
+			lendo_jobs_count{status="new"} 3
+			lendo_jobs_count{status="pending"} 1
+			# HELP lendo_jobs_oldest_age_seconds Age of the oldest job by status.
+			# TYPE lendo_jobs_oldest_age_seconds gauge
+			lendo_jobs_oldest_age_seconds{status="new"} 12.5
+			lendo_jobs_oldest_age_seconds{status="pending"} 60
+		`
+		err := testutil.CollectAndCompare(poller.NewJobsCollector(repo), strings.NewReader(expected))
+
+		assert.NoError(t, err)
+	})
+
+	t.Run("when cannot get stats", func(t *testing.T) {
+		repo := &mocks.StatsRepo{}
+		defer repo.AssertExpectations(t)
+
+		repo.On("GetStats", mock.Anything).Return(nil, errors.New("db is down"))
+
+		count := testutil.CollectAndCount(poller.NewJobsCollector(repo))
+
+		assert.Zero(t, count)
+	})
+}
@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"context"
+	"github.com/ivanovaleksey/lendo/pkg/logging"
+	commonModels "github.com/ivanovaleksey/lendo/pkg/models"
+	"github.com/ivanovaleksey/lendo/registry/models"
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// CallbackJobHandler applies an application status pushed by a bank system,
+// updates job and application status, notifies queue.
+type CallbackJobHandler struct {
+	Handler
+}
+
+func NewCallbackJobHandler(repo Repo, notifier Notifier) *CallbackJobHandler {
+	return &CallbackJobHandler{
+		Handler: Handler{
+			repo:     repo,
+			notifier: notifier,
+			logger:   log.WithField("handler", "callback"),
+		},
+	}
+}
+
+func (h *CallbackJobHandler) Handle(ctx context.Context, tx sqlx.ExecerContext, job models.Job, status commonModels.ApplicationStatus) error {
+	logger := logging.WithContext(ctx, h.logger).WithField("job_id", job.ID.String())
+
+	if job.Status != models.JobStatusPending {
+		logger.Debugf("skip job in %q status", job.Status)
+		return nil
+	}
+
+	if !status.Final() {
+		logger.Warnf("skip status %q which is not final", status)
+		return nil
+	}
+	application, err := job.Application()
+	if err != nil {
+		return errors.Wrap(err, "can't decode application")
+	}
+	if status == application.Status {
+		logger.Debug("status has not changed")
+		return nil
+	}
+
+	return h.complete(ctx, tx, job, application, status)
+}
@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"github.com/brianvoe/gofakeit"
+	commonModels "github.com/ivanovaleksey/lendo/pkg/models"
+	"github.com/ivanovaleksey/lendo/registry/models"
+	"github.com/pkg/errors"
+	uuid "github.com/satori/go.uuid"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestCallbackJobHandler_Handle(t *testing.T) {
+	application := commonModels.Application{
+		NewApplication: commonModels.NewApplication{
+			FirstName: gofakeit.FirstName(),
+			LastName:  gofakeit.LastName(),
+		},
+		ID:     uuid.NewV4(),
+		Status: commonModels.ApplicationStatusPending,
+	}
+	pendingJob := buildJob(t, uuid.NewV4(), models.JobStatusPending, application)
+
+	t.Run("when job is not pending", func(t *testing.T) {
+		fx := newFixture(t)
+		defer fx.Finish()
+
+		job := pendingJob
+		job.Status = models.JobStatusDone
+
+		err := NewCallbackJobHandler(fx.repo, fx.notifier).Handle(fx.ctx, fx.tx, job, commonModels.ApplicationStatusCompleted)
+
+		assert.NoError(t, err)
+	})
+
+	t.Run("when application status has not changed", func(t *testing.T) {
+		fx := newFixture(t)
+		defer fx.Finish()
+
+		err := NewCallbackJobHandler(fx.repo, fx.notifier).Handle(fx.ctx, fx.tx, pendingJob, application.Status)
+
+		assert.NoError(t, err)
+	})
+
+	t.Run("when status is not final", func(t *testing.T) {
+		fx := newFixture(t)
+		defer fx.Finish()
+
+		err := NewCallbackJobHandler(fx.repo, fx.notifier).Handle(fx.ctx, fx.tx, pendingJob, commonModels.ApplicationStatus(gofakeit.Word()))
+
+		assert.NoError(t, err)
+	})
+
+	t.Run("when cannot update job", func(t *testing.T) {
+		fx := newFixture(t)
+		defer fx.Finish()
+
+		applicationStatus := commonModels.ApplicationStatus(commonModels.ApplicationStatusCompleted)
+
+		repoErr := errors.New(gofakeit.Sentence(3))
+		updated := application
+		updated.Status = applicationStatus
+		job := buildJob(t, pendingJob.ID, models.JobStatusDone, updated)
+		fx.repo.On("UpdateJobTx", fx.ctx, fx.tx, job).Return(repoErr)
+
+		err := NewCallbackJobHandler(fx.repo, fx.notifier).Handle(fx.ctx, fx.tx, pendingJob, applicationStatus)
+
+		assert.Equal(t, repoErr, errors.Cause(err))
+	})
+
+	t.Run("when everything is fine should move to done", func(t *testing.T) {
+		fx := newFixture(t)
+		defer fx.Finish()
+
+		applicationStatus := commonModels.ApplicationStatus(commonModels.ApplicationStatusCompleted)
+
+		updated := application
+		updated.Status = applicationStatus
+		job := buildJob(t, pendingJob.ID, models.JobStatusDone, updated)
+		fx.repo.On("UpdateJobTx", fx.ctx, fx.tx, job).Return(nil)
+
+		notification := commonModels.StatusChange{
+			ID:     application.ID,
+			Status: applicationStatus,
+		}
+		fx.notifier.On("ApplicationStatusChanged", fx.ctx, notification).Return(nil)
+
+		err := NewCallbackJobHandler(fx.repo, fx.notifier).Handle(fx.ctx, fx.tx, pendingJob, applicationStatus)
+
+		assert.NoError(t, err)
+	})
+}
@@ -5,6 +5,7 @@ import (
 	commonModels "github.com/ivanovaleksey/lendo/pkg/models"
 	"github.com/ivanovaleksey/lendo/registry/models"
 	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
 	uuid "github.com/satori/go.uuid"
 	log "github.com/sirupsen/logrus"
 )
@@ -28,3 +29,35 @@ type Handler struct {
 	notifier Notifier
 	logger   log.FieldLogger
 }
+
+// update saves the application as the job payload and moves the job to the status.
+func (h *Handler) update(ctx context.Context, tx sqlx.ExecerContext, job models.Job, application commonModels.Application, status models.JobStatus) error {
+	job.Status = status
+	if err := job.SetApplication(application); err != nil {
+		return errors.Wrap(err, "can't encode application")
+	}
+	if err := h.repo.UpdateJobTx(ctx, tx, job); err != nil {
+		return errors.Wrap(err, "can't update application status")
+	}
+	return nil
+}
+
+// complete moves the job to a 'done' status and notifies about the new application status.
+func (h *Handler) complete(ctx context.Context, tx sqlx.ExecerContext, job models.Job, application commonModels.Application, status commonModels.ApplicationStatus) error {
+	application.Status = status
+	err := h.update(ctx, tx, job, application, models.JobStatusDone)
+	if err != nil {
+		return err
+	}
+
+	notification := commonModels.StatusChange{
+		ID:     application.ID,
+		Status: application.Status,
+	}
+	err = h.notifier.ApplicationStatusChanged(ctx, notification)
+	if err != nil {
+		return errors.Wrap(err, "can't send notification")
+	}
+
+	return nil
+}
@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"context"
+	"github.com/ivanovaleksey/lendo/pkg/logging"
 	commonModels "github.com/ivanovaleksey/lendo/pkg/models"
 	"github.com/ivanovaleksey/lendo/registry/models"
 	"github.com/jmoiron/sqlx"
@@ -27,23 +28,27 @@ func NewNewJobHandler(bank Bank, repo Repo, notifier Notifier) *NewJobHandler {
 }
 
 func (h *NewJobHandler) Handle(ctx context.Context, tx sqlx.ExecerContext, job models.Job) error {
-	logger := h.logger.WithField("job_id", job.ID.String())
+	logger := logging.WithContext(ctx, h.logger).WithField("job_id", job.ID.String())
 
-	status, err := h.bank.CreateApplication(ctx, job.Application)
+	application, err := job.Application()
+	if err != nil {
+		return errors.Wrap(err, "can't decode application")
+	}
+
+	status, err := h.bank.CreateApplication(ctx, application)
 	if err != nil {
 		return errors.Wrap(err, "can't create application in bank")
 	}
 
-	job.Status = models.JobStatusPending
-	job.Application.Status = status
-	err = h.repo.UpdateJobTx(ctx, tx, job)
+	application.Status = status
+	err = h.update(ctx, tx, job, application, models.JobStatusPending)
 	if err != nil {
-		return errors.Wrap(err, "can't update application status")
+		return err
 	}
 
 	notification := commonModels.StatusChange{
-		ID:     job.Application.ID,
-		Status: job.Application.Status,
+		ID:     application.ID,
+		Status: application.Status,
 	}
 	err = h.notifier.ApplicationStatusChanged(ctx, notification)
 	if err != nil {
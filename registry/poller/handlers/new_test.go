@@ -12,22 +12,32 @@ import (
 	"github.com/pkg/errors"
 	uuid "github.com/satori/go.uuid"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"testing"
 )
 
 func TestNewJobHandler_Handle(t *testing.T) {
-	newJob := models.Job{
-		ID: uuid.NewV4(),
-		Application: commonModels.Application{
-			NewApplication: commonModels.NewApplication{
-				FirstName: gofakeit.FirstName(),
-				LastName:  gofakeit.LastName(),
-			},
-			ID:     uuid.NewV4(),
-			Status: commonModels.ApplicationStatusNew,
+	application := commonModels.Application{
+		NewApplication: commonModels.NewApplication{
+			FirstName: gofakeit.FirstName(),
+			LastName:  gofakeit.LastName(),
 		},
-		Status: models.JobStatusNew,
+		ID:     uuid.NewV4(),
+		Status: commonModels.ApplicationStatusNew,
 	}
+	newJob := buildJob(t, uuid.NewV4(), models.JobStatusNew, application)
+
+	t.Run("when job is not an application", func(t *testing.T) {
+		fx := newNewHandlerFixture(t)
+		defer fx.Finish()
+
+		job := newJob
+		job.Kind = models.JobKind(gofakeit.Word())
+
+		err := fx.handler.Handle(fx.ctx, dummyExecer{}, job)
+
+		assert.Error(t, err)
+	})
 
 	t.Run("when cannot register application in bank", func(t *testing.T) {
 		fx := newNewHandlerFixture(t)
@@ -37,7 +47,7 @@ func TestNewJobHandler_Handle(t *testing.T) {
 			Code:    400,
 			Message: gofakeit.Sentence(3),
 		}
-		fx.bank.On("CreateApplication", fx.ctx, newJob.Application).Return(commonModels.ApplicationStatus(""), bankErr)
+		fx.bank.On("CreateApplication", fx.ctx, application).Return(commonModels.ApplicationStatus(""), bankErr)
 
 		err := fx.handler.Handle(fx.ctx, dummyExecer{}, newJob)
 
@@ -49,12 +59,12 @@ func TestNewJobHandler_Handle(t *testing.T) {
 		defer fx.Finish()
 
 		applicationStatus := commonModels.ApplicationStatus(gofakeit.Word())
-		fx.bank.On("CreateApplication", fx.ctx, newJob.Application).Return(applicationStatus, nil)
+		fx.bank.On("CreateApplication", fx.ctx, application).Return(applicationStatus, nil)
 
 		repoErr := errors.New(gofakeit.Sentence(3))
-		job := newJob
-		job.Status = models.JobStatusPending
-		job.Application.Status = applicationStatus
+		updated := application
+		updated.Status = applicationStatus
+		job := buildJob(t, newJob.ID, models.JobStatusPending, updated)
 		fx.repo.On("UpdateJobTx", fx.ctx, fx.tx, job).Return(repoErr)
 
 		err := fx.handler.Handle(fx.ctx, fx.tx, newJob)
@@ -67,15 +77,15 @@ func TestNewJobHandler_Handle(t *testing.T) {
 		defer fx.Finish()
 
 		applicationStatus := commonModels.ApplicationStatus(gofakeit.Word())
-		fx.bank.On("CreateApplication", fx.ctx, newJob.Application).Return(applicationStatus, nil)
+		fx.bank.On("CreateApplication", fx.ctx, application).Return(applicationStatus, nil)
 
-		job := newJob
-		job.Status = models.JobStatusPending
-		job.Application.Status = applicationStatus
+		updated := application
+		updated.Status = applicationStatus
+		job := buildJob(t, newJob.ID, models.JobStatusPending, updated)
 		fx.repo.On("UpdateJobTx", fx.ctx, fx.tx, job).Return(nil)
 
 		notification := commonModels.StatusChange{
-			ID:     newJob.Application.ID,
+			ID:     application.ID,
 			Status: applicationStatus,
 		}
 		notifierErr := errors.New(gofakeit.Sentence(3))
@@ -91,15 +101,15 @@ func TestNewJobHandler_Handle(t *testing.T) {
 		defer fx.Finish()
 
 		applicationStatus := commonModels.ApplicationStatus(gofakeit.Word())
-		fx.bank.On("CreateApplication", fx.ctx, newJob.Application).Return(applicationStatus, nil)
+		fx.bank.On("CreateApplication", fx.ctx, application).Return(applicationStatus, nil)
 
-		job := newJob
-		job.Status = models.JobStatusPending
-		job.Application.Status = applicationStatus
+		updated := application
+		updated.Status = applicationStatus
+		job := buildJob(t, newJob.ID, models.JobStatusPending, updated)
 		fx.repo.On("UpdateJobTx", fx.ctx, fx.tx, job).Return(nil)
 
 		notification := commonModels.StatusChange{
-			ID:     newJob.Application.ID,
+			ID:     application.ID,
 			Status: applicationStatus,
 		}
 		fx.notifier.On("ApplicationStatusChanged", fx.ctx, notification).Return(nil)
@@ -151,6 +161,15 @@ func (fx *fixture) Finish() {
 	fx.notifier.AssertExpectations(fx.t)
 }
 
+// buildJob returns an application job with the given ID.
+func buildJob(t *testing.T, id uuid.UUID, status models.JobStatus, application commonModels.Application) models.Job {
+	job, err := models.NewApplicationJob(application)
+	require.NoError(t, err)
+	job.ID = id
+	job.Status = status
+	return job
+}
+
 type dummyExecer struct{}
 
 func (dummyExecer) ExecContext(context.Context, string, ...interface{}) (sql.Result, error) {
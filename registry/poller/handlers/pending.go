@@ -2,7 +2,7 @@ package handlers
 
 import (
 	"context"
-	commonModels "github.com/ivanovaleksey/lendo/pkg/models"
+	"github.com/ivanovaleksey/lendo/pkg/logging"
 	"github.com/ivanovaleksey/lendo/registry/models"
 	"github.com/jmoiron/sqlx"
 	"github.com/pkg/errors"
@@ -27,33 +27,22 @@ func NewPendingJobHandler(bank Bank, repo Repo, notifier Notifier) *PendingJobHa
 }
 
 func (h *PendingJobHandler) Handle(ctx context.Context, tx sqlx.ExecerContext, job models.Job) error {
-	logger := h.logger.WithField("job_id", job.ID.String())
+	logger := logging.WithContext(ctx, h.logger).WithField("job_id", job.ID.String())
 
-	status, err := h.bank.GetApplicationStatus(ctx, job.Application.ID)
+	application, err := job.Application()
 	if err != nil {
-		return errors.Wrap(err, "can't get application status")
-	}
-
-	if status == job.Application.Status {
-		logger.Debug("not ready yet")
-		return nil
+		return errors.Wrap(err, "can't decode application")
 	}
 
-	job.Status = models.JobStatusDone
-	job.Application.Status = status
-	err = h.repo.UpdateJobTx(ctx, tx, job)
+	status, err := h.bank.GetApplicationStatus(ctx, application.ID)
 	if err != nil {
-		return errors.Wrap(err, "can't update application status")
+		return errors.Wrap(err, "can't get application status")
 	}
 
-	notification := commonModels.StatusChange{
-		ID:     job.Application.ID,
-		Status: job.Application.Status,
-	}
-	err = h.notifier.ApplicationStatusChanged(ctx, notification)
-	if err != nil {
-		return errors.Wrap(err, "can't send notification")
+	if status == application.Status {
+		logger.Debug("not ready yet")
+		return nil
 	}
 
-	return nil
+	return h.complete(ctx, tx, job, application, status)
 }
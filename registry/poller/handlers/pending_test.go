@@ -12,18 +12,15 @@ import (
 )
 
 func TestPendingJobHandler_Handle(t *testing.T) {
-	pendingJob := models.Job{
-		ID: uuid.NewV4(),
-		Application: commonModels.Application{
-			NewApplication: commonModels.NewApplication{
-				FirstName: gofakeit.FirstName(),
-				LastName:  gofakeit.LastName(),
-			},
-			ID:     uuid.NewV4(),
-			Status: commonModels.ApplicationStatusPending,
+	application := commonModels.Application{
+		NewApplication: commonModels.NewApplication{
+			FirstName: gofakeit.FirstName(),
+			LastName:  gofakeit.LastName(),
 		},
-		Status: models.JobStatusPending,
+		ID:     uuid.NewV4(),
+		Status: commonModels.ApplicationStatusPending,
 	}
+	pendingJob := buildJob(t, uuid.NewV4(), models.JobStatusPending, application)
 
 	t.Run("when cannot get application status", func(t *testing.T) {
 		fx := newPendingHandlerFixture(t)
@@ -33,7 +30,7 @@ func TestPendingJobHandler_Handle(t *testing.T) {
 			Code:    400,
 			Message: gofakeit.Sentence(3),
 		}
-		fx.bank.On("GetApplicationStatus", fx.ctx, pendingJob.Application.ID).Return(commonModels.ApplicationStatus(""), bankErr)
+		fx.bank.On("GetApplicationStatus", fx.ctx, application.ID).Return(commonModels.ApplicationStatus(""), bankErr)
 
 		err := fx.handler.Handle(fx.ctx, dummyExecer{}, pendingJob)
 
@@ -44,8 +41,8 @@ func TestPendingJobHandler_Handle(t *testing.T) {
 		fx := newPendingHandlerFixture(t)
 		defer fx.Finish()
 
-		applicationStatus := pendingJob.Application.Status
-		fx.bank.On("GetApplicationStatus", fx.ctx, pendingJob.Application.ID).Return(applicationStatus, nil)
+		applicationStatus := application.Status
+		fx.bank.On("GetApplicationStatus", fx.ctx, application.ID).Return(applicationStatus, nil)
 
 		err := fx.handler.Handle(fx.ctx, dummyExecer{}, pendingJob)
 
@@ -57,12 +54,12 @@ func TestPendingJobHandler_Handle(t *testing.T) {
 		defer fx.Finish()
 
 		applicationStatus := commonModels.ApplicationStatus(gofakeit.Word())
-		fx.bank.On("GetApplicationStatus", fx.ctx, pendingJob.Application.ID).Return(applicationStatus, nil)
+		fx.bank.On("GetApplicationStatus", fx.ctx, application.ID).Return(applicationStatus, nil)
 
 		repoErr := errors.New(gofakeit.Sentence(3))
-		job := pendingJob
-		job.Status = models.JobStatusDone
-		job.Application.Status = applicationStatus
+		updated := application
+		updated.Status = applicationStatus
+		job := buildJob(t, pendingJob.ID, models.JobStatusDone, updated)
 		fx.repo.On("UpdateJobTx", fx.ctx, fx.tx, job).Return(repoErr)
 
 		err := fx.handler.Handle(fx.ctx, fx.tx, pendingJob)
@@ -75,15 +72,15 @@ func TestPendingJobHandler_Handle(t *testing.T) {
 		defer fx.Finish()
 
 		applicationStatus := commonModels.ApplicationStatus(gofakeit.Word())
-		fx.bank.On("GetApplicationStatus", fx.ctx, pendingJob.Application.ID).Return(applicationStatus, nil)
+		fx.bank.On("GetApplicationStatus", fx.ctx, application.ID).Return(applicationStatus, nil)
 
-		job := pendingJob
-		job.Status = models.JobStatusDone
-		job.Application.Status = applicationStatus
+		updated := application
+		updated.Status = applicationStatus
+		job := buildJob(t, pendingJob.ID, models.JobStatusDone, updated)
 		fx.repo.On("UpdateJobTx", fx.ctx, fx.tx, job).Return(nil)
 
 		notification := commonModels.StatusChange{
-			ID:     pendingJob.Application.ID,
+			ID:     application.ID,
 			Status: applicationStatus,
 		}
 		notifierErr := errors.New(gofakeit.Sentence(3))
@@ -99,15 +96,15 @@ func TestPendingJobHandler_Handle(t *testing.T) {
 		defer fx.Finish()
 
 		applicationStatus := commonModels.ApplicationStatus(gofakeit.Word())
-		fx.bank.On("GetApplicationStatus", fx.ctx, pendingJob.Application.ID).Return(applicationStatus, nil)
+		fx.bank.On("GetApplicationStatus", fx.ctx, application.ID).Return(applicationStatus, nil)
 
-		job := pendingJob
-		job.Status = models.JobStatusDone
-		job.Application.Status = applicationStatus
+		updated := application
+		updated.Status = applicationStatus
+		job := buildJob(t, pendingJob.ID, models.JobStatusDone, updated)
 		fx.repo.On("UpdateJobTx", fx.ctx, fx.tx, job).Return(nil)
 
 		notification := commonModels.StatusChange{
-			ID:     pendingJob.Application.ID,
+			ID:     application.ID,
 			Status: applicationStatus,
 		}
 		fx.notifier.On("ApplicationStatusChanged", fx.ctx, notification).Return(nil)
@@ -118,7 +115,7 @@ func TestPendingJobHandler_Handle(t *testing.T) {
 	})
 }
 
-func newPendingHandlerFixture(t *testing.T) *fixture{
+func newPendingHandlerFixture(t *testing.T) *fixture {
 	fx := newFixture(t)
 	fx.handler = NewPendingJobHandler(fx.bank, fx.repo, fx.notifier)
 	return fx
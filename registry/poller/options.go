@@ -2,11 +2,15 @@ package poller
 
 import (
 	"github.com/ivanovaleksey/lendo/pkg/db"
+	"github.com/ivanovaleksey/lendo/registry/models"
 	"github.com/ivanovaleksey/lendo/registry/poller/handlers"
+	"github.com/ivanovaleksey/lendo/registry/poller/worker"
+	"time"
 )
 
 type Option func(*Poller)
 
+// WithBank makes workers handle application jobs, see models.JobKindApplication.
 func WithBank(b handlers.Bank) Option {
 	return func(p *Poller) {
 		p.bank = b
@@ -25,6 +29,27 @@ func WithNotifier(n handlers.Notifier) Option {
 	}
 }
 
+// WithHandler registers a handler of jobs of the kind in the status for all the workers.
+func WithHandler(kind models.JobKind, status models.JobStatus, h worker.Handler) Option {
+	return func(p *Poller) {
+		p.handlers = append(p.handlers, kindHandler{kind: kind, status: status, handler: h})
+	}
+}
+
+// WithKinds subscribes the workers to jobs of the given kinds only, so that separate pollers
+// may process different kinds. By default workers claim jobs of all the kinds they have handlers for.
+func WithKinds(kinds ...models.JobKind) Option {
+	return func(p *Poller) {
+		p.kinds = kinds
+	}
+}
+
+func WithEventsRepo(r worker.EventsRepo) Option {
+	return func(p *Poller) {
+		p.events = r
+	}
+}
+
 func WithDB(db *db.DB) Option {
 	return func(p *Poller) {
 		p.db = db
@@ -48,3 +73,24 @@ func WithWorkerFactory(f WorkerFactory) Option {
 		p.workerFactory = f
 	}
 }
+
+// WithStaleAfter sets how long the poller may go without a processed tick before it's reported unhealthy.
+func WithStaleAfter(d time.Duration) Option {
+	return func(p *Poller) {
+		p.staleAfter = d
+	}
+}
+
+// WithDrainTimeout sets how long workers may finish their in-flight jobs on close.
+func WithDrainTimeout(d time.Duration) Option {
+	return func(p *Poller) {
+		p.drainTimeout = d
+	}
+}
+
+// WithMaxAttempts sets how many times a job may fail before it's moved to a 'failed' status.
+func WithMaxAttempts(n int) Option {
+	return func(p *Poller) {
+		p.maxAttempts = n
+	}
+}
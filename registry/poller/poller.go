@@ -6,34 +6,58 @@ import (
 	"github.com/ivanovaleksey/lendo/registry/models"
 	"github.com/ivanovaleksey/lendo/registry/poller/handlers"
 	"github.com/ivanovaleksey/lendo/registry/poller/worker"
+	"github.com/pkg/errors"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
 const (
 	defaultNumWorkers = 2
 	tickerDuration    = 10 * time.Second
+	staleAfter        = 3 * tickerDuration
 )
 
 type Poller struct {
 	bank          handlers.Bank
 	repo          handlers.Repo
 	notifier      handlers.Notifier
+	events        worker.EventsRepo
+	handlers      []kindHandler
+	kinds         []models.JobKind
 	workerFactory WorkerFactory
 	tickerFactory TickerFactory
 
 	db         *db.DB
 	numWorkers int
+	staleAfter time.Duration
 
-	workersWg     sync.WaitGroup
-	workersCancel context.CancelFunc
+	drainTimeout time.Duration
+	maxAttempts  int
+
+	mu       sync.Mutex
+	stop     chan struct{}
+	stopOnce sync.Once
+	runWg    sync.WaitGroup
+
+	// accessed atomically
+	aliveWorkers int32
+	lastTick     int64
+}
+
+type kindHandler struct {
+	kind    models.JobKind
+	status  models.JobStatus
+	handler worker.Handler
 }
 
 func New(opts ...Option) *Poller {
 	p := &Poller{
 		numWorkers:    defaultNumWorkers,
+		staleAfter:    staleAfter,
 		workerFactory: stdWorkerFactory{},
 		tickerFactory: stdTickerFactory{duration: tickerDuration},
+		stop:          make(chan struct{}),
 	}
 	for _, opt := range opts {
 		opt(p)
@@ -41,22 +65,61 @@ func New(opts ...Option) *Poller {
 	return p
 }
 
+// Run runs the workers until ctx is done or the poller is closed. When a worker fails or panics
+// the rest are stopped and the error is returned, so that the supervisor may restart the poller.
 func (p *Poller) Run(ctx context.Context) error {
+	if !p.start() {
+		return nil
+	}
+	defer p.runWg.Done()
+
 	ctx, cancel := context.WithCancel(ctx)
-	p.workersCancel = cancel
+	defer cancel()
+	go func() {
+		select {
+		case <-p.stop:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	p.tick()
 
+	var wg sync.WaitGroup
+	errs := make(chan error, p.numWorkers)
 	for i := 0; i < p.numWorkers; i++ {
-		p.workersWg.Add(1)
-
-		go func(ctx context.Context, id int) {
-			defer p.workersWg.Done()
+		wg.Add(1)
+		atomic.AddInt32(&p.aliveWorkers, 1)
 
-			w := p.newWorker(id + 1)
-			w.Run(ctx)
-		}(ctx, i)
+		go func(id int) {
+			defer wg.Done()
+			defer atomic.AddInt32(&p.aliveWorkers, -1)
+			errs <- p.runWorker(ctx, id)
+		}(i + 1)
 	}
 
-	return nil
+	// the first worker to stop either failed or was cancelled, the rest are stopped anyway
+	err := <-errs
+	cancel()
+	wg.Wait()
+	return err
+}
+
+// runWorker returns nil when the worker is stopped by ctx and an error when it stopped by itself.
+func (p *Poller) runWorker(ctx context.Context, id int) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = errors.Errorf("worker %d panic: %v", id, r)
+		}
+	}()
+
+	err = p.newWorker(id).Run(ctx)
+	if ctx.Err() != nil {
+		return nil
+	}
+	if err == nil {
+		return errors.Errorf("worker %d stopped", id)
+	}
+	return errors.Wrapf(err, "worker %d failed", id)
 }
 
 func (p *Poller) newWorker(id int) Worker {
@@ -64,16 +127,63 @@ func (p *Poller) newWorker(id int) Worker {
 		worker.WithID(id),
 		worker.WithTxFactory(db.NewTxFactory(p.db)),
 		worker.WithTicker(p.tickerFactory.NewTicker()),
-		worker.WithHandler(models.JobStatusNew, handlers.NewNewJobHandler(p.bank, p.repo, p.notifier)),
-		worker.WithHandler(models.JobStatusPending, handlers.NewPendingJobHandler(p.bank, p.repo, p.notifier)),
+		worker.WithTickHook(p.tick),
+		worker.WithDrainTimeout(p.drainTimeout),
+		worker.WithMaxAttempts(p.maxAttempts),
+		worker.WithEventsRepo(p.events),
+		worker.WithKinds(p.kinds...),
+	}
+	if p.bank != nil {
+		opts = append(opts,
+			worker.WithHandler(models.JobKindApplication, models.JobStatusNew, handlers.NewNewJobHandler(p.bank, p.repo, p.notifier)),
+			worker.WithHandler(models.JobKindApplication, models.JobStatusPending, handlers.NewPendingJobHandler(p.bank, p.repo, p.notifier)),
+		)
+	}
+	for _, h := range p.handlers {
+		opts = append(opts, worker.WithHandler(h.kind, h.status, h.handler))
 	}
 	w := p.workerFactory.NewWorker(opts...)
 	return w
 }
 
+func (p *Poller) tick() {
+	atomic.StoreInt64(&p.lastTick, time.Now().UnixNano())
+}
+
+// CheckHealth reports whether all the workers are running and at least one of them
+// has processed a tick recently. Failures of the work itself don't make the poller unhealthy.
+func (p *Poller) CheckHealth(ctx context.Context) error {
+	if alive := int(atomic.LoadInt32(&p.aliveWorkers)); alive < p.numWorkers {
+		return errors.Errorf("%d of %d workers alive", alive, p.numWorkers)
+	}
+
+	lastTick := time.Unix(0, atomic.LoadInt64(&p.lastTick))
+	if since := time.Since(lastTick); since > p.staleAfter {
+		return errors.Errorf("last tick %s ago", since.Round(time.Second))
+	}
+	return nil
+}
+
+// start registers a run unless the poller is closed.
+func (p *Poller) start() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	select {
+	case <-p.stop:
+		return false
+	default:
+	}
+	p.runWg.Add(1)
+	return true
+}
+
 func (p *Poller) Close() error {
-	p.workersCancel()
-	p.workersWg.Wait()
+	p.stopOnce.Do(func() { close(p.stop) })
+	// a Run starting concurrently either sees the stop or is waited for
+	p.mu.Lock()
+	p.mu.Unlock()
+	p.runWg.Wait()
 	return nil
 }
 
@@ -2,6 +2,8 @@ package poller_test
 
 import (
 	"context"
+	"github.com/ivanovaleksey/lendo/pkg/closer"
+	"github.com/ivanovaleksey/lendo/pkg/component"
 	"github.com/ivanovaleksey/lendo/pkg/db"
 	"github.com/ivanovaleksey/lendo/pkg/test"
 	"github.com/ivanovaleksey/lendo/pkg/ticker/mocks"
@@ -9,6 +11,8 @@ import (
 	"github.com/ivanovaleksey/lendo/registry/poller"
 	mockHandlers "github.com/ivanovaleksey/lendo/registry/poller/handlers/mocks"
 	"github.com/ivanovaleksey/lendo/registry/poller/mocks"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 	"testing"
@@ -31,16 +35,127 @@ func TestPoller_Run(t *testing.T) {
 
 		for i := 0; i < numWorkers; i++ {
 			wrk := &mocks.Worker{}
-			wrk.On("Run", mock.AnythingOfType("*context.cancelCtx")).Return(nil).Once()
+			wrk.On("Run", mock.AnythingOfType("*context.cancelCtx")).Run(waitDone).Return(context.Canceled).Once()
 			fx.workers = append(fx.workers, wrk)
 
 			fx.workerFactory.On("NewWorker", mock.AnythingOfType("[]worker.Option")).Return(wrk).Once()
 		}
 
-		err := fx.poller.Run(fx.ctx)
-
-		require.NoError(t, err)
+		errs := fx.run()
 		fx.Wait()
+
+		require.NoError(t, <-errs)
+	})
+}
+
+func TestPoller_Failure(t *testing.T) {
+	t.Run("should stop workers when one of them fails", func(t *testing.T) {
+		fx := newFakeFixture(t)
+		failed := &mocks.Worker{}
+		failed.On("Run", mock.Anything).Return(errors.New("boom")).Once()
+		running := &mocks.Worker{}
+		running.On("Run", mock.Anything).Run(waitDone).Return(context.Canceled).Once()
+		fx.workerFactory.On("NewWorker", mock.Anything).Return(failed).Once()
+		fx.workerFactory.On("NewWorker", mock.Anything).Return(running).Once()
+		fx.poller = poller.New(fx.opts(poller.WithNumWorkers(2))...)
+
+		err := fx.poller.Run(context.Background())
+
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "boom")
+		failed.AssertExpectations(t)
+		running.AssertExpectations(t)
+	})
+
+	t.Run("should recover worker panic", func(t *testing.T) {
+		fx := newFakeFixture(t)
+		wrk := &mocks.Worker{}
+		wrk.On("Run", mock.Anything).Run(func(mock.Arguments) { panic("boom") }).Return(nil).Once()
+		fx.workerFactory.On("NewWorker", mock.Anything).Return(wrk).Once()
+		fx.poller = poller.New(fx.opts()...)
+
+		err := fx.poller.Run(context.Background())
+
+		require.EqualError(t, err, "worker 1 panic: boom")
+	})
+
+	t.Run("should be restarted by supervisor", func(t *testing.T) {
+		fx := newFakeFixture(t)
+		restarted := make(chan struct{})
+		panicked := &mocks.Worker{}
+		panicked.On("Run", mock.Anything).Run(func(mock.Arguments) { panic("boom") }).Return(nil).Once()
+		running := &mocks.Worker{}
+		running.On("Run", mock.Anything).Run(func(args mock.Arguments) {
+			close(restarted)
+			waitDone(args)
+		}).Return(context.Canceled).Once()
+		fx.workerFactory.On("NewWorker", mock.Anything).Return(panicked).Once()
+		fx.workerFactory.On("NewWorker", mock.Anything).Return(running).Once()
+		fx.poller = poller.New(fx.opts()...)
+
+		supervisor := component.NewSupervisor(closer.New())
+		closure := supervisor.Run(context.Background(), fx.poller,
+			component.WithRestartPolicy(component.RestartOnFailure),
+			component.WithBackoff(time.Millisecond, time.Millisecond),
+		)
+
+		select {
+		case <-restarted:
+		case <-time.After(time.Second):
+			t.Fatal("poller was not restarted")
+		}
+		assert.Equal(t, component.StateRunning, supervisor.States()["poller"])
+		require.NoError(t, closure())
+		panicked.AssertExpectations(t)
+		running.AssertExpectations(t)
+		fx.workerFactory.AssertExpectations(t)
+	})
+}
+
+func TestPoller_CheckHealth(t *testing.T) {
+	setup := func(fx *fixture) {
+		tick := &mockTicker.Ticker{}
+		fx.tickerFactory.On("NewTicker").Return(tick).Once()
+
+		wrk := &mocks.Worker{}
+		wrk.On("Run", mock.AnythingOfType("*context.cancelCtx")).Run(waitDone).Return(context.Canceled).Once()
+		fx.workers = append(fx.workers, wrk)
+
+		fx.workerFactory.On("NewWorker", mock.AnythingOfType("[]worker.Option")).Return(wrk).Once()
+	}
+
+	t.Run("when workers are not running", func(t *testing.T) {
+		fx := newFixture(t)
+
+		err := fx.poller.CheckHealth(fx.ctx)
+
+		require.EqualError(t, err, "0 of 1 workers alive")
+	})
+
+	t.Run("when workers are running", func(t *testing.T) {
+		fx := newFixture(t)
+		defer fx.Finish()
+		setup(fx)
+
+		fx.run()
+
+		require.Eventually(t, func() bool {
+			return fx.poller.CheckHealth(fx.ctx) == nil
+		}, time.Second, time.Millisecond)
+	})
+
+	t.Run("when ticks are stale", func(t *testing.T) {
+		fx := newFixture(t, poller.WithStaleAfter(10*time.Millisecond))
+		defer fx.Finish()
+		setup(fx)
+
+		fx.run()
+		time.Sleep(20 * time.Millisecond)
+
+		err := fx.poller.CheckHealth(fx.ctx)
+
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "last tick")
 	})
 }
 
@@ -89,6 +204,15 @@ func newFixture(t *testing.T, opts ...poller.Option) *fixture {
 	return fx
 }
 
+// run runs the poller in background, its error is sent to the channel once the poller stops.
+func (fx *fixture) run() <-chan error {
+	errs := make(chan error, 1)
+	go func() {
+		errs <- fx.poller.Run(fx.ctx)
+	}()
+	return errs
+}
+
 func (fx *fixture) Finish() {
 	require.NoError(fx.t, fx.poller.Close())
 	require.True(fx.t, fx.tickerFactory.AssertExpectations(fx.t))
@@ -106,3 +230,34 @@ func (fx *fixture) Wait() {
 	})
 	<-done
 }
+
+// fakeFixture runs the poller with fake workers only, so it doesn't need the DB.
+type fakeFixture struct {
+	tickerFactory *mocks.TickerFactory
+	workerFactory *mocks.WorkerFactory
+
+	poller *poller.Poller
+}
+
+func newFakeFixture(t *testing.T) *fakeFixture {
+	fx := &fakeFixture{
+		tickerFactory: &mocks.TickerFactory{},
+		workerFactory: &mocks.WorkerFactory{},
+	}
+	fx.tickerFactory.On("NewTicker").Return(&mockTicker.Ticker{})
+	return fx
+}
+
+func (fx *fakeFixture) opts(opts ...poller.Option) []poller.Option {
+	baseOpts := []poller.Option{
+		poller.WithNumWorkers(1),
+		poller.WithWorkerFactory(fx.workerFactory),
+		poller.WithTickerFactory(fx.tickerFactory),
+	}
+	return append(baseOpts, opts...)
+}
+
+// waitDone blocks a fake worker until its context is done.
+func waitDone(args mock.Arguments) {
+	<-args.Get(0).(context.Context).Done()
+}
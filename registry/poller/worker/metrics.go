@@ -0,0 +1,29 @@
+package worker
+
+import (
+	"github.com/ivanovaleksey/lendo/pkg/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const (
+	outcomeSuccess = "success"
+	outcomeFailure = "failure"
+)
+
+var (
+	handlerDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metrics.Namespace,
+		Subsystem: "worker",
+		Name:      "handler_duration_seconds",
+		Help:      "Duration of job handlers by job kind and status.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"kind", "status"})
+
+	handlerOutcomes = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metrics.Namespace,
+		Subsystem: "worker",
+		Name:      "handler_outcomes_total",
+		Help:      "Number of handled jobs by job kind, status and outcome.",
+	}, []string{"kind", "status", "outcome"})
+)
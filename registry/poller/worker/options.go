@@ -4,6 +4,7 @@ import (
 	"github.com/ivanovaleksey/lendo/pkg/db"
 	"github.com/ivanovaleksey/lendo/pkg/ticker"
 	"github.com/ivanovaleksey/lendo/registry/models"
+	"time"
 )
 
 type Option func(*Worker)
@@ -26,8 +27,47 @@ func WithTicker(t ticker.Ticker) Option {
 	}
 }
 
-func WithHandler(s models.JobStatus, h Handler) Option {
+// WithHandler registers a handler of jobs of the kind in the status.
+func WithHandler(kind models.JobKind, status models.JobStatus, h Handler) Option {
 	return func(w *Worker) {
-		w.handlers[s] = h
+		w.handlers[handlerKey{kind: kind, status: status}] = h
+	}
+}
+
+// WithKinds subscribes the worker to jobs of the given kinds only.
+// By default the worker claims jobs of all the kinds it has handlers for.
+func WithKinds(kinds ...models.JobKind) Option {
+	return func(w *Worker) {
+		w.kinds = kinds
+	}
+}
+
+// WithEventsRepo makes the worker record every handler invocation to the job timeline.
+func WithEventsRepo(r EventsRepo) Option {
+	return func(w *Worker) {
+		w.events = r
+	}
+}
+
+// WithTickHook sets a function called after every processed tick, whether the work succeeded or not.
+func WithTickHook(fn func()) Option {
+	return func(w *Worker) {
+		w.tickHook = fn
+	}
+}
+
+// WithDrainTimeout lets an in-flight job run for the given time after the worker is cancelled.
+// Zero means the job is cancelled together with the worker.
+func WithDrainTimeout(d time.Duration) Option {
+	return func(w *Worker) {
+		w.drainTimeout = d
+	}
+}
+
+// WithMaxAttempts sets how many times a job may fail before it's moved to a 'failed' status.
+// Zero means no limit.
+func WithMaxAttempts(n int) Option {
+	return func(w *Worker) {
+		w.maxAttempts = n
 	}
 }
@@ -4,11 +4,23 @@ import (
 	"context"
 	"database/sql"
 	"github.com/ivanovaleksey/lendo/pkg/db"
+	"github.com/ivanovaleksey/lendo/pkg/envelope"
+	"github.com/ivanovaleksey/lendo/pkg/logging"
 	"github.com/ivanovaleksey/lendo/pkg/ticker"
+	"github.com/ivanovaleksey/lendo/pkg/tracing"
 	"github.com/ivanovaleksey/lendo/registry/models"
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"time"
+)
+
+const (
+	minRetryBackoff = 5 * time.Second
+	maxRetryBackoff = 10 * time.Minute
 )
 
 type Worker struct {
@@ -16,20 +28,40 @@ type Worker struct {
 	txFactory db.TxFactory
 	logger    log.FieldLogger
 	ticker    ticker.Ticker
-	handlers  map[models.JobStatus]Handler
+	handlers  map[handlerKey]Handler
+	kinds     []models.JobKind
+	events    EventsRepo
+	tickHook  func()
+
+	drainTimeout time.Duration
+	maxAttempts  int
 }
 
+// Handler processes a job of some kind in some status.
 type Handler interface {
 	Handle(ctx context.Context, tx sqlx.ExecerContext, job models.Job) error
 }
 
+type handlerKey struct {
+	kind   models.JobKind
+	status models.JobStatus
+}
+
+// EventsRepo records handler invocations to the job timeline.
+type EventsRepo interface {
+	CreateEventTx(ctx context.Context, tx sqlx.ExecerContext, event models.JobEvent) error
+}
+
 func New(opts ...Option) *Worker {
 	w := &Worker{
-		handlers: make(map[models.JobStatus]Handler),
+		handlers: make(map[handlerKey]Handler),
 	}
 	for _, opt := range opts {
 		opt(w)
 	}
+	if len(w.kinds) == 0 {
+		w.kinds = w.handledKinds()
+	}
 	w.logger = log.WithFields(log.Fields{
 		"component": "worker",
 		"id":        w.id,
@@ -43,9 +75,17 @@ func (w *Worker) Run(ctx context.Context) error {
 	for {
 		select {
 		case <-w.ticker.Tick():
+			if ctx.Err() != nil {
+				w.logger.Debug("context cancelled")
+				return ctx.Err()
+			}
+			// the tick is counted whatever the outcome, a failing database must not look like a stuck worker
 			if err := w.doWork(ctx); err != nil {
 				w.logger.Error(err)
 			}
+			if w.tickHook != nil {
+				w.tickHook()
+			}
 		case <-ctx.Done():
 			w.logger.Debug("context cancelled")
 			return ctx.Err()
@@ -56,6 +96,9 @@ func (w *Worker) Run(ctx context.Context) error {
 func (w *Worker) doWork(ctx context.Context) error {
 	w.logger.Debug("do work")
 
+	ctx, cancel := w.drainContext(ctx)
+	defer cancel()
+
 	tx, err := w.txFactory.Begin(ctx)
 	if err != nil {
 		return errors.Wrap(err, "can't begin tx")
@@ -64,18 +107,38 @@ func (w *Worker) doWork(ctx context.Context) error {
 	return tx.Do(ctx, w.doWorkTx)
 }
 
-func (w *Worker) doWorkTx(ctx context.Context, tx db.SQLTx) error {
-	const query = `
-		SELECT id, application, status
-		FROM jobs
-		WHERE status IN ('new', 'pending')
-		ORDER BY created_at
-		LIMIT 1
-		FOR UPDATE SKIP LOCKED
-	`
+// drainContext returns a context which is not cancelled together with ctx,
+// so that an in-flight job is finished on shutdown. It's cancelled when the drain timeout
+// passes after ctx is done, then the job is rolled back and picked up again later.
+func (w *Worker) drainContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if w.drainTimeout <= 0 {
+		return context.WithCancel(ctx)
+	}
 
-	var job models.Job
-	err := tx.QueryRowxContext(ctx, query).StructScan(&job)
+	drainCtx, cancel := context.WithCancel(detachedContext{parent: ctx})
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-drainCtx.Done():
+			return
+		}
+
+		w.logger.Debugf("draining in-flight job for %s", w.drainTimeout)
+		timer := time.NewTimer(w.drainTimeout)
+		defer timer.Stop()
+
+		select {
+		case <-timer.C:
+			w.logger.Warn("drain timeout exceeded, releasing job")
+			cancel()
+		case <-drainCtx.Done():
+		}
+	}()
+	return drainCtx, cancel
+}
+
+func (w *Worker) doWorkTx(ctx context.Context, tx db.SQLTx) error {
+	job, err := w.claimJob(ctx, tx)
 	switch {
 	case err == sql.ErrNoRows:
 		w.logger.Debug("no work")
@@ -84,11 +147,236 @@ func (w *Worker) doWorkTx(ctx context.Context, tx db.SQLTx) error {
 		return errors.Wrap(err, "can't get job")
 	}
 
-	handler, ok := w.handlers[job.Status]
+	if job.CorrelationID != "" {
+		ctx = envelope.ContextWithCorrelationID(ctx, job.CorrelationID)
+	}
+	logger := logging.WithContext(ctx, w.logger).WithField("job_id", job.ID.String())
+
+	handler, ok := w.handlers[handlerKey{kind: job.Kind, status: job.Status}]
 	if !ok {
-		w.logger.Errorf("no handler for status %q", job.Status)
+		logger.Errorf("no handler for %q job in %q status", job.Kind, job.Status)
+		return nil
+	}
+
+	ctx = models.ContextWithJob(ctx, job)
+	ctx, span := tracing.Tracer().Start(tracing.Extract(ctx, job.TraceContext), "job "+string(job.Kind)+" "+string(job.Status),
+		trace.WithAttributes(
+			attribute.String("job.id", job.ID.String()),
+			attribute.String("job.kind", string(job.Kind)),
+			attribute.String("job.status", string(job.Status)),
+			attribute.Int("worker.id", w.id),
+		),
+	)
+
+	// a failed handler is rolled back to the savepoint, so that the attempt is recorded in the same tx
+	if _, err := tx.ExecContext(ctx, "SAVEPOINT handle"); err != nil {
+		return errors.Wrap(err, "can't create savepoint")
+	}
+
+	start := time.Now()
+	err = handler.Handle(ctx, tx, job)
+	duration := time.Since(start)
+	tracing.End(span, err)
+	handlerDuration.WithLabelValues(string(job.Kind), string(job.Status)).Observe(duration.Seconds())
+
+	outcome := outcomeSuccess
+	if err != nil {
+		outcome = outcomeFailure
+	}
+	handlerOutcomes.WithLabelValues(string(job.Kind), string(job.Status), outcome).Inc()
+
+	if err == nil {
+		return w.recordEvent(ctx, tx, job, duration, nil)
+	}
+	if ctx.Err() != nil {
+		// the job is released as is, so it's not counted as an attempt
+		return err
+	}
+	if fErr := w.recordFailure(ctx, tx, job, err); fErr != nil {
+		return errors.Wrapf(fErr, "can't record failure of %v", err)
+	}
+	if eErr := w.recordEvent(ctx, tx, job, duration, err); eErr != nil {
+		return eErr
+	}
+	logger.Errorf("attempt %d failed: %v", job.Attempts+1, err)
+	return nil
+}
+
+// recordEvent adds the handler invocation to the job timeline. The bank status is read
+// from the application job updated by the handler, it's left empty when the handler failed.
+func (w *Worker) recordEvent(ctx context.Context, tx db.SQLTx, job models.Job, duration time.Duration, handleErr error) error {
+	if w.events == nil {
 		return nil
 	}
 
-	return handler.Handle(ctx, tx, job)
+	event := models.JobEvent{
+		JobID:      job.ID,
+		Handler:    string(job.Kind) + "." + string(job.Status),
+		DurationMS: duration.Milliseconds(),
+		WorkerID:   w.id,
+	}
+	if handleErr != nil {
+		event.Error = handleErr.Error()
+	} else if job.Kind == models.JobKindApplication {
+		const query = `SELECT payload->>'status' FROM jobs WHERE id = $1`
+		if err := tx.QueryRowxContext(ctx, query, job.ID).Scan(&event.BankStatus); err != nil {
+			return errors.Wrap(err, "can't get bank status")
+		}
+	}
+
+	if err := w.events.CreateEventTx(ctx, tx, event); err != nil {
+		return errors.Wrap(err, "can't record event")
+	}
+	return nil
+}
+
+// handledKinds returns kinds the worker has handlers for.
+func (w *Worker) handledKinds() []models.JobKind {
+	var kinds []models.JobKind
+	seen := make(map[models.JobKind]bool)
+	for key := range w.handlers {
+		if !seen[key.kind] {
+			seen[key.kind] = true
+			kinds = append(kinds, key.kind)
+		}
+	}
+	return kinds
+}
+
+// claimable returns (kind, status) pairs of the subscribed kinds the worker has handlers for, so that it never claims
+// a job it can't handle.
+func (w *Worker) claimable() (kinds, statuses pq.StringArray) {
+	subscribed := make(map[models.JobKind]bool, len(w.kinds))
+	for _, kind := range w.kinds {
+		subscribed[kind] = true
+	}
+	for key := range w.handlers {
+		if subscribed[key.kind] {
+			kinds = append(kinds, string(key.kind))
+			statuses = append(statuses, string(key.status))
+		}
+	}
+	return kinds, statuses
+}
+
+// claimJob locks a job of the subscribed kinds in a status it has a handler for to work on. Sources take turns:
+// the one with the highest priority ready job which was claimed the longest time ago goes first. When all its ready
+// jobs are locked by other workers, any ready job is claimed in priority order.
+func (w *Worker) claimJob(ctx context.Context, tx db.SQLTx) (models.Job, error) {
+	const sourceQuery = `
+		SELECT s.source
+		FROM job_sources s
+		CROSS JOIN LATERAL (
+			SELECT priority
+			FROM jobs
+			WHERE source = s.source AND (kind, status) IN (SELECT * FROM unnest($1::text[], $2::text[]))
+				AND status IN ('new', 'pending') AND run_at <= now()
+			ORDER BY priority DESC
+			LIMIT 1
+		) ready
+		LEFT JOIN LATERAL (
+			SELECT claimed_at
+			FROM jobs
+			WHERE source = s.source AND claimed_at IS NOT NULL
+			ORDER BY claimed_at DESC
+			LIMIT 1
+		) last ON true
+		ORDER BY ready.priority DESC, last.claimed_at NULLS FIRST
+		LIMIT 1
+	`
+	const sourceJobQuery = `
+		SELECT id, kind, payload, application_id, bank, status, priority, source, trace_context, correlation_id, attempts
+		FROM jobs
+		WHERE source = $3 AND (kind, status) IN (SELECT * FROM unnest($1::text[], $2::text[]))
+			AND status IN ('new', 'pending') AND run_at <= now()
+		ORDER BY priority DESC, run_at, created_at
+		LIMIT 1
+		FOR UPDATE SKIP LOCKED
+	`
+	const anyJobQuery = `
+		SELECT id, kind, payload, application_id, bank, status, priority, source, trace_context, correlation_id, attempts
+		FROM jobs
+		WHERE (kind, status) IN (SELECT * FROM unnest($1::text[], $2::text[]))
+			AND status IN ('new', 'pending') AND run_at <= now()
+		ORDER BY priority DESC, run_at, created_at
+		LIMIT 1
+		FOR UPDATE SKIP LOCKED
+	`
+	const claimQuery = `UPDATE jobs SET claimed_at = clock_timestamp() WHERE id = $1`
+
+	kinds, statuses := w.claimable()
+
+	var source string
+	err := tx.QueryRowxContext(ctx, sourceQuery, kinds, statuses).Scan(&source)
+	if err != nil {
+		return models.Job{}, err
+	}
+
+	var job models.Job
+	err = tx.QueryRowxContext(ctx, sourceJobQuery, kinds, statuses, source).StructScan(&job)
+	if err == sql.ErrNoRows {
+		err = tx.QueryRowxContext(ctx, anyJobQuery, kinds, statuses).StructScan(&job)
+	}
+	if err != nil {
+		return models.Job{}, err
+	}
+
+	if _, err := tx.ExecContext(ctx, claimQuery, job.ID); err != nil {
+		return models.Job{}, errors.Wrap(err, "can't claim job")
+	}
+	return job, nil
+}
+
+// recordFailure counts a failed attempt and postpones the job with an exponential backoff.
+// The job is moved to a 'failed' status when it runs out of attempts.
+func (w *Worker) recordFailure(ctx context.Context, tx db.SQLTx, job models.Job, handleErr error) error {
+	const query = `
+		UPDATE jobs
+		SET attempts = attempts + 1,
+			last_error = $2,
+			status = CASE WHEN $3 > 0 AND attempts + 1 >= $3 THEN 'failed' ELSE status END,
+			run_at = now() + $4 * interval '1 millisecond',
+			updated_at = now()
+		WHERE id = $1
+	`
+
+	if _, err := tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT handle"); err != nil {
+		return errors.Wrap(err, "can't rollback to savepoint")
+	}
+
+	backoff := retryBackoff(job.Attempts + 1)
+	_, err := tx.ExecContext(ctx, query, job.ID, handleErr.Error(), w.maxAttempts, backoff.Milliseconds())
+	return err
+}
+
+func retryBackoff(attempt int) time.Duration {
+	backoff := minRetryBackoff
+	for i := 1; i < attempt && backoff < maxRetryBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > maxRetryBackoff {
+		backoff = maxRetryBackoff
+	}
+	return backoff
+}
+
+// detachedContext keeps values of the parent, but not its cancellation and deadline.
+type detachedContext struct {
+	parent context.Context
+}
+
+func (detachedContext) Deadline() (time.Time, bool) {
+	return time.Time{}, false
+}
+
+func (detachedContext) Done() <-chan struct{} {
+	return nil
+}
+
+func (detachedContext) Err() error {
+	return nil
+}
+
+func (c detachedContext) Value(key interface{}) interface{} {
+	return c.parent.Value(key)
 }
@@ -8,11 +8,18 @@ import (
 	"github.com/ivanovaleksey/lendo/pkg/test"
 	"github.com/ivanovaleksey/lendo/registry/config"
 	"github.com/ivanovaleksey/lendo/registry/models"
+	"github.com/ivanovaleksey/lendo/registry/poller/handlers"
+	mockHandlers "github.com/ivanovaleksey/lendo/registry/poller/handlers/mocks"
 	"github.com/ivanovaleksey/lendo/registry/poller/worker/mocks"
+	"github.com/ivanovaleksey/lendo/registry/repos/events"
+	"github.com/ivanovaleksey/lendo/registry/repos/jobs"
+	"github.com/pkg/errors"
 	uuid "github.com/satori/go.uuid"
 	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
+	"sync/atomic"
 	"testing"
 	"time"
 )
@@ -28,7 +35,7 @@ func TestWorker_Run(t *testing.T) {
 		newJob := jobs[0]
 		fx.insertJobs(jobs)
 
-		fx.newJobHandler.On("Handle", fx.ctx, mock.AnythingOfType("db.tx"), newJob).Return(nil)
+		fx.newJobHandler.On("Handle", jobCtx(newJob), mock.AnythingOfType("db.tx"), newJob).Return(nil)
 
 		time.AfterFunc(100*time.Millisecond, fx.cancel)
 		err := fx.worker.Run(fx.ctx)
@@ -44,7 +51,7 @@ func TestWorker_Run(t *testing.T) {
 		pendingJob := jobs[1]
 		fx.insertJobs(jobs[1:])
 
-		fx.pendingJobHandler.On("Handle", fx.ctx, mock.AnythingOfType("db.tx"), pendingJob).Return(nil)
+		fx.pendingJobHandler.On("Handle", jobCtx(pendingJob), mock.AnythingOfType("db.tx"), pendingJob).Return(nil)
 
 		time.AfterFunc(100*time.Millisecond, fx.cancel)
 		err := fx.worker.Run(fx.ctx)
@@ -53,6 +60,255 @@ func TestWorker_Run(t *testing.T) {
 	})
 }
 
+func TestWorker_TickHook(t *testing.T) {
+	t.Run("should count ticks when work fails", func(t *testing.T) {
+		var ticks int32
+		w := New(
+			WithTicker(newFixedTicker(2)),
+			WithTxFactory(failingTxFactory{}),
+			WithTickHook(func() { atomic.AddInt32(&ticks, 1) }),
+		)
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		time.AfterFunc(100*time.Millisecond, cancel)
+		err := w.Run(ctx)
+
+		require.Equal(t, context.Canceled, err)
+		assert.EqualValues(t, 2, atomic.LoadInt32(&ticks))
+	})
+}
+
+type failingTxFactory struct{}
+
+func (failingTxFactory) Begin(context.Context) (db.Tx, error) {
+	return nil, errors.New("connection refused")
+}
+
+func TestWorker_Failure(t *testing.T) {
+	t.Run("should record failed attempt", func(t *testing.T) {
+		fx := newFixture(t, WithMaxAttempts(3))
+		defer fx.Finish()
+
+		newJob := fx.buildJobs()[0]
+		fx.insertJob(newJob)
+
+		errMsg := gofakeit.Sentence(3)
+		fx.newJobHandler.On("Handle", jobCtx(newJob), mock.AnythingOfType("db.tx"), newJob).Return(errors.New(errMsg)).Once()
+
+		time.AfterFunc(100*time.Millisecond, fx.cancel)
+		err := fx.worker.Run(fx.ctx)
+
+		require.Equal(t, context.Canceled, err)
+		job := fx.getJob(newJob.ID)
+		assert.Equal(t, models.JobStatusNew, job.Status)
+		assert.Equal(t, 1, job.Attempts)
+		assert.Equal(t, errMsg, job.LastError)
+
+		events := fx.getEvents(newJob.ID)
+		require.Len(t, events, 1)
+		assert.Equal(t, errMsg, events[0].Error)
+		assert.Empty(t, events[0].BankStatus)
+	})
+
+	t.Run("should fail job when attempts are exhausted", func(t *testing.T) {
+		fx := newFixture(t, WithMaxAttempts(1))
+		defer fx.Finish()
+
+		newJob := fx.buildJobs()[0]
+		fx.insertJob(newJob)
+
+		fx.newJobHandler.On("Handle", jobCtx(newJob), mock.AnythingOfType("db.tx"), newJob).Return(errors.New(gofakeit.Sentence(3))).Once()
+
+		time.AfterFunc(100*time.Millisecond, fx.cancel)
+		err := fx.worker.Run(fx.ctx)
+
+		require.Equal(t, context.Canceled, err)
+		job := fx.getJob(newJob.ID)
+		assert.Equal(t, models.JobStatusFailed, job.Status)
+		assert.Equal(t, 1, job.Attempts)
+	})
+}
+
+func TestWorker_Events(t *testing.T) {
+	t.Run("should record handler invocation", func(t *testing.T) {
+		fx := newFixture(t, WithID(3))
+		defer fx.Finish()
+
+		pendingJob := fx.buildJobs()[1]
+		fx.insertJob(pendingJob)
+
+		fx.pendingJobHandler.On("Handle", jobCtx(pendingJob), mock.AnythingOfType("db.tx"), pendingJob).Return(nil)
+
+		time.AfterFunc(100*time.Millisecond, fx.cancel)
+		err := fx.worker.Run(fx.ctx)
+
+		require.Equal(t, context.Canceled, err)
+		events := fx.getEvents(pendingJob.ID)
+		require.NotEmpty(t, events)
+		application, err := pendingJob.Application()
+		require.NoError(t, err)
+		assert.Equal(t, "application.pending", events[0].Handler)
+		assert.Equal(t, application.Status, events[0].BankStatus)
+		assert.Empty(t, events[0].Error)
+		assert.Equal(t, 3, events[0].WorkerID)
+	})
+}
+
+func TestWorker_claimJob(t *testing.T) {
+	t.Run("should claim higher priority first", func(t *testing.T) {
+		fx := newFixture(t)
+		defer fx.Finish()
+
+		low, high := fx.buildJobs()[0], fx.buildJobs()[0]
+		high.Priority = 10
+		fx.insertJobs([]models.Job{low, high})
+
+		assert.Equal(t, high.ID, fx.claim().ID)
+	})
+
+	t.Run("sources should take turns", func(t *testing.T) {
+		fx := newFixture(t)
+		defer fx.Finish()
+
+		jobs := []models.Job{fx.buildJobs()[0], fx.buildJobs()[0], fx.buildJobs()[0]}
+		jobs[0].Source, jobs[1].Source, jobs[2].Source = "bulk", "bulk", "web"
+		fx.insertJobs(jobs)
+
+		first, second, third := fx.claim(), fx.claim(), fx.claim()
+
+		assert.NotEqual(t, first.Source, second.Source)
+		assert.ElementsMatch(t, []uuid.UUID{jobs[0].ID, jobs[1].ID, jobs[2].ID}, []uuid.UUID{first.ID, second.ID, third.ID})
+	})
+
+	t.Run("should claim only handled kinds", func(t *testing.T) {
+		fx := newFixture(t)
+		defer fx.Finish()
+
+		other, application := fx.buildJobs()[0], fx.buildJobs()[0]
+		other.Kind = "report"
+		other.Priority = 10
+		fx.insertJobs([]models.Job{other, application})
+
+		assert.Equal(t, application.ID, fx.claim().ID)
+	})
+
+	t.Run("should claim only handled statuses", func(t *testing.T) {
+		fx := newFixture(t)
+		defer fx.Finish()
+		fx.worker = New(
+			WithTxFactory(db.NewTxFactory(fx.db)),
+			WithHandler(models.JobKindApplication, models.JobStatusNew, fx.newJobHandler),
+		)
+
+		jobs := fx.buildJobs()
+		newJob, pendingJob := jobs[0], jobs[1]
+		pendingJob.Priority = 10
+		fx.insertJobs([]models.Job{newJob, pendingJob})
+
+		assert.Equal(t, newJob.ID, fx.claim().ID)
+	})
+}
+
+func TestRetryBackoff(t *testing.T) {
+	assert.Equal(t, 5*time.Second, retryBackoff(1))
+	assert.Equal(t, 10*time.Second, retryBackoff(2))
+	assert.Equal(t, 40*time.Second, retryBackoff(4))
+	assert.Equal(t, 10*time.Minute, retryBackoff(20))
+}
+
+func TestWorker_Drain(t *testing.T) {
+	t.Run("should finish in-flight job on shutdown", func(t *testing.T) {
+		fx := newFixture(t, WithDrainTimeout(time.Second))
+		defer fx.Finish()
+
+		newJob := fx.buildJobs()[0]
+		fx.insertJob(newJob)
+
+		bank := newBlockingBank()
+		fx.useBank(bank)
+
+		done := make(chan error, 1)
+		go func() {
+			done <- fx.worker.Run(fx.ctx)
+		}()
+
+		<-bank.started
+		fx.cancel()
+		time.AfterFunc(50*time.Millisecond, bank.Release)
+
+		require.Equal(t, context.Canceled, <-done)
+		assert.Equal(t, models.JobStatusPending, fx.getJob(newJob.ID).Status)
+	})
+
+	t.Run("should release job after drain timeout", func(t *testing.T) {
+		fx := newFixture(t, WithDrainTimeout(50*time.Millisecond))
+		defer fx.Finish()
+
+		newJob := fx.buildJobs()[0]
+		fx.insertJob(newJob)
+
+		bank := newBlockingBank()
+		fx.useBank(bank)
+
+		done := make(chan error, 1)
+		go func() {
+			done <- fx.worker.Run(fx.ctx)
+		}()
+
+		<-bank.started
+		fx.cancel()
+
+		require.Equal(t, context.Canceled, <-done)
+		assert.Equal(t, models.JobStatusNew, fx.getJob(newJob.ID).Status)
+	})
+
+	t.Run("should not claim jobs after shutdown", func(t *testing.T) {
+		fx := newFixture(t, WithDrainTimeout(time.Second))
+		defer fx.Finish()
+
+		fx.insertJobs(fx.buildJobs())
+		fx.cancel()
+
+		err := fx.worker.Run(fx.ctx)
+
+		require.Equal(t, context.Canceled, err)
+	})
+}
+
+func TestWorker_drainContext(t *testing.T) {
+	type key struct{}
+
+	t.Run("should outlive parent for drain timeout", func(t *testing.T) {
+		w := New(WithDrainTimeout(50 * time.Millisecond))
+
+		parent, cancel := context.WithCancel(context.WithValue(context.Background(), key{}, "value"))
+		ctx, release := w.drainContext(parent)
+		defer release()
+
+		cancel()
+		time.Sleep(10 * time.Millisecond)
+
+		assert.NoError(t, ctx.Err())
+		assert.Equal(t, "value", ctx.Value(key{}))
+		require.Eventually(t, func() bool {
+			return ctx.Err() == context.Canceled
+		}, time.Second, 5*time.Millisecond)
+	})
+
+	t.Run("should be cancelled with parent without drain timeout", func(t *testing.T) {
+		w := New()
+
+		parent, cancel := context.WithCancel(context.Background())
+		ctx, release := w.drainContext(parent)
+		defer release()
+
+		cancel()
+
+		assert.Equal(t, context.Canceled, ctx.Err())
+	})
+}
+
 type fixture struct {
 	t      *testing.T
 	ctx    context.Context
@@ -61,6 +317,7 @@ type fixture struct {
 
 	newJobHandler     *mocks.Handler
 	pendingJobHandler *mocks.Handler
+	events            *eventsRepo.Repo
 
 	worker *Worker
 }
@@ -78,13 +335,15 @@ func newFixture(t *testing.T, opts ...Option) *fixture {
 		newJobHandler:     &mocks.Handler{},
 		pendingJobHandler: &mocks.Handler{},
 	}
+	fx.events = eventsRepo.New(fx.db)
 	fx.ctx, fx.cancel = context.WithCancel(context.Background())
 
 	baseOpts := []Option{
 		WithTicker(newFixedTicker(1)),
 		WithTxFactory(db.NewTxFactory(fx.db)),
-		WithHandler(models.JobStatusNew, fx.newJobHandler),
-		WithHandler(models.JobStatusPending, fx.pendingJobHandler),
+		WithHandler(models.JobKindApplication, models.JobStatusNew, fx.newJobHandler),
+		WithHandler(models.JobKindApplication, models.JobStatusPending, fx.pendingJobHandler),
+		WithEventsRepo(fx.events),
 	}
 	opts = append(baseOpts, opts...)
 	fx.worker = New(opts...)
@@ -98,43 +357,23 @@ func (fx *fixture) Finish() {
 }
 
 func (fx *fixture) buildJobs() []models.Job {
-	jobs := []models.Job{
-		{
-			Application: commonModels.Application{
-				NewApplication: commonModels.NewApplication{
-					FirstName: gofakeit.FirstName(),
-					LastName:  gofakeit.LastName(),
-				},
-				ID:     uuid.NewV4(),
-				Status: commonModels.ApplicationStatus(gofakeit.Word()),
-			},
-			Status: models.JobStatusNew,
-			ID:     uuid.NewV4(),
-		},
-		{
-			Application: commonModels.Application{
-				NewApplication: commonModels.NewApplication{
-					FirstName: gofakeit.FirstName(),
-					LastName:  gofakeit.LastName(),
-				},
-				ID:     uuid.NewV4(),
-				Status: commonModels.ApplicationStatus(gofakeit.Word()),
+	statuses := []models.JobStatus{models.JobStatusNew, models.JobStatusPending, models.JobStatusDone}
+	jobs := make([]models.Job, 0, len(statuses))
+	for _, status := range statuses {
+		job, err := models.NewApplicationJob(commonModels.Application{
+			NewApplication: commonModels.NewApplication{
+				FirstName: gofakeit.FirstName(),
+				LastName:  gofakeit.LastName(),
 			},
-			Status: models.JobStatusPending,
 			ID:     uuid.NewV4(),
-		},
-		{
-			Application: commonModels.Application{
-				NewApplication: commonModels.NewApplication{
-					FirstName: gofakeit.FirstName(),
-					LastName:  gofakeit.LastName(),
-				},
-				ID:     uuid.NewV4(),
-				Status: commonModels.ApplicationStatus(gofakeit.Word()),
-			},
-			Status: models.JobStatusDone,
-			ID:     uuid.NewV4(),
-		},
+			Status: commonModels.ApplicationStatus(gofakeit.Word()),
+		})
+		require.NoError(fx.t, err)
+		job.ID = uuid.NewV4()
+		job.Status = status
+		job.Source = models.DefaultJobSource
+		job.Bank = models.DefaultBank
+		jobs = append(jobs, job)
 	}
 	return jobs
 }
@@ -147,17 +386,90 @@ func (fx *fixture) insertJobs(jobs []models.Job) {
 
 func (fx *fixture) insertJob(job models.Job) {
 	const q = `
-		INSERT INTO jobs (id, application, status)
-		VALUES ($1, $2, $3)
+		WITH source AS (
+			INSERT INTO job_sources (source)
+			VALUES ($8)
+			ON CONFLICT DO NOTHING
+		)
+		INSERT INTO jobs (id, kind, payload, application_id, bank, status, priority, source)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
 		RETURNING id
 	`
-	_, err := fx.db.ExecContext(fx.ctx, q, job.ID, job.Application, job.Status)
+	_, err := fx.db.ExecContext(fx.ctx, q, job.ID, job.Kind, job.Payload, job.ApplicationID, job.Bank, job.Status, job.Priority, job.Source)
+	require.NoError(fx.t, err)
+}
+
+// claim claims a job and marks it done, so that it's not claimed again.
+func (fx *fixture) claim() (job models.Job) {
+	tx, err := db.NewTxFactory(fx.db).Begin(fx.ctx)
 	require.NoError(fx.t, err)
+
+	err = tx.Do(fx.ctx, func(ctx context.Context, tx db.SQLTx) error {
+		job, err = fx.worker.claimJob(ctx, tx)
+		if err != nil {
+			return err
+		}
+		_, err = tx.ExecContext(ctx, "UPDATE jobs SET status = 'done' WHERE id = $1", job.ID)
+		return err
+	})
+	require.NoError(fx.t, err)
+	return
 }
 
 func (fx *fixture) getJob(id uuid.UUID) (job models.Job) {
-	const q = `SELECT id, application, status FROM jobs WHERE id = $1`
-	err := fx.db.GetContext(fx.ctx, &job, q, id)
+	const q = `SELECT id, kind, payload, status, attempts, last_error FROM jobs WHERE id = $1`
+	err := fx.db.GetContext(context.Background(), &job, q, id)
 	require.NoError(fx.t, err)
 	return
 }
+
+func (fx *fixture) getEvents(jobID uuid.UUID) []models.JobEvent {
+	events, err := fx.events.GetByJobID(context.Background(), jobID)
+	require.NoError(fx.t, err)
+	return events
+}
+
+func (fx *fixture) useBank(bank handlers.Bank) {
+	notifier := &mockHandlers.Notifier{}
+	notifier.On("ApplicationStatusChanged", mock.Anything, mock.Anything).Return(nil).Maybe()
+
+	fx.worker.handlers[handlerKey{kind: models.JobKindApplication, status: models.JobStatusNew}] = handlers.NewNewJobHandler(bank, jobsRepo.New(fx.db), notifier)
+}
+
+// blockingBank blocks in CreateApplication until it's released or the context is done.
+type blockingBank struct {
+	started chan struct{}
+	release chan struct{}
+}
+
+func newBlockingBank() *blockingBank {
+	return &blockingBank{
+		started: make(chan struct{}),
+		release: make(chan struct{}),
+	}
+}
+
+func (b *blockingBank) Release() {
+	close(b.release)
+}
+
+func (b *blockingBank) CreateApplication(ctx context.Context, application commonModels.Application) (commonModels.ApplicationStatus, error) {
+	close(b.started)
+	select {
+	case <-b.release:
+		return commonModels.ApplicationStatusPending, nil
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+}
+
+func (b *blockingBank) GetApplicationStatus(ctx context.Context, id uuid.UUID) (commonModels.ApplicationStatus, error) {
+	return "", errors.New("not implemented")
+}
+
+func jobCtx(job models.Job) interface{} {
+	return mock.MatchedBy(func(ctx context.Context) bool {
+		got, ok := models.JobFromContext(ctx)
+		return ok && got.ID == job.ID
+	})
+}
@@ -1,3 +1,4 @@
 //go:generate mockery --dir .. --output . --name PubClient --filename pub_client.mock.go
+//go:generate mockery --dir .. --output . --name Repo --filename repo.mock.go
 
 package mocks
@@ -2,21 +2,28 @@ package applicationsPubSub
 
 import (
 	"context"
-	"encoding/json"
+	"github.com/ivanovaleksey/lendo/pkg/bus"
+	"github.com/ivanovaleksey/lendo/pkg/envelope"
 	"github.com/ivanovaleksey/lendo/pkg/models"
+	"github.com/ivanovaleksey/lendo/pkg/pb"
+	"github.com/ivanovaleksey/lendo/pkg/tracing"
+	"net/http"
 )
 
 type Pub struct {
-	client PubClient
+	client  PubClient
+	encoder *envelope.Encoder
 }
 
 type PubClient interface {
-	Publish(subj string, data []byte) error
+	PublishMsg(msg *bus.Msg) error
 }
 
-func NewPub(client PubClient) *Pub {
+// NewPub returns a publisher of events in the JSON format unless another format is given.
+func NewPub(client PubClient, opts ...envelope.EncoderOption) *Pub {
 	pub := &Pub{
-		client: client,
+		client:  client,
+		encoder: envelope.NewEncoder("registry", opts...),
 	}
 	return pub
 }
@@ -24,9 +31,24 @@ func NewPub(client PubClient) *Pub {
 func (p *Pub) ApplicationStatusChanged(ctx context.Context, change models.StatusChange) error {
 	const subject = "applications.changed"
 
-	data, err := json.Marshal(change)
+	header := make(http.Header)
+	data, err := p.encoder.Encode(ctx, header, models.EventApplicationStatusChanged, models.EventApplicationStatusChangedVersion, pb.FromStatusChange(change))
 	if err != nil {
 		return err
 	}
-	return p.client.Publish(subject, data)
+
+	return p.publish(ctx, subject, header, data)
+}
+
+// publish sends data carrying the trace context of ctx in message headers.
+func (p *Pub) publish(ctx context.Context, subject string, header http.Header, data []byte) error {
+	msg := &bus.Msg{
+		Subject: subject,
+		Data:    data,
+		Header:  header,
+	}
+	_, span := tracing.StartPublish(ctx, msg.Subject, msg.Header)
+	err := p.client.PublishMsg(msg)
+	tracing.End(span, err)
+	return err
 }
@@ -5,10 +5,16 @@ import (
 	"encoding/json"
 	"errors"
 	"github.com/brianvoe/gofakeit"
+	"github.com/ivanovaleksey/lendo/pkg/bus"
+	"github.com/ivanovaleksey/lendo/pkg/envelope"
 	"github.com/ivanovaleksey/lendo/pkg/models"
+	"github.com/ivanovaleksey/lendo/pkg/pb"
 	"github.com/ivanovaleksey/lendo/registry/pubsub/applications/mocks"
 	uuid "github.com/satori/go.uuid"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
 	"testing"
 )
 
@@ -17,25 +23,68 @@ func TestPub_ApplicationStatusChanged(t *testing.T) {
 		ID:     uuid.NewV4(),
 		Status: models.ApplicationStatus(gofakeit.Word()),
 	}
-	data, _ := json.Marshal(change)
 
 	t.Run("without error", func(t *testing.T) {
 		fx := newFixture(t)
 		defer fx.Finish()
 
-		fx.nats.On("Publish", "applications.changed", data).Return(nil)
+		fx.nats.On("PublishMsg", message("applications.changed", change)).Return(nil)
 
 		err := fx.pub.ApplicationStatusChanged(fx.ctx, change)
 
 		assert.NoError(t, err)
 	})
 
+	t.Run("should wrap change into envelope", func(t *testing.T) {
+		fx := newFixture(t)
+		defer fx.Finish()
+
+		var env envelope.Envelope
+		fx.nats.On("PublishMsg", message("applications.changed", change)).
+			Run(func(args mock.Arguments) {
+				_ = json.Unmarshal(args.Get(0).(*bus.Msg).Data, &env)
+			}).
+			Return(nil)
+
+		err := fx.pub.ApplicationStatusChanged(fx.ctx, change)
+
+		assert.NoError(t, err)
+		assert.Equal(t, models.EventApplicationStatusChanged, env.Type)
+		assert.Equal(t, models.EventApplicationStatusChangedVersion, env.DataVersion)
+		assert.Equal(t, "registry", env.Source)
+		assert.NotEmpty(t, env.ID)
+	})
+
+	t.Run("should encode change in protobuf format", func(t *testing.T) {
+		fx := newFixture(t, envelope.WithFormat(envelope.FormatProtobuf))
+		defer fx.Finish()
+
+		var msg *bus.Msg
+		fx.nats.On("PublishMsg", mock.Anything).
+			Run(func(args mock.Arguments) {
+				msg = args.Get(0).(*bus.Msg)
+			}).
+			Return(nil)
+
+		err := fx.pub.ApplicationStatusChanged(fx.ctx, change)
+
+		require.NoError(t, err)
+		assert.Equal(t, "applications.changed", msg.Subject)
+		assert.Equal(t, envelope.ProtobufContentType, msg.Header.Get("Content-Type"))
+		assert.Equal(t, models.EventApplicationStatusChanged, msg.Header.Get("ce-type"))
+		var data pb.StatusChange
+		require.NoError(t, proto.Unmarshal(msg.Data, &data))
+		got, err := data.ToModel()
+		require.NoError(t, err)
+		assert.Equal(t, change, got)
+	})
+
 	t.Run("with error", func(t *testing.T) {
 		fx := newFixture(t)
 		defer fx.Finish()
 
 		clientErr := errors.New(gofakeit.Sentence(3))
-		fx.nats.On("Publish", "applications.changed", data).Return(clientErr)
+		fx.nats.On("PublishMsg", message("applications.changed", change)).Return(clientErr)
 
 		err := fx.pub.ApplicationStatusChanged(fx.ctx, change)
 
@@ -51,16 +100,33 @@ type fixture struct {
 	pub *Pub
 }
 
-func newFixture(t *testing.T) *fixture {
+func newFixture(t *testing.T, opts ...envelope.EncoderOption) *fixture {
 	fx := &fixture{
 		t:    t,
 		ctx:  context.Background(),
 		nats: &mocks.PubClient{},
 	}
-	fx.pub = NewPub(fx.nats)
+	fx.pub = NewPub(fx.nats, opts...)
 	return fx
 }
 
 func (fx *fixture) Finish() {
 	fx.nats.AssertExpectations(fx.t)
 }
+
+// message matches an enveloped message of the subject carrying the data.
+func message(subject string, data interface{}) interface{} {
+	expected, _ := json.Marshal(data)
+	return mock.MatchedBy(func(msg *bus.Msg) bool {
+		var env envelope.Envelope
+		if err := json.Unmarshal(msg.Data, &env); err != nil {
+			return false
+		}
+		var got, want interface{}
+		_ = json.Unmarshal(env.Data, &got)
+		_ = json.Unmarshal(expected, &want)
+		return msg.Subject == subject &&
+			msg.Header.Get("Content-Type") == envelope.ContentType &&
+			assert.ObjectsAreEqual(want, got)
+	})
+}
@@ -2,53 +2,88 @@ package applicationsPubSub
 
 import (
 	"context"
-	"encoding/json"
+	"github.com/ivanovaleksey/lendo/pkg/bus"
+	"github.com/ivanovaleksey/lendo/pkg/envelope"
+	"github.com/ivanovaleksey/lendo/pkg/logging"
 	commonModels "github.com/ivanovaleksey/lendo/pkg/models"
+	"github.com/ivanovaleksey/lendo/pkg/pb"
+	"github.com/ivanovaleksey/lendo/pkg/tracing"
 	"github.com/ivanovaleksey/lendo/registry/models"
-	"github.com/nats-io/nats.go"
 	"github.com/pkg/errors"
 	uuid "github.com/satori/go.uuid"
 	log "github.com/sirupsen/logrus"
 )
 
 type NewApplicationHandler struct {
-	repo   Repo
-	logger log.FieldLogger
+	repo       Repo
+	decoder    *envelope.Decoder
+	bank       string
+	priorities map[string]int
+	logger     log.FieldLogger
 }
 
 type Repo interface {
-	CreateJob(ctx context.Context, job models.Job) (uuid.UUID, error)
+	CreateJob(ctx context.Context, job models.Job) (uuid.UUID, bool, error)
 }
 
-func NewNewApplicationHandler(repo Repo) *NewApplicationHandler {
+// NewNewApplicationHandler creates jobs submitting applications to the bank, prioritized by the given
+// source priorities unless a priority is passed in the message metadata. A redelivered application
+// doesn't get a second job.
+func NewNewApplicationHandler(repo Repo, bank string, priorities map[string]int) *NewApplicationHandler {
 	h := &NewApplicationHandler{
-		repo:   repo,
-		logger: log.WithField("handler", "applications-new"),
+		repo:       repo,
+		decoder:    envelope.NewDecoder(commonModels.EventApplicationNew, commonModels.EventApplicationNewVersion),
+		bank:       bank,
+		priorities: priorities,
+		logger:     log.WithField("handler", "applications-new"),
 	}
 	return h
 }
 
-func (h *NewApplicationHandler) Handle(msg *nats.Msg) {
-	h.logger.Debugf("new application %s", string(msg.Data))
+func (h *NewApplicationHandler) Handle(ctx context.Context, msg *bus.Msg) error {
+	var data pb.Application
+	env, err := h.decoder.Decode(msg.Header, msg.Data, &data)
+	if err != nil {
+		return errors.Wrap(err, "can't parse application")
+	}
+	application, err := data.ToModel()
+	if err != nil {
+		return errors.Wrap(err, "can't parse application")
+	}
+	logger := logging.WithContext(envelope.ContextWithCorrelationID(ctx, env.CorrelationID), h.logger).
+		WithFields(logging.ApplicationFields(application))
+	logger.Debug("new application")
 
-	var application commonModels.Application
-	err := json.Unmarshal(msg.Data, &application)
+	md, err := commonModels.ExtractMetadata(msg.Header)
 	if err != nil {
-		err = errors.Wrap(err, "can't parse application")
-		h.logger.Error(err)
-		return
+		return errors.Wrap(err, "can't parse metadata")
+	}
+	if md.Source == "" {
+		md.Source = models.DefaultJobSource
+	}
+	priority := h.priorities[md.Source]
+	if md.Priority != nil {
+		priority = *md.Priority
 	}
 
-	job := models.Job{
-		Status:      models.JobStatusNew,
-		Application: application,
+	job, err := models.NewApplicationJob(application)
+	if err != nil {
+		return errors.Wrap(err, "can't encode application")
 	}
-	id, err := h.repo.CreateJob(context.Background(), job)
+	job.Priority = priority
+	job.Source = md.Source
+	job.Bank = h.bank
+	job.TraceContext = tracing.Inject(ctx)
+	job.CorrelationID = env.CorrelationID
+	id, created, err := h.repo.CreateJob(ctx, job)
 	if err != nil {
-		err = errors.Wrap(err, "can't create job")
-		h.logger.Error(err)
-		return
+		return errors.Wrap(err, "can't create job")
+	}
+	if !created {
+		logger.Infof("application already has job %s", id.String())
+		return nil
 	}
 
-	h.logger.Debugf("job created %s", id.String())
+	logger.Debugf("job created %s", id.String())
+	return nil
 }
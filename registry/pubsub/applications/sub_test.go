@@ -1,8 +1,182 @@
 package applicationsPubSub
 
-import "testing"
+import (
+	"context"
+	"encoding/json"
+	"github.com/brianvoe/gofakeit"
+	"github.com/ivanovaleksey/lendo/pkg/bus"
+	"github.com/ivanovaleksey/lendo/pkg/envelope"
+	commonModels "github.com/ivanovaleksey/lendo/pkg/models"
+	"github.com/ivanovaleksey/lendo/pkg/pb"
+	"github.com/ivanovaleksey/lendo/registry/models"
+	"github.com/ivanovaleksey/lendo/registry/pubsub/applications/mocks"
+	"github.com/pkg/errors"
+	uuid "github.com/satori/go.uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"testing"
+)
 
 func TestNewApplicationHandler_Handle(t *testing.T) {
-	// todo: implement
-	t.Skip("not implement")
+	application := commonModels.Application{
+		NewApplication: commonModels.NewApplication{
+			FirstName: gofakeit.FirstName(),
+			LastName:  gofakeit.LastName(),
+		},
+		ID:     uuid.NewV4(),
+		Status: commonModels.ApplicationStatusNew,
+	}
+	data, _ := json.Marshal(application)
+
+	t.Run("with invalid message", func(t *testing.T) {
+		fx := newSubFixture(t)
+		defer fx.Finish()
+
+		err := fx.handler.Handle(fx.ctx, &bus.Msg{Data: []byte("{")})
+
+		assert.Error(t, err)
+	})
+
+	t.Run("without metadata should use default source", func(t *testing.T) {
+		fx := newSubFixture(t)
+		defer fx.Finish()
+
+		fx.repo.On("CreateJob", fx.ctx, job(models.DefaultJobSource, 0)).Return(uuid.NewV4(), true, nil)
+
+		err := fx.handler.Handle(fx.ctx, &bus.Msg{Data: data})
+
+		assert.NoError(t, err)
+	})
+
+	t.Run("should decode enveloped application", func(t *testing.T) {
+		fx := newSubFixture(t)
+		defer fx.Finish()
+
+		fx.repo.On("CreateJob", fx.ctx, mock.MatchedBy(func(job models.Job) bool {
+			return job.ApplicationID != nil && *job.ApplicationID == application.ID
+		})).Return(uuid.NewV4(), true, nil)
+
+		header := make(http.Header)
+		msg, err := envelope.NewEncoder("api").Encode(fx.ctx, header, commonModels.EventApplicationNew, commonModels.EventApplicationNewVersion, pb.FromApplication(application))
+		require.NoError(t, err)
+		err = fx.handler.Handle(fx.ctx, &bus.Msg{Data: msg, Header: header})
+
+		assert.NoError(t, err)
+	})
+
+	t.Run("should keep correlation ID", func(t *testing.T) {
+		fx := newSubFixture(t)
+		defer fx.Finish()
+
+		fx.repo.On("CreateJob", fx.ctx, mock.MatchedBy(func(job models.Job) bool {
+			return job.CorrelationID == "correlation"
+		})).Return(uuid.NewV4(), true, nil)
+
+		header := make(http.Header)
+		ctx := envelope.ContextWithCorrelationID(fx.ctx, "correlation")
+		msg, err := envelope.NewEncoder("api").Encode(ctx, header, commonModels.EventApplicationNew, commonModels.EventApplicationNewVersion, pb.FromApplication(application))
+		require.NoError(t, err)
+		err = fx.handler.Handle(fx.ctx, &bus.Msg{Data: msg, Header: header})
+
+		assert.NoError(t, err)
+	})
+
+	t.Run("should decode application in protobuf format", func(t *testing.T) {
+		fx := newSubFixture(t)
+		defer fx.Finish()
+
+		fx.repo.On("CreateJob", fx.ctx, mock.MatchedBy(func(job models.Job) bool {
+			return job.ApplicationID != nil && *job.ApplicationID == application.ID
+		})).Return(uuid.NewV4(), true, nil)
+
+		header := make(http.Header)
+		encoder := envelope.NewEncoder("api", envelope.WithFormat(envelope.FormatProtobuf))
+		msg, err := encoder.Encode(fx.ctx, header, commonModels.EventApplicationNew, commonModels.EventApplicationNewVersion, pb.FromApplication(application))
+		require.NoError(t, err)
+		err = fx.handler.Handle(fx.ctx, &bus.Msg{Data: msg, Header: header})
+
+		assert.NoError(t, err)
+	})
+
+	t.Run("should use source priority", func(t *testing.T) {
+		fx := newSubFixture(t)
+		defer fx.Finish()
+
+		fx.repo.On("CreateJob", fx.ctx, job("bulk", -10)).Return(uuid.NewV4(), true, nil)
+
+		msg := &bus.Msg{Data: data, Header: http.Header{}}
+		msg.Header.Set(commonModels.SourceHeader, "bulk")
+		err := fx.handler.Handle(fx.ctx, msg)
+
+		assert.NoError(t, err)
+	})
+
+	t.Run("should prefer priority from metadata", func(t *testing.T) {
+		fx := newSubFixture(t)
+		defer fx.Finish()
+
+		fx.repo.On("CreateJob", fx.ctx, job("bulk", 5)).Return(uuid.NewV4(), true, nil)
+
+		msg := &bus.Msg{Data: data, Header: http.Header{}}
+		msg.Header.Set(commonModels.SourceHeader, "bulk")
+		msg.Header.Set(commonModels.PriorityHeader, "5")
+		err := fx.handler.Handle(fx.ctx, msg)
+
+		assert.NoError(t, err)
+	})
+
+	t.Run("should treat duplicate application as success", func(t *testing.T) {
+		fx := newSubFixture(t)
+		defer fx.Finish()
+
+		fx.repo.On("CreateJob", fx.ctx, job(models.DefaultJobSource, 0)).Return(uuid.NewV4(), false, nil)
+
+		err := fx.handler.Handle(fx.ctx, &bus.Msg{Data: data})
+
+		assert.NoError(t, err)
+	})
+
+	t.Run("when cannot create job", func(t *testing.T) {
+		fx := newSubFixture(t)
+		defer fx.Finish()
+
+		repoErr := errors.New(gofakeit.Sentence(3))
+		fx.repo.On("CreateJob", fx.ctx, mock.Anything).Return(uuid.Nil, false, repoErr)
+
+		err := fx.handler.Handle(fx.ctx, &bus.Msg{Data: data})
+
+		assert.Equal(t, repoErr, errors.Cause(err))
+	})
+}
+
+type subFixture struct {
+	t    *testing.T
+	ctx  context.Context
+	repo *mocks.Repo
+
+	handler *NewApplicationHandler
+}
+
+func newSubFixture(t *testing.T) *subFixture {
+	fx := &subFixture{
+		t:    t,
+		ctx:  context.Background(),
+		repo: &mocks.Repo{},
+	}
+	fx.handler = NewNewApplicationHandler(fx.repo, testBank, map[string]int{"bulk": -10, "web": 10})
+	return fx
+}
+
+func (fx *subFixture) Finish() {
+	fx.repo.AssertExpectations(fx.t)
+}
+
+const testBank = "acme"
+
+func job(source string, priority int) interface{} {
+	return mock.MatchedBy(func(job models.Job) bool {
+		return job.Status == models.JobStatusNew && job.Source == source && job.Priority == priority && job.Bank == testBank
+	})
 }
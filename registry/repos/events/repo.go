@@ -0,0 +1,60 @@
+package eventsRepo
+
+import (
+	"context"
+	"github.com/Masterminds/squirrel"
+	"github.com/ivanovaleksey/lendo/pkg/db"
+	"github.com/ivanovaleksey/lendo/registry/models"
+	"github.com/jmoiron/sqlx"
+	uuid "github.com/satori/go.uuid"
+)
+
+const (
+	tableName = "job_events"
+)
+
+type Repo struct {
+	db      *db.DB
+	builder squirrel.StatementBuilderType
+}
+
+func New(database *db.DB) *Repo {
+	repo := &Repo{
+		db:      database,
+		builder: db.Builder,
+	}
+	return repo
+}
+
+// CreateEventTx records the event in the transaction the job is updated in.
+func (repo *Repo) CreateEventTx(ctx context.Context, tx sqlx.ExecerContext, item models.JobEvent) error {
+	const query = `
+		INSERT INTO ` + tableName + ` (job_id, handler, bank_status, error, duration_ms, worker_id)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`
+
+	_, err := tx.ExecContext(ctx, query,
+		item.JobID, item.Handler, item.BankStatus, item.Error, item.DurationMS, item.WorkerID,
+	)
+	return err
+}
+
+// GetByJobID returns the job timeline in chronological order.
+func (repo *Repo) GetByJobID(ctx context.Context, id uuid.UUID) ([]models.JobEvent, error) {
+	query, args, err := repo.builder.
+		Select("*").
+		From(tableName).
+		Where("job_id = ?", id).
+		OrderBy("created_at").
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]models.JobEvent, 0)
+	err = repo.db.SelectContext(ctx, &items, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	return items, nil
+}
@@ -0,0 +1,93 @@
+package eventsRepo
+
+import (
+	"context"
+	"github.com/brianvoe/gofakeit"
+	"github.com/ivanovaleksey/lendo/pkg/db"
+	commonModels "github.com/ivanovaleksey/lendo/pkg/models"
+	"github.com/ivanovaleksey/lendo/pkg/test"
+	"github.com/ivanovaleksey/lendo/registry/config"
+	"github.com/ivanovaleksey/lendo/registry/models"
+	uuid "github.com/satori/go.uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestRepo_CreateEventTx(t *testing.T) {
+	fx := newFixture(t)
+	defer fx.Finish()
+
+	item := fx.buildEvent(uuid.NewV4())
+
+	err := fx.repo.CreateEventTx(fx.ctx, fx.db, item)
+	require.NoError(t, err)
+
+	list, err := fx.repo.GetByJobID(fx.ctx, item.JobID)
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+
+	got := list[0]
+	assert.NotEqual(t, uuid.Nil, got.ID)
+	assert.False(t, got.CreatedAt.IsZero())
+	got.ID, got.CreatedAt = uuid.Nil, time.Time{}
+	assert.Equal(t, item, got)
+}
+
+func TestRepo_GetByJobID(t *testing.T) {
+	fx := newFixture(t)
+	defer fx.Finish()
+
+	jobID := uuid.NewV4()
+	first := fx.buildEvent(jobID)
+	second := fx.buildEvent(jobID)
+	require.NoError(t, fx.repo.CreateEventTx(fx.ctx, fx.db, first))
+	require.NoError(t, fx.repo.CreateEventTx(fx.ctx, fx.db, second))
+	require.NoError(t, fx.repo.CreateEventTx(fx.ctx, fx.db, fx.buildEvent(uuid.NewV4())))
+
+	list, err := fx.repo.GetByJobID(fx.ctx, jobID)
+
+	require.NoError(t, err)
+	require.Len(t, list, 2)
+	assert.Equal(t, first.Handler, list[0].Handler)
+	assert.Equal(t, second.Handler, list[1].Handler)
+}
+
+type fixture struct {
+	t   *testing.T
+	ctx context.Context
+	db  *db.DB
+
+	repo *Repo
+}
+
+func newFixture(t *testing.T) *fixture {
+	test.LoadRegistryEnv(t)
+
+	cfg, err := config.New()
+	require.NoError(t, err)
+
+	fx := &fixture{
+		t:   t,
+		ctx: context.Background(),
+		db:  db.NewTestDB(t, cfg.DB),
+	}
+	fx.repo = New(fx.db)
+	return fx
+}
+
+func (fx *fixture) Finish() {
+	require.NoError(fx.t, fx.db.Close())
+}
+
+func (fx *fixture) buildEvent(jobID uuid.UUID) models.JobEvent {
+	return models.JobEvent{
+		JobID:      jobID,
+		Handler:    gofakeit.Word(),
+		BankStatus: commonModels.ApplicationStatusPending,
+		Error:      gofakeit.Sentence(3),
+		DurationMS: int64(gofakeit.Number(1, 1000)),
+		WorkerID:   gofakeit.Number(1, 10),
+	}
+}
@@ -0,0 +1,81 @@
+package exchangesRepo
+
+import (
+	"context"
+	"github.com/Masterminds/squirrel"
+	"github.com/ivanovaleksey/lendo/pkg/db"
+	"github.com/ivanovaleksey/lendo/registry/models"
+	uuid "github.com/satori/go.uuid"
+	"time"
+)
+
+const (
+	tableName = "bank_exchanges"
+)
+
+type Repo struct {
+	db      *db.DB
+	builder squirrel.StatementBuilderType
+}
+
+func New(database *db.DB) *Repo {
+	repo := &Repo{
+		db:      database,
+		builder: db.Builder,
+	}
+	return repo
+}
+
+func (repo *Repo) CreateExchange(ctx context.Context, item models.BankExchange) error {
+	const query = `
+		INSERT INTO ` + tableName + ` (
+			job_id, application_id, method, url,
+			request_headers, request_body,
+			response_status, response_headers, response_body,
+			error, latency_ms
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+	`
+
+	_, err := repo.db.ExecContext(ctx, query,
+		item.JobID, item.ApplicationID, item.Method, item.URL,
+		item.RequestHeaders, item.RequestBody,
+		item.ResponseStatus, item.ResponseHeaders, item.ResponseBody,
+		item.Error, item.LatencyMS,
+	)
+	return err
+}
+
+// GetByApplicationID returns all exchanges made for the application in chronological order.
+func (repo *Repo) GetByApplicationID(ctx context.Context, id uuid.UUID) ([]models.BankExchange, error) {
+	query, args, err := repo.builder.
+		Select("*").
+		From(tableName).
+		Where(squirrel.Eq{"application_id": id}).
+		OrderBy("created_at").
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]models.BankExchange, 0)
+	err = repo.db.SelectContext(ctx, &items, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// DeleteBefore removes exchanges recorded before the given time and returns the number of removed rows.
+func (repo *Repo) DeleteBefore(ctx context.Context, before time.Time) (int64, error) {
+	const query = `
+		DELETE FROM ` + tableName + `
+		WHERE created_at < $1
+	`
+
+	res, err := repo.db.ExecContext(ctx, query, before)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
@@ -0,0 +1,114 @@
+package exchangesRepo
+
+import (
+	"context"
+	"github.com/brianvoe/gofakeit"
+	"github.com/ivanovaleksey/lendo/pkg/db"
+	"github.com/ivanovaleksey/lendo/pkg/test"
+	"github.com/ivanovaleksey/lendo/registry/config"
+	"github.com/ivanovaleksey/lendo/registry/models"
+	uuid "github.com/satori/go.uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestRepo_CreateExchange(t *testing.T) {
+	fx := newFixture(t)
+	defer fx.Finish()
+
+	item := fx.buildExchange(uuid.NewV4())
+
+	err := fx.repo.CreateExchange(fx.ctx, item)
+	require.NoError(t, err)
+
+	list, err := fx.repo.GetByApplicationID(fx.ctx, *item.ApplicationID)
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+
+	got := list[0]
+	assert.NotEqual(t, uuid.Nil, got.ID)
+	assert.False(t, got.CreatedAt.IsZero())
+	got.ID, got.CreatedAt = uuid.Nil, time.Time{}
+	assert.Equal(t, item, got)
+}
+
+func TestRepo_GetByApplicationID(t *testing.T) {
+	fx := newFixture(t)
+	defer fx.Finish()
+
+	applicationID := uuid.NewV4()
+	require.NoError(t, fx.repo.CreateExchange(fx.ctx, fx.buildExchange(applicationID)))
+	require.NoError(t, fx.repo.CreateExchange(fx.ctx, fx.buildExchange(applicationID)))
+	require.NoError(t, fx.repo.CreateExchange(fx.ctx, fx.buildExchange(uuid.NewV4())))
+
+	list, err := fx.repo.GetByApplicationID(fx.ctx, applicationID)
+
+	require.NoError(t, err)
+	assert.Len(t, list, 2)
+}
+
+func TestRepo_DeleteBefore(t *testing.T) {
+	fx := newFixture(t)
+	defer fx.Finish()
+
+	applicationID := uuid.NewV4()
+	require.NoError(t, fx.repo.CreateExchange(fx.ctx, fx.buildExchange(applicationID)))
+
+	num, err := fx.repo.DeleteBefore(fx.ctx, time.Now().Add(-time.Hour))
+	require.NoError(t, err)
+	assert.Zero(t, num)
+
+	num, err = fx.repo.DeleteBefore(fx.ctx, time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, num)
+
+	list, err := fx.repo.GetByApplicationID(fx.ctx, applicationID)
+	require.NoError(t, err)
+	assert.Empty(t, list)
+}
+
+type fixture struct {
+	t   *testing.T
+	ctx context.Context
+	db  *db.DB
+
+	repo *Repo
+}
+
+func newFixture(t *testing.T) *fixture {
+	test.LoadRegistryEnv(t)
+
+	cfg, err := config.New()
+	require.NoError(t, err)
+
+	fx := &fixture{
+		t:   t,
+		ctx: context.Background(),
+		db:  db.NewTestDB(t, cfg.DB),
+	}
+	fx.repo = New(fx.db)
+	return fx
+}
+
+func (fx *fixture) Finish() {
+	require.NoError(fx.t, fx.db.Close())
+}
+
+func (fx *fixture) buildExchange(applicationID uuid.UUID) models.BankExchange {
+	jobID := uuid.NewV4()
+	return models.BankExchange{
+		JobID:           &jobID,
+		ApplicationID:   &applicationID,
+		Method:          http.MethodGet,
+		URL:             gofakeit.URL(),
+		RequestHeaders:  models.Headers{"Accept": []string{"application/json"}},
+		RequestBody:     "",
+		ResponseStatus:  http.StatusOK,
+		ResponseHeaders: models.Headers{"Content-Type": []string{"application/json"}},
+		ResponseBody:    `{"status":"pending"}`,
+		LatencyMS:       int64(gofakeit.Number(1, 1000)),
+	}
+}
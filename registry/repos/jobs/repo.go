@@ -2,15 +2,24 @@ package jobsRepo
 
 import (
 	"context"
+	"database/sql"
 	"github.com/Masterminds/squirrel"
 	"github.com/ivanovaleksey/lendo/pkg/db"
+	commonModels "github.com/ivanovaleksey/lendo/pkg/models"
 	"github.com/ivanovaleksey/lendo/registry/models"
 	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
 	uuid "github.com/satori/go.uuid"
+	"time"
 )
 
 const (
-	tableName = "jobs"
+	tableName  = "jobs"
+	jobColumns = "id, kind, payload, application_id, bank, status, priority, source, correlation_id, attempts, last_error, run_at, created_at, updated_at"
+)
+
+var (
+	ErrNotFound = errors.New("job not found")
 )
 
 type Repo struct {
@@ -26,31 +35,342 @@ func New(database *db.DB) *Repo {
 	return repo
 }
 
-func (repo *Repo) CreateJob(ctx context.Context, job models.Job) (uuid.UUID, error) {
+// CreateJob creates the job and registers its source, so that workers take it into turn.
+// A job without a source or a bank gets the default one. An application already submitted to the bank
+// is not created again: the ID of the existing job is returned and created is false.
+func (repo *Repo) CreateJob(ctx context.Context, job models.Job) (id uuid.UUID, created bool, err error) {
 	const query = `
-		INSERT INTO ` + tableName + ` (application, status)
-		VALUES ($1, $2)
-		RETURNING id
+		WITH source AS (
+			INSERT INTO job_sources (source)
+			VALUES ($5)
+			ON CONFLICT DO NOTHING
+		), inserted AS (
+			INSERT INTO ` + tableName + ` (kind, payload, status, trace_context, priority, source, application_id, bank, correlation_id, pending_at)
+			VALUES ($6, $1, $2, $3, $4, $5, $7, $8, $9, CASE WHEN $2 = $10 THEN now() END)
+			ON CONFLICT (application_id, bank) DO NOTHING
+			RETURNING id
+		)
+		SELECT id, true AS created FROM inserted
+		UNION ALL
+		SELECT id, false AS created FROM ` + tableName + `
+		WHERE application_id = $7 AND bank = $8 AND NOT EXISTS (SELECT FROM inserted)
 	`
 
-	var id uuid.UUID
-	err := repo.db.GetContext(ctx, &id, query, job.Application, job.Status)
+	if job.Source == "" {
+		job.Source = models.DefaultJobSource
+	}
+	if job.Bank == "" {
+		job.Bank = models.DefaultBank
+	}
+
+	var row struct {
+		ID      uuid.UUID `db:"id"`
+		Created bool      `db:"created"`
+	}
+	err = repo.db.GetContext(ctx, &row, query,
+		job.Payload, job.Status, job.TraceContext, job.Priority, job.Source, job.Kind, job.ApplicationID, job.Bank,
+		job.CorrelationID, models.JobStatusPending,
+	)
+	if err == sql.ErrNoRows {
+		// the conflicting job is committed after the statement has started, it's seen on a retry
+		return uuid.Nil, false, errors.New("job is being created concurrently")
+	}
 	if err != nil {
-		return uuid.Nil, err
+		return uuid.Nil, false, err
 	}
-	return id, nil
+	return row.ID, row.Created, nil
 }
 
 func (repo *Repo) UpdateJob(ctx context.Context, job models.Job) error {
 	return repo.UpdateJobTx(ctx, repo.db, job)
 }
 
+// UpdateJobTx updates the status and the payload of the job, the time it becomes pending is recorded.
 func (repo *Repo) UpdateJobTx(ctx context.Context, tx sqlx.ExecerContext, job models.Job) error {
 	const query = `
 		UPDATE ` + tableName + `
-		SET status = $2, application = $3, updated_at = now()
+		SET status = $2, payload = $3, updated_at = now(),
+			pending_at = CASE WHEN status <> $4 AND $2 = $4 THEN now() ELSE pending_at END
 		WHERE id = $1
 	`
-	_, err := tx.ExecContext(ctx, query, job.ID, job.Status, job.Application)
+	_, err := tx.ExecContext(ctx, query, job.ID, job.Status, job.Payload, models.JobStatusPending)
 	return err
 }
+
+// LockJobByApplicationIDTx returns the latest application job of the application locking it until the end of tx.
+func (repo *Repo) LockJobByApplicationIDTx(ctx context.Context, tx sqlx.QueryerContext, id uuid.UUID) (models.Job, error) {
+	const query = `
+		SELECT id, kind, payload, application_id, bank, status, correlation_id
+		FROM ` + tableName + `
+		WHERE application_id = $1
+		ORDER BY created_at DESC
+		LIMIT 1
+		FOR UPDATE
+	`
+
+	var job models.Job
+	err := sqlx.GetContext(ctx, tx, &job, query, id)
+	switch {
+	case err == sql.ErrNoRows:
+		return models.Job{}, ErrNotFound
+	case err != nil:
+		return models.Job{}, err
+	}
+	return job, nil
+}
+
+type GetListParams struct {
+	models.PaginationParams
+	Status        models.JobStatus
+	CreatedBefore time.Time
+	MinAttempts   int
+}
+
+func (repo *Repo) GetList(ctx context.Context, params GetListParams) ([]models.Job, int, error) {
+	qb := repo.builder.
+		Select(jobColumns, "count(*) over () AS total").
+		From(tableName).
+		OrderBy("created_at").
+		Offset(uint64(params.Offset)).
+		Limit(uint64(params.GetLimit()))
+
+	if params.Status != "" {
+		qb = qb.Where(squirrel.Eq{"status": params.Status})
+	}
+	if !params.CreatedBefore.IsZero() {
+		qb = qb.Where(squirrel.Lt{"created_at": params.CreatedBefore})
+	}
+	if params.MinAttempts > 0 {
+		qb = qb.Where(squirrel.GtOrEq{"attempts": params.MinAttempts})
+	}
+
+	query, args, err := qb.ToSql()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var (
+		items = make([]models.Job, 0)
+		total int
+	)
+	rows, err := repo.db.QueryxContext(ctx, query, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var row struct {
+			models.Job
+			Total int `db:"total"`
+		}
+		err := rows.StructScan(&row)
+		if err != nil {
+			return nil, 0, err
+		}
+		items = append(items, row.Job)
+		total = row.Total
+	}
+
+	return items, total, rows.Err()
+}
+
+// GetLatestByApplicationIDs returns the latest application job of every given application which has one.
+func (repo *Repo) GetLatestByApplicationIDs(ctx context.Context, ids []uuid.UUID) ([]models.Job, error) {
+	items := make([]models.Job, 0)
+	if len(ids) == 0 {
+		return items, nil
+	}
+
+	strIDs := make([]string, 0, len(ids))
+	for _, id := range ids {
+		strIDs = append(strIDs, id.String())
+	}
+
+	query, args, err := repo.builder.
+		Select("DISTINCT ON (application_id) "+jobColumns).
+		From(tableName).
+		Where(squirrel.Eq{"application_id": strIDs}).
+		OrderBy("application_id", "created_at DESC").
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	err = repo.db.SelectContext(ctx, &items, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+func (repo *Repo) GetByID(ctx context.Context, id uuid.UUID) (models.Job, error) {
+	const query = `
+		SELECT ` + jobColumns + `
+		FROM ` + tableName + `
+		WHERE id = $1
+	`
+
+	var job models.Job
+	err := repo.db.GetContext(ctx, &job, query, id)
+	switch {
+	case err == sql.ErrNoRows:
+		return models.Job{}, ErrNotFound
+	case err != nil:
+		return models.Job{}, err
+	}
+	return job, nil
+}
+
+// UpdateStatus moves the job to a status if it's in one of the from statuses.
+// ErrNotFound is returned when there is no such job.
+func (repo *Repo) UpdateStatus(ctx context.Context, id uuid.UUID, from []models.JobStatus, to models.JobStatus) error {
+	qb := repo.builder.
+		Update(tableName).
+		Set("status", to).
+		Set("updated_at", squirrel.Expr("now()")).
+		Where("id = ?", id).
+		Where(squirrel.Eq{"status": from})
+	if to == models.JobStatusPending {
+		qb = qb.Set("pending_at", squirrel.Expr("CASE WHEN status <> ? THEN now() ELSE pending_at END", models.JobStatusPending))
+	}
+
+	return repo.exec(ctx, qb)
+}
+
+// Reschedule makes the job run as soon as possible if it's in one of the from statuses.
+// ErrNotFound is returned when there is no such job.
+func (repo *Repo) Reschedule(ctx context.Context, id uuid.UUID, from []models.JobStatus) error {
+	qb := repo.builder.
+		Update(tableName).
+		Set("run_at", squirrel.Expr("now()")).
+		Set("updated_at", squirrel.Expr("now()")).
+		Where("id = ?", id).
+		Where(squirrel.Eq{"status": from})
+
+	return repo.exec(ctx, qb)
+}
+
+// Requeue resets the job attempts and puts it back to processing if it's in one of the from statuses.
+// An application job whose application is already known to the bank is put to 'pending',
+// so the application is not created twice. Jobs of other kinds are put to 'new'.
+// ErrNotFound is returned when there is no such job.
+func (repo *Repo) Requeue(ctx context.Context, id uuid.UUID, from []models.JobStatus) error {
+	qb := repo.requeue().
+		Where("id = ?", id).
+		Where(squirrel.Eq{"status": from})
+	return repo.exec(ctx, qb)
+}
+
+// RequeueFailed requeues jobs which failed within [from, to) and returns their number.
+func (repo *Repo) RequeueFailed(ctx context.Context, from, to time.Time) (int64, error) {
+	qb := repo.requeue().Where(squirrel.And{
+		squirrel.Eq{"status": models.JobStatusFailed},
+		squirrel.GtOrEq{"updated_at": from},
+		squirrel.Lt{"updated_at": to},
+	})
+
+	query, args, err := qb.ToSql()
+	if err != nil {
+		return 0, err
+	}
+	res, err := repo.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// RejectPendingBefore rejects applications of application jobs which have been pending since before
+// the given time, the jobs are done with the reason as their last error. IDs of the applications are returned.
+func (repo *Repo) RejectPendingBefore(ctx context.Context, before time.Time, reason string) ([]uuid.UUID, error) {
+	const query = `
+		UPDATE ` + tableName + `
+		SET status = $1, payload = jsonb_set(payload::jsonb, '{status}', to_jsonb($2::text))::json,
+			last_error = $3, updated_at = now()
+		WHERE kind = $4 AND status = $5 AND pending_at < $6
+		RETURNING (payload->>'id')::uuid
+	`
+
+	var ids []uuid.UUID
+	err := repo.db.SelectContext(ctx, &ids, query,
+		models.JobStatusDone, commonModels.ApplicationStatusRejected, reason, models.JobKindApplication,
+		models.JobStatusPending, before,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+// DeleteDoneBefore removes jobs done before the given time together with their events and returns their number.
+func (repo *Repo) DeleteDoneBefore(ctx context.Context, before time.Time) (int64, error) {
+	const query = `
+		WITH deleted AS (
+			DELETE FROM ` + tableName + `
+			WHERE status = $1 AND updated_at < $2
+			RETURNING id
+		), events AS (
+			DELETE FROM job_events
+			WHERE job_id IN (SELECT id FROM deleted)
+		)
+		SELECT count(*) FROM deleted
+	`
+
+	var num int64
+	err := repo.db.GetContext(ctx, &num, query, models.JobStatusDone, before)
+	if err != nil {
+		return 0, err
+	}
+	return num, nil
+}
+
+func (repo *Repo) requeue() squirrel.UpdateBuilder {
+	status := squirrel.Expr(
+		"CASE WHEN kind <> ? OR coalesce(payload->>'status', '') IN ('', ?) THEN ? ELSE ? END",
+		models.JobKindApplication, commonModels.ApplicationStatusNew, models.JobStatusNew, models.JobStatusPending,
+	)
+	return repo.builder.
+		Update(tableName).
+		Set("status", status).
+		Set("attempts", 0).
+		Set("last_error", "").
+		Set("run_at", squirrel.Expr("now()")).
+		Set("updated_at", squirrel.Expr("now()")).
+		// it's only read while the job is pending
+		Set("pending_at", squirrel.Expr("now()"))
+}
+
+func (repo *Repo) exec(ctx context.Context, qb squirrel.UpdateBuilder) error {
+	query, args, err := qb.ToSql()
+	if err != nil {
+		return err
+	}
+	res, err := repo.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	num, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if num == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (repo *Repo) GetStats(ctx context.Context) ([]models.JobStats, error) {
+	const query = `
+		SELECT status, count(*) AS count, extract(epoch FROM now() - min(created_at)) AS oldest_age
+		FROM ` + tableName + `
+		GROUP BY status
+	`
+
+	items := make([]models.JobStats, 0)
+	err := repo.db.SelectContext(ctx, &items, query)
+	if err != nil {
+		return nil, err
+	}
+	return items, nil
+}
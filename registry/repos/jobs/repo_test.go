@@ -12,31 +12,303 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"testing"
+	"time"
 )
 
 func TestRepo_CreateJob(t *testing.T) {
 	fx := newFixture(t)
 	defer fx.Finish()
 
-	application := commonModels.Application{
-		NewApplication: commonModels.NewApplication{
-			FirstName: gofakeit.FirstName(),
-			LastName:  gofakeit.LastName(),
-		},
-		ID:     uuid.NewV4(),
-		Status: commonModels.ApplicationStatus(gofakeit.Word()),
-	}
-	item := models.Job{
-		Application: application,
-		Status:      models.JobStatus(gofakeit.Word()),
-	}
+	item := fx.buildJob(models.JobStatus(gofakeit.Word()), commonModels.ApplicationStatus(gofakeit.Word()))
+	item.Priority = gofakeit.Number(-10, 10)
+	item.Source = gofakeit.Word()
+	item.CorrelationID = uuid.NewV4().String()
 
-	id, err := fx.repo.CreateJob(fx.ctx, item)
+	id, created, err := fx.repo.CreateJob(fx.ctx, item)
 
 	require.NoError(t, err)
+	assert.True(t, created)
 	item.ID = id
 	job := fx.getJob(id)
 	assert.Equal(t, item, job)
+
+	var sources []string
+	require.NoError(t, fx.db.SelectContext(fx.ctx, &sources, "SELECT source FROM job_sources WHERE source = $1", item.Source))
+	assert.Len(t, sources, 1)
+}
+
+func TestRepo_CreateJob_Kind(t *testing.T) {
+	fx := newFixture(t)
+	defer fx.Finish()
+
+	item := models.Job{
+		Kind:    models.JobKind(gofakeit.Word()),
+		Payload: models.Payload(`{"document_id": "` + uuid.NewV4().String() + `"}`),
+		Status:  models.JobStatusNew,
+		Source:  models.DefaultJobSource,
+		Bank:    models.DefaultBank,
+	}
+
+	id, _, err := fx.repo.CreateJob(fx.ctx, item)
+
+	require.NoError(t, err)
+	item.ID = id
+	assert.Equal(t, item, fx.getJob(id))
+}
+
+func TestRepo_CreateJob_Duplicate(t *testing.T) {
+	t.Run("should not create job of the same application", func(t *testing.T) {
+		fx := newFixture(t)
+		defer fx.Finish()
+
+		item := fx.createJob(models.JobStatusNew, commonModels.ApplicationStatusNew)
+
+		id, created, err := fx.repo.CreateJob(fx.ctx, item)
+
+		require.NoError(t, err)
+		assert.False(t, created)
+		assert.Equal(t, item.ID, id)
+	})
+
+	t.Run("should create job of the same application for another bank", func(t *testing.T) {
+		fx := newFixture(t)
+		defer fx.Finish()
+
+		item := fx.createJob(models.JobStatusNew, commonModels.ApplicationStatusNew)
+		item.Bank = gofakeit.Word()
+
+		id, created, err := fx.repo.CreateJob(fx.ctx, item)
+
+		require.NoError(t, err)
+		assert.True(t, created)
+		assert.NotEqual(t, item.ID, id)
+	})
+}
+
+func TestRepo_CreateJob_DefaultSource(t *testing.T) {
+	fx := newFixture(t)
+	defer fx.Finish()
+
+	item := fx.createJob(models.JobStatusNew, commonModels.ApplicationStatusNew)
+
+	job := fx.getJob(item.ID)
+	assert.Equal(t, models.DefaultJobSource, job.Source)
+}
+
+func TestRepo_LockJobByApplicationIDTx(t *testing.T) {
+	t.Run("when job does not exist", func(t *testing.T) {
+		fx := newFixture(t)
+		defer fx.Finish()
+
+		job, err := fx.repo.LockJobByApplicationIDTx(fx.ctx, fx.db, uuid.NewV4())
+
+		require.Equal(t, ErrNotFound, err)
+		assert.Empty(t, job)
+	})
+
+	t.Run("when job exists", func(t *testing.T) {
+		fx := newFixture(t)
+		defer fx.Finish()
+
+		item := fx.buildJob(models.JobStatusPending, commonModels.ApplicationStatusPending)
+		id, _, err := fx.repo.CreateJob(fx.ctx, item)
+		require.NoError(t, err)
+		item.ID = id
+
+		job, err := fx.repo.LockJobByApplicationIDTx(fx.ctx, fx.db, fx.application(item).ID)
+
+		require.NoError(t, err)
+		assert.Equal(t, item, job)
+	})
+}
+
+func TestRepo_GetList(t *testing.T) {
+	fx := newFixture(t)
+	defer fx.Finish()
+
+	failed := fx.createJob(models.JobStatusFailed, commonModels.ApplicationStatusPending)
+	fx.createJob(models.JobStatusPending, commonModels.ApplicationStatusPending)
+	_, err := fx.db.ExecContext(fx.ctx, `UPDATE jobs SET attempts = 5 WHERE id = $1`, failed.ID)
+	require.NoError(t, err)
+
+	items, total, err := fx.repo.GetList(fx.ctx, GetListParams{
+		Status:        models.JobStatusFailed,
+		CreatedBefore: time.Now().Add(time.Minute),
+		MinAttempts:   5,
+	})
+
+	require.NoError(t, err)
+	require.Equal(t, 1, total)
+	assert.Equal(t, failed.ID, items[0].ID)
+	assert.Equal(t, 5, items[0].Attempts)
+}
+
+func TestRepo_GetLatestByApplicationIDs(t *testing.T) {
+	fx := newFixture(t)
+	defer fx.Finish()
+
+	first := fx.createJob(models.JobStatusDone, commonModels.ApplicationStatusCompleted)
+	fx.createJob(models.JobStatusNew, commonModels.ApplicationStatusNew)
+
+	items, err := fx.repo.GetLatestByApplicationIDs(fx.ctx, []uuid.UUID{fx.application(first).ID, uuid.NewV4()})
+
+	require.NoError(t, err)
+	require.Len(t, items, 1)
+	assert.Equal(t, first.ID, items[0].ID)
+	assert.Equal(t, first.Payload, items[0].Payload)
+}
+
+func TestRepo_GetByID(t *testing.T) {
+	t.Run("when job does not exist", func(t *testing.T) {
+		fx := newFixture(t)
+		defer fx.Finish()
+
+		_, err := fx.repo.GetByID(fx.ctx, uuid.NewV4())
+
+		assert.Equal(t, ErrNotFound, err)
+	})
+
+	t.Run("when job exists", func(t *testing.T) {
+		fx := newFixture(t)
+		defer fx.Finish()
+
+		item := fx.createJob(models.JobStatusNew, commonModels.ApplicationStatusNew)
+
+		job, err := fx.repo.GetByID(fx.ctx, item.ID)
+
+		require.NoError(t, err)
+		assert.Equal(t, item.Kind, job.Kind)
+		assert.Equal(t, item.Payload, job.Payload)
+		assert.Equal(t, item.Status, job.Status)
+		assert.False(t, job.CreatedAt.IsZero())
+	})
+}
+
+func TestRepo_UpdateStatus(t *testing.T) {
+	t.Run("when job is in allowed status", func(t *testing.T) {
+		fx := newFixture(t)
+		defer fx.Finish()
+
+		item := fx.createJob(models.JobStatusPending, commonModels.ApplicationStatusPending)
+
+		err := fx.repo.UpdateStatus(fx.ctx, item.ID, []models.JobStatus{models.JobStatusNew, models.JobStatusPending}, models.JobStatusCancelled)
+
+		require.NoError(t, err)
+		assert.Equal(t, models.JobStatusCancelled, fx.getJob(item.ID).Status)
+	})
+
+	t.Run("when job is in another status", func(t *testing.T) {
+		fx := newFixture(t)
+		defer fx.Finish()
+
+		item := fx.createJob(models.JobStatusDone, commonModels.ApplicationStatusCompleted)
+
+		err := fx.repo.UpdateStatus(fx.ctx, item.ID, []models.JobStatus{models.JobStatusNew}, models.JobStatusCancelled)
+
+		assert.Equal(t, ErrNotFound, err)
+		assert.Equal(t, models.JobStatusDone, fx.getJob(item.ID).Status)
+	})
+}
+
+func TestRepo_Requeue(t *testing.T) {
+	t.Run("should requeue application known to bank as pending", func(t *testing.T) {
+		fx := newFixture(t)
+		defer fx.Finish()
+
+		item := fx.createJob(models.JobStatusFailed, commonModels.ApplicationStatusPending)
+
+		err := fx.repo.Requeue(fx.ctx, item.ID, []models.JobStatus{models.JobStatusFailed})
+
+		require.NoError(t, err)
+		assert.Equal(t, models.JobStatusPending, fx.getJob(item.ID).Status)
+	})
+
+	t.Run("should requeue new application as new", func(t *testing.T) {
+		fx := newFixture(t)
+		defer fx.Finish()
+
+		item := fx.createJob(models.JobStatusCancelled, commonModels.ApplicationStatusNew)
+
+		err := fx.repo.Requeue(fx.ctx, item.ID, []models.JobStatus{models.JobStatusCancelled})
+
+		require.NoError(t, err)
+		assert.Equal(t, models.JobStatusNew, fx.getJob(item.ID).Status)
+	})
+}
+
+func TestRepo_RequeueFailed(t *testing.T) {
+	fx := newFixture(t)
+	defer fx.Finish()
+
+	failed := fx.createJob(models.JobStatusFailed, commonModels.ApplicationStatusPending)
+	done := fx.createJob(models.JobStatusDone, commonModels.ApplicationStatusCompleted)
+
+	num, err := fx.repo.RequeueFailed(fx.ctx, time.Now().Add(-time.Minute), time.Now().Add(time.Minute))
+
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), num)
+	assert.Equal(t, models.JobStatusPending, fx.getJob(failed.ID).Status)
+	assert.Equal(t, models.JobStatusDone, fx.getJob(done.ID).Status)
+}
+
+func TestRepo_RejectPendingBefore(t *testing.T) {
+	t.Run("should reject application jobs pending before time", func(t *testing.T) {
+		fx := newFixture(t)
+		defer fx.Finish()
+
+		pending := fx.createJob(models.JobStatusPending, commonModels.ApplicationStatusPending)
+		done := fx.createJob(models.JobStatusDone, commonModels.ApplicationStatusCompleted)
+		other := fx.buildJob(models.JobStatusPending, commonModels.ApplicationStatusPending)
+		other.Kind = models.JobKind(gofakeit.Word())
+		other.ApplicationID = nil
+		otherID, _, err := fx.repo.CreateJob(fx.ctx, other)
+		require.NoError(t, err)
+
+		ids, err := fx.repo.RejectPendingBefore(fx.ctx, time.Now().Add(time.Minute), "timed out")
+
+		require.NoError(t, err)
+		assert.Equal(t, []uuid.UUID{fx.application(pending).ID}, ids)
+		job := fx.getJob(pending.ID)
+		assert.Equal(t, models.JobStatusDone, job.Status)
+		assert.Equal(t, commonModels.ApplicationStatusRejected, fx.application(job).Status)
+		assert.Equal(t, "timed out", job.LastError)
+		assert.Equal(t, models.JobStatusDone, fx.getJob(done.ID).Status)
+		assert.Equal(t, commonModels.ApplicationStatusCompleted, fx.application(fx.getJob(done.ID)).Status)
+		assert.Equal(t, models.JobStatusPending, fx.getJob(otherID).Status)
+	})
+
+	t.Run("should measure from transition to pending", func(t *testing.T) {
+		fx := newFixture(t)
+		defer fx.Finish()
+
+		job := fx.createJob(models.JobStatusNew, commonModels.ApplicationStatusNew)
+		_, err := fx.db.ExecContext(fx.ctx, `UPDATE jobs SET created_at = now() - interval '1 day' WHERE id = $1`, job.ID)
+		require.NoError(t, err)
+		job.Status = models.JobStatusPending
+		require.NoError(t, fx.repo.UpdateJob(fx.ctx, job))
+
+		ids, err := fx.repo.RejectPendingBefore(fx.ctx, time.Now().Add(-time.Hour), "timed out")
+
+		require.NoError(t, err)
+		assert.Empty(t, ids)
+		assert.Equal(t, models.JobStatusPending, fx.getJob(job.ID).Status)
+	})
+}
+
+func TestRepo_DeleteDoneBefore(t *testing.T) {
+	fx := newFixture(t)
+	defer fx.Finish()
+
+	pending := fx.createJob(models.JobStatusPending, commonModels.ApplicationStatusPending)
+	done := fx.createJob(models.JobStatusDone, commonModels.ApplicationStatusCompleted)
+
+	num, err := fx.repo.DeleteDoneBefore(fx.ctx, time.Now().Add(time.Minute))
+
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), num)
+	_, err = fx.repo.GetByID(fx.ctx, done.ID)
+	assert.Equal(t, ErrNotFound, err)
+	assert.Equal(t, models.JobStatusPending, fx.getJob(pending.ID).Status)
 }
 
 type fixture struct {
@@ -66,8 +338,37 @@ func (fx *fixture) Finish() {
 	require.NoError(fx.t, fx.db.Close())
 }
 
+func (fx *fixture) buildJob(status models.JobStatus, applicationStatus commonModels.ApplicationStatus) models.Job {
+	job, err := models.NewApplicationJob(commonModels.Application{
+		NewApplication: commonModels.NewApplication{
+			FirstName: gofakeit.FirstName(),
+			LastName:  gofakeit.LastName(),
+		},
+		ID:     uuid.NewV4(),
+		Status: applicationStatus,
+	})
+	require.NoError(fx.t, err)
+	job.Status = status
+	job.Bank = models.DefaultBank
+	return job
+}
+
+func (fx *fixture) createJob(status models.JobStatus, applicationStatus commonModels.ApplicationStatus) models.Job {
+	item := fx.buildJob(status, applicationStatus)
+	id, _, err := fx.repo.CreateJob(fx.ctx, item)
+	require.NoError(fx.t, err)
+	item.ID = id
+	return item
+}
+
+func (fx *fixture) application(job models.Job) commonModels.Application {
+	application, err := job.Application()
+	require.NoError(fx.t, err)
+	return application
+}
+
 func (fx *fixture) getJob(id uuid.UUID) (job models.Job) {
-	const query = `SELECT id, application, status FROM jobs WHERE id = $1`
+	const query = `SELECT id, kind, payload, application_id, bank, status, priority, source, correlation_id, last_error FROM jobs WHERE id = $1`
 
 	err := fx.db.GetContext(fx.ctx, &job, query, id)
 	require.NoError(fx.t, err)
@@ -0,0 +1,57 @@
+package responses
+
+import (
+	"github.com/go-chi/render"
+	"net/http"
+)
+
+type ErrorResponse struct {
+	HTTPCode int    `json:"-"`
+	Error    string `json:"error"`
+	Debug    string `json:"debug"`
+}
+
+func (e ErrorResponse) Render(w http.ResponseWriter, r *http.Request) error {
+	render.Status(r, e.HTTPCode)
+	return nil
+}
+
+func ErrInternal(err error) render.Renderer {
+	return ErrorResponse{
+		HTTPCode: http.StatusInternalServerError,
+		Error:    "internal error",
+		Debug:    err.Error(),
+	}
+}
+
+func ErrBadRequest(err error) render.Renderer {
+	return ErrorResponse{
+		HTTPCode: http.StatusBadRequest,
+		Error:    "bad request",
+		Debug:    err.Error(),
+	}
+}
+
+func ErrUnauthorized(err error) render.Renderer {
+	return ErrorResponse{
+		HTTPCode: http.StatusUnauthorized,
+		Error:    "unauthorized",
+		Debug:    err.Error(),
+	}
+}
+
+func ErrNotFound(err error) render.Renderer {
+	return ErrorResponse{
+		HTTPCode: http.StatusNotFound,
+		Error:    "not found",
+		Debug:    err.Error(),
+	}
+}
+
+func ErrConflict(err error) render.Renderer {
+	return ErrorResponse{
+		HTTPCode: http.StatusConflict,
+		Error:    "conflict",
+		Debug:    err.Error(),
+	}
+}
@@ -0,0 +1,14 @@
+package responses
+
+import (
+	"github.com/ivanovaleksey/lendo/registry/models"
+	"net/http"
+)
+
+type GetExchangesResponse struct {
+	Items []models.BankExchange `json:"items"`
+}
+
+func (GetExchangesResponse) Render(w http.ResponseWriter, r *http.Request) error {
+	return nil
+}
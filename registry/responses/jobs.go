@@ -0,0 +1,31 @@
+package responses
+
+import (
+	"github.com/ivanovaleksey/lendo/registry/models"
+	"net/http"
+)
+
+type GetJobsResponse struct {
+	Items []models.Job `json:"items"`
+	Total int          `json:"total"`
+}
+
+func (GetJobsResponse) Render(w http.ResponseWriter, r *http.Request) error {
+	return nil
+}
+
+type GetJobResponse struct {
+	models.JobDetails
+}
+
+func (GetJobResponse) Render(w http.ResponseWriter, r *http.Request) error {
+	return nil
+}
+
+type RetryFailedJobsResponse struct {
+	Count int64 `json:"count"`
+}
+
+func (RetryFailedJobsResponse) Render(w http.ResponseWriter, r *http.Request) error {
+	return nil
+}
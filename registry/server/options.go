@@ -0,0 +1,26 @@
+package server
+
+// Role selects the components a process runs, so that consumers and pollers scale as separate deployments
+// of one image. The HTTP server runs in every role.
+type Role int
+
+const (
+	// RoleAll runs every component.
+	RoleAll Role = iota
+	// RoleConsumer runs the consumer of new applications.
+	RoleConsumer
+	// RolePoller runs the poller and the scheduled tasks.
+	RolePoller
+)
+
+type Option func(*settings)
+
+type settings struct {
+	role Role
+}
+
+func WithRole(role Role) Option {
+	return func(s *settings) {
+		s.role = role
+	}
+}
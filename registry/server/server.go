@@ -0,0 +1,203 @@
+// Package server starts the registry components on an infrastructure owned by the caller,
+// so that the registry runs both as a service of its own and together with the api in one process.
+package server
+
+import (
+	"context"
+	"github.com/ivanovaleksey/lendo/pkg/bus"
+	"github.com/ivanovaleksey/lendo/pkg/closer"
+	"github.com/ivanovaleksey/lendo/pkg/component"
+	"github.com/ivanovaleksey/lendo/pkg/db"
+	"github.com/ivanovaleksey/lendo/pkg/envelope"
+	"github.com/ivanovaleksey/lendo/pkg/health"
+	"github.com/ivanovaleksey/lendo/pkg/migrate"
+	"github.com/ivanovaleksey/lendo/pkg/scheduler"
+	"github.com/ivanovaleksey/lendo/pkg/tracing"
+	"github.com/ivanovaleksey/lendo/registry/app"
+	"github.com/ivanovaleksey/lendo/registry/bank"
+	"github.com/ivanovaleksey/lendo/registry/bank/audit"
+	"github.com/ivanovaleksey/lendo/registry/config"
+	"github.com/ivanovaleksey/lendo/registry/maintenance"
+	"github.com/ivanovaleksey/lendo/registry/migrations"
+	"github.com/ivanovaleksey/lendo/registry/poller"
+	"github.com/ivanovaleksey/lendo/registry/poller/handlers"
+	"github.com/ivanovaleksey/lendo/registry/pubsub/applications"
+	"github.com/ivanovaleksey/lendo/registry/repos/events"
+	"github.com/ivanovaleksey/lendo/registry/repos/exchanges"
+	"github.com/ivanovaleksey/lendo/registry/repos/jobs"
+	"github.com/ivanovaleksey/lendo/registry/services/callbacks"
+	"github.com/ivanovaleksey/lendo/registry/services/jobs"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"net/http"
+	"time"
+)
+
+const (
+	defaultReadTimeout  = 5 * time.Second
+	defaultWriteTimeout = 5 * time.Second
+)
+
+// Infra is what the registry components run on. Its closing is up to the caller.
+type Infra struct {
+	DB         *db.DB
+	Bus        bus.Bus
+	Closer     *closer.Closer
+	Supervisor *component.Supervisor
+	Health     *health.Health
+	// BankTransport carries requests to the bank partner, http.DefaultTransport is used when it's nil.
+	BankTransport http.RoundTripper
+}
+
+// Start starts the consumer of new applications, the scheduler, the poller and the HTTP server, or a part of them
+// selected by the role. Nothing is started when the config is invalid or the schema isn't of the version
+// the registry expects. The closer stops the consumer and the server in the ingress phase and the rest
+// in the workers phase.
+func Start(ctx context.Context, cfg config.Config, infra Infra, opts ...Option) error {
+	var settings settings
+	for _, opt := range opts {
+		opt(&settings)
+	}
+	consume, poll := settings.role != RolePoller, settings.role != RoleConsumer
+
+	database := infra.DB
+
+	migrator, err := migrations.New(database, cfg.Migrate)
+	if err != nil {
+		return errors.Wrap(err, "can't create migrator")
+	}
+	if err := migrate.Ensure(ctx, migrator, cfg.Migrate); err != nil {
+		return err
+	}
+
+	var schedulerOpts []scheduler.Option
+
+	var bankTransport = infra.BankTransport
+	if bankTransport == nil {
+		bankTransport = http.DefaultTransport
+	}
+	if cfg.BankAudit.Enabled {
+		repo := exchangesRepo.New(database)
+		bankTransport = audit.NewTransport(bankTransport, repo, cfg.BankAudit)
+
+		if cfg.BankAudit.Retention > 0 {
+			cleaner := audit.NewCleaner(repo, cfg.BankAudit.Retention)
+			schedulerOpts = append(schedulerOpts, scheduler.WithTask("bank_audit.clean", scheduler.Every(audit.CleanInterval), cleaner.Clean))
+		}
+	}
+
+	pub := applicationsPubSub.NewPub(infra.Bus, envelope.WithFormat(cfg.Events.Format))
+
+	{
+		tasks := maintenance.NewJobs(jobsRepo.New(database), pub, cfg.Scheduler.PendingTimeout, cfg.Scheduler.DoneRetention)
+
+		if cfg.Scheduler.PendingTimeout > 0 {
+			schedule, err := scheduler.Parse(cfg.Scheduler.PendingTimeoutSchedule)
+			if err != nil {
+				return errors.Wrap(err, "invalid pending timeout schedule")
+			}
+			schedulerOpts = append(schedulerOpts, scheduler.WithTask("jobs.timeout_pending", schedule, tasks.TimeoutPending))
+		}
+		if cfg.Scheduler.DoneRetention > 0 {
+			schedule, err := scheduler.Parse(cfg.Scheduler.DoneRetentionSchedule)
+			if err != nil {
+				return errors.Wrap(err, "invalid done retention schedule")
+			}
+			schedulerOpts = append(schedulerOpts, scheduler.WithTask("jobs.purge_done", schedule, tasks.PurgeDone))
+		}
+	}
+
+	if consume {
+		repo := jobsRepo.New(database)
+		handler := applicationsPubSub.NewNewApplicationHandler(repo, cfg.Bank.Name, cfg.Jobs.SourcePriorities)
+
+		opts := []bus.ConsumerOption{
+			bus.WithBus(infra.Bus),
+			bus.WithQueue("registry"),
+			bus.WithSubject("applications.new"),
+			bus.WithHandler(handler),
+			bus.WithComponentName("consumer.applications.new"),
+		}
+		consumer := bus.NewConsumer(opts...)
+		infra.Health.AddLiveness(consumer)
+
+		closure := infra.Supervisor.Run(ctx, consumer, component.WithRestartPolicy(component.RestartOnFailure))
+		infra.Closer.Add(closer.PhaseIngress, closure)
+	}
+
+	if poll && len(schedulerOpts) > 0 {
+		s := scheduler.New(scheduler.NewPGElector(database, "registry"), scheduler.NewPGStore(database), schedulerOpts...)
+		closure := infra.Supervisor.Run(ctx, s, component.WithRestartPolicy(component.RestartOnFailure))
+		infra.Closer.Add(closer.PhaseWorkers, closure)
+	}
+
+	if poll {
+		bankClient := bank.NewClient(cfg.Bank, bank.WithTransport(tracing.Transport(bankTransport)))
+		repo := jobsRepo.New(database)
+
+		opts := []poller.Option{
+			poller.WithDB(database),
+			poller.WithBank(bankClient),
+			poller.WithRepo(repo),
+			poller.WithNotifier(pub),
+			poller.WithEventsRepo(eventsRepo.New(database)),
+			poller.WithDrainTimeout(cfg.Poller.DrainTimeout),
+			poller.WithMaxAttempts(cfg.Poller.MaxAttempts),
+		}
+		p := poller.New(opts...)
+		infra.Health.AddLiveness(p)
+		infra.Health.AddReadiness(bankClient)
+
+		closure := infra.Supervisor.Run(ctx, p,
+			component.WithRestartPolicy(component.FailFast),
+			component.WithCloseTimeout(cfg.Poller.DrainTimeout+component.CloseTimeout),
+		)
+		infra.Closer.Add(closer.PhaseWorkers, closure)
+	}
+
+	appOpts := []app.Option{
+		app.WithHealth(infra.Health),
+		app.WithExchangesRepo(exchangesRepo.New(database)),
+	}
+	{
+		repo := jobsRepo.New(database)
+		handler := handlers.NewCallbackJobHandler(repo, pub)
+		srv := callbacksSrv.New(db.NewTxFactory(database), repo, eventsRepo.New(database), handler)
+		appOpts = append(appOpts, app.WithCallbacksSrv(srv))
+	}
+	{
+		srv := jobsSrv.New(jobsRepo.New(database), exchangesRepo.New(database), eventsRepo.New(database))
+		appOpts = append(appOpts, app.WithJobsSrv(srv))
+	}
+
+	srv := http.Server{
+		Addr:         cfg.Addr,
+		Handler:      app.New(cfg, appOpts...),
+		ReadTimeout:  defaultReadTimeout,
+		WriteTimeout: defaultWriteTimeout,
+	}
+	infra.Closer.Add(closer.PhaseIngress, func() error {
+		return component.ShutdownServer(&srv)
+	})
+
+	go func() {
+		log.Debugf("starting registry server on %s", cfg.Addr)
+		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Errorf("registry server error: %v", err)
+			infra.Closer.CloseAll()
+		}
+	}()
+	return nil
+}
+
+// ShutdownDeadline leaves every phase its time: the server shutdown, the poller drain
+// and closing of the components and the clients.
+func ShutdownDeadline(cfg config.Config) time.Duration {
+	const (
+		ingress    = component.GracefulDelay + component.GracefulTimeout
+		publishers = component.CloseTimeout
+		infra      = component.CloseTimeout
+	)
+	workers := cfg.Poller.DrainTimeout + component.CloseTimeout
+	return ingress + workers + publishers + infra
+}
@@ -0,0 +1,3 @@
+//go:generate mockery --dir .. --output . --name Handler --filename handler.mock.go
+
+package mocks
@@ -0,0 +1,83 @@
+package callbacksSrv
+
+import (
+	"context"
+	"github.com/ivanovaleksey/lendo/pkg/db"
+	"github.com/ivanovaleksey/lendo/pkg/envelope"
+	commonModels "github.com/ivanovaleksey/lendo/pkg/models"
+	"github.com/ivanovaleksey/lendo/registry/bank"
+	"github.com/ivanovaleksey/lendo/registry/models"
+	jobsRepo "github.com/ivanovaleksey/lendo/registry/repos/jobs"
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+	uuid "github.com/satori/go.uuid"
+	"time"
+)
+
+var ErrJobNotFound = jobsRepo.ErrNotFound
+
+// Service applies bank callbacks to jobs. Jobs are locked while being updated,
+// so a callback and a poller worker never handle the same job concurrently.
+type Service struct {
+	txFactory db.TxFactory
+	repo      Repo
+	events    EventsRepo
+	handler   Handler
+}
+
+type Repo interface {
+	LockJobByApplicationIDTx(ctx context.Context, tx sqlx.QueryerContext, id uuid.UUID) (models.Job, error)
+}
+
+type EventsRepo interface {
+	CreateEventTx(ctx context.Context, tx sqlx.ExecerContext, event models.JobEvent) error
+}
+
+type Handler interface {
+	Handle(ctx context.Context, tx sqlx.ExecerContext, job models.Job, status commonModels.ApplicationStatus) error
+}
+
+func New(txFactory db.TxFactory, repo Repo, events EventsRepo, handler Handler) *Service {
+	srv := &Service{
+		txFactory: txFactory,
+		repo:      repo,
+		events:    events,
+		handler:   handler,
+	}
+	return srv
+}
+
+func (srv *Service) Apply(ctx context.Context, callback bank.Callback) error {
+	tx, err := srv.txFactory.Begin(ctx)
+	if err != nil {
+		return errors.Wrap(err, "can't begin tx")
+	}
+
+	return tx.Do(ctx, func(ctx context.Context, tx db.SQLTx) error {
+		job, err := srv.repo.LockJobByApplicationIDTx(ctx, tx, callback.ApplicationID)
+		if err != nil {
+			return err
+		}
+
+		ctx = models.ContextWithJob(ctx, job)
+		if job.CorrelationID != "" {
+			ctx = envelope.ContextWithCorrelationID(ctx, job.CorrelationID)
+		}
+		start := time.Now()
+		if err := srv.handler.Handle(ctx, tx, job, callback.Status); err != nil {
+			return err
+		}
+
+		// a failed callback is rolled back as a whole and retried by the partner, so only successful ones are recorded
+		event := models.JobEvent{
+			JobID:      job.ID,
+			Handler:    models.JobEventHandlerCallback,
+			BankStatus: callback.Status,
+			DurationMS: time.Since(start).Milliseconds(),
+		}
+		if err := srv.events.CreateEventTx(ctx, tx, event); err != nil {
+			return errors.Wrap(err, "can't record event")
+		}
+		return nil
+	})
+}
@@ -0,0 +1,105 @@
+package callbacksSrv
+
+import (
+	"context"
+	"github.com/brianvoe/gofakeit"
+	"github.com/ivanovaleksey/lendo/pkg/db"
+	commonModels "github.com/ivanovaleksey/lendo/pkg/models"
+	"github.com/ivanovaleksey/lendo/pkg/test"
+	"github.com/ivanovaleksey/lendo/registry/bank"
+	"github.com/ivanovaleksey/lendo/registry/config"
+	"github.com/ivanovaleksey/lendo/registry/models"
+	eventsRepo "github.com/ivanovaleksey/lendo/registry/repos/events"
+	jobsRepo "github.com/ivanovaleksey/lendo/registry/repos/jobs"
+	"github.com/ivanovaleksey/lendo/registry/services/callbacks/mocks"
+	uuid "github.com/satori/go.uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestService_Apply(t *testing.T) {
+	t.Run("when job does not exist", func(t *testing.T) {
+		fx := newFixture(t)
+		defer fx.Finish()
+
+		callback := bank.Callback{
+			ApplicationID: uuid.NewV4(),
+			Status:        commonModels.ApplicationStatusCompleted,
+		}
+
+		err := fx.srv.Apply(fx.ctx, callback)
+
+		assert.Equal(t, ErrJobNotFound, err)
+	})
+
+	t.Run("should handle locked job", func(t *testing.T) {
+		fx := newFixture(t)
+		defer fx.Finish()
+
+		application := commonModels.Application{
+			NewApplication: commonModels.NewApplication{
+				FirstName: gofakeit.FirstName(),
+				LastName:  gofakeit.LastName(),
+			},
+			ID:     uuid.NewV4(),
+			Status: commonModels.ApplicationStatusPending,
+		}
+		job, err := models.NewApplicationJob(application)
+		require.NoError(t, err)
+		job.Status = models.JobStatusPending
+		job.Bank = models.DefaultBank
+		id, _, err := jobsRepo.New(fx.db).CreateJob(fx.ctx, job)
+		require.NoError(t, err)
+		job.ID = id
+
+		callback := bank.Callback{
+			ApplicationID: application.ID,
+			Status:        commonModels.ApplicationStatusCompleted,
+		}
+		fx.handler.On("Handle", mock.Anything, mock.Anything, job, callback.Status).Return(nil)
+
+		err = fx.srv.Apply(fx.ctx, callback)
+
+		require.NoError(t, err)
+		events, err := fx.events.GetByJobID(fx.ctx, job.ID)
+		require.NoError(t, err)
+		require.Len(t, events, 1)
+		assert.Equal(t, models.JobEventHandlerCallback, events[0].Handler)
+		assert.Equal(t, callback.Status, events[0].BankStatus)
+	})
+}
+
+type fixture struct {
+	t   *testing.T
+	ctx context.Context
+	db  *db.DB
+
+	handler *mocks.Handler
+	events  *eventsRepo.Repo
+
+	srv *Service
+}
+
+func newFixture(t *testing.T) *fixture {
+	test.LoadRegistryEnv(t)
+
+	cfg, err := config.New()
+	require.NoError(t, err)
+
+	fx := &fixture{
+		t:       t,
+		ctx:     context.Background(),
+		db:      db.NewTestDB(t, cfg.DB),
+		handler: &mocks.Handler{},
+	}
+	fx.events = eventsRepo.New(fx.db)
+	fx.srv = New(db.NewTxFactory(fx.db), jobsRepo.New(fx.db), fx.events, fx.handler)
+	return fx
+}
+
+func (fx *fixture) Finish() {
+	fx.handler.AssertExpectations(fx.t)
+	require.NoError(fx.t, fx.db.Close())
+}
@@ -0,0 +1,5 @@
+//go:generate mockery --dir .. --output . --name Repo --filename repo.mock.go
+//go:generate mockery --dir .. --output . --name ExchangesRepo --filename exchanges_repo.mock.go
+//go:generate mockery --dir .. --output . --name EventsRepo --filename events_repo.mock.go
+
+package mocks
@@ -0,0 +1,154 @@
+package jobsSrv
+
+import (
+	"context"
+	"github.com/ivanovaleksey/lendo/registry/models"
+	jobsRepo "github.com/ivanovaleksey/lendo/registry/repos/jobs"
+	"github.com/pkg/errors"
+	uuid "github.com/satori/go.uuid"
+	"time"
+)
+
+var (
+	ErrJobNotFound       = jobsRepo.ErrNotFound
+	ErrInvalidTransition = errors.New("invalid job status transition")
+	ErrInvalidRange      = errors.New("from must be before to")
+)
+
+var (
+	activeStatuses   = []models.JobStatus{models.JobStatusNew, models.JobStatusPending}
+	finishedStatuses = []models.JobStatus{models.JobStatusDone, models.JobStatusFailed, models.JobStatusCancelled}
+)
+
+type GetListParams = jobsRepo.GetListParams
+
+// Service lets operators inspect and control jobs.
+type Service struct {
+	repo      Repo
+	exchanges ExchangesRepo
+	events    EventsRepo
+}
+
+type Repo interface {
+	GetList(ctx context.Context, params GetListParams) ([]models.Job, int, error)
+	GetByID(ctx context.Context, id uuid.UUID) (models.Job, error)
+	UpdateStatus(ctx context.Context, id uuid.UUID, from []models.JobStatus, to models.JobStatus) error
+	Reschedule(ctx context.Context, id uuid.UUID, from []models.JobStatus) error
+	Requeue(ctx context.Context, id uuid.UUID, from []models.JobStatus) error
+	RequeueFailed(ctx context.Context, from, to time.Time) (int64, error)
+}
+
+type ExchangesRepo interface {
+	GetByApplicationID(ctx context.Context, id uuid.UUID) ([]models.BankExchange, error)
+}
+
+type EventsRepo interface {
+	GetByJobID(ctx context.Context, id uuid.UUID) ([]models.JobEvent, error)
+}
+
+func New(repo Repo, exchanges ExchangesRepo, events EventsRepo) *Service {
+	srv := &Service{
+		repo:      repo,
+		exchanges: exchanges,
+		events:    events,
+	}
+	return srv
+}
+
+func (srv *Service) GetList(ctx context.Context, params GetListParams) ([]models.Job, int, error) {
+	return srv.repo.GetList(ctx, params)
+}
+
+// GetByID returns the job together with its timeline and, for an application job,
+// the bank exchanges of its application.
+func (srv *Service) GetByID(ctx context.Context, id uuid.UUID) (models.JobDetails, error) {
+	job, err := srv.repo.GetByID(ctx, id)
+	if err != nil {
+		return models.JobDetails{}, err
+	}
+
+	exchanges := make([]models.BankExchange, 0)
+	if job.Kind == models.JobKindApplication {
+		application, err := job.Application()
+		if err != nil {
+			return models.JobDetails{}, errors.Wrap(err, "can't decode application")
+		}
+		exchanges, err = srv.exchanges.GetByApplicationID(ctx, application.ID)
+		if err != nil {
+			return models.JobDetails{}, errors.Wrap(err, "can't get exchanges")
+		}
+	}
+
+	events, err := srv.events.GetByJobID(ctx, job.ID)
+	if err != nil {
+		return models.JobDetails{}, errors.Wrap(err, "can't get events")
+	}
+
+	details := models.JobDetails{
+		Job:       job,
+		Exchanges: exchanges,
+		Events:    events,
+	}
+	return details, nil
+}
+
+// Retry makes an active job run as soon as possible.
+func (srv *Service) Retry(ctx context.Context, id uuid.UUID) error {
+	return srv.transition(ctx, id, activeStatuses, func() error {
+		return srv.repo.Reschedule(ctx, id, activeStatuses)
+	})
+}
+
+func (srv *Service) Cancel(ctx context.Context, id uuid.UUID) error {
+	return srv.transition(ctx, id, activeStatuses, func() error {
+		return srv.repo.UpdateStatus(ctx, id, activeStatuses, models.JobStatusCancelled)
+	})
+}
+
+func (srv *Service) Fail(ctx context.Context, id uuid.UUID) error {
+	return srv.transition(ctx, id, activeStatuses, func() error {
+		return srv.repo.UpdateStatus(ctx, id, activeStatuses, models.JobStatusFailed)
+	})
+}
+
+// Requeue puts a finished job back to processing.
+func (srv *Service) Requeue(ctx context.Context, id uuid.UUID) error {
+	return srv.transition(ctx, id, finishedStatuses, func() error {
+		return srv.repo.Requeue(ctx, id, finishedStatuses)
+	})
+}
+
+// RetryFailed requeues jobs which failed within [from, to) and returns their number.
+func (srv *Service) RetryFailed(ctx context.Context, from, to time.Time) (int64, error) {
+	if !from.Before(to) {
+		return 0, ErrInvalidRange
+	}
+	return srv.repo.RequeueFailed(ctx, from, to)
+}
+
+// transition checks that the job is in one of the allowed statuses and applies fn.
+// Since fn is conditional on the status too, a job changed concurrently results in ErrInvalidTransition.
+func (srv *Service) transition(ctx context.Context, id uuid.UUID, allowed []models.JobStatus, fn func() error) error {
+	job, err := srv.repo.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if !hasStatus(allowed, job.Status) {
+		return errors.Wrapf(ErrInvalidTransition, "job is %s", job.Status)
+	}
+
+	err = fn()
+	if err == jobsRepo.ErrNotFound {
+		return errors.Wrap(ErrInvalidTransition, "job was changed concurrently")
+	}
+	return err
+}
+
+func hasStatus(statuses []models.JobStatus, status models.JobStatus) bool {
+	for _, s := range statuses {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,203 @@
+package jobsSrv
+
+import (
+	"context"
+	"github.com/brianvoe/gofakeit"
+	commonModels "github.com/ivanovaleksey/lendo/pkg/models"
+	"github.com/ivanovaleksey/lendo/registry/models"
+	jobsRepo "github.com/ivanovaleksey/lendo/registry/repos/jobs"
+	"github.com/ivanovaleksey/lendo/registry/services/jobs/mocks"
+	"github.com/pkg/errors"
+	uuid "github.com/satori/go.uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestService_GetByID(t *testing.T) {
+	t.Run("when job does not exist", func(t *testing.T) {
+		fx := newFixture(t)
+		defer fx.Finish()
+
+		id := uuid.NewV4()
+		fx.repo.On("GetByID", fx.ctx, id).Return(models.Job{}, jobsRepo.ErrNotFound)
+
+		details, err := fx.srv.GetByID(fx.ctx, id)
+
+		assert.Equal(t, ErrJobNotFound, err)
+		assert.Empty(t, details)
+	})
+
+	t.Run("should return job with history", func(t *testing.T) {
+		fx := newFixture(t)
+		defer fx.Finish()
+
+		job := fx.buildJob(models.JobStatusPending)
+		application, err := job.Application()
+		require.NoError(t, err)
+		exchanges := []models.BankExchange{{ID: uuid.NewV4(), Method: "GET"}}
+		fx.repo.On("GetByID", fx.ctx, job.ID).Return(job, nil)
+		fx.exchanges.On("GetByApplicationID", fx.ctx, application.ID).Return(exchanges, nil)
+		events := []models.JobEvent{{ID: uuid.NewV4(), JobID: job.ID, Handler: "application.pending"}}
+		fx.events.On("GetByJobID", fx.ctx, job.ID).Return(events, nil)
+
+		details, err := fx.srv.GetByID(fx.ctx, job.ID)
+
+		require.NoError(t, err)
+		assert.Equal(t, models.JobDetails{Job: job, Exchanges: exchanges, Events: events}, details)
+	})
+
+	t.Run("should not get exchanges of other kinds", func(t *testing.T) {
+		fx := newFixture(t)
+		defer fx.Finish()
+
+		job := models.Job{ID: uuid.NewV4(), Kind: models.JobKind(gofakeit.Word()), Status: models.JobStatusNew}
+		fx.repo.On("GetByID", fx.ctx, job.ID).Return(job, nil)
+		fx.events.On("GetByJobID", fx.ctx, job.ID).Return([]models.JobEvent{}, nil)
+
+		details, err := fx.srv.GetByID(fx.ctx, job.ID)
+
+		require.NoError(t, err)
+		assert.Empty(t, details.Exchanges)
+	})
+}
+
+func TestService_Cancel(t *testing.T) {
+	t.Run("when job is active", func(t *testing.T) {
+		fx := newFixture(t)
+		defer fx.Finish()
+
+		job := fx.buildJob(models.JobStatusPending)
+		fx.repo.On("GetByID", fx.ctx, job.ID).Return(job, nil)
+		fx.repo.On("UpdateStatus", fx.ctx, job.ID, activeStatuses, models.JobStatusCancelled).Return(nil)
+
+		err := fx.srv.Cancel(fx.ctx, job.ID)
+
+		assert.NoError(t, err)
+	})
+
+	t.Run("when job is finished", func(t *testing.T) {
+		fx := newFixture(t)
+		defer fx.Finish()
+
+		job := fx.buildJob(models.JobStatusDone)
+		fx.repo.On("GetByID", fx.ctx, job.ID).Return(job, nil)
+
+		err := fx.srv.Cancel(fx.ctx, job.ID)
+
+		assert.Equal(t, ErrInvalidTransition, errors.Cause(err))
+	})
+
+	t.Run("when job is changed concurrently", func(t *testing.T) {
+		fx := newFixture(t)
+		defer fx.Finish()
+
+		job := fx.buildJob(models.JobStatusNew)
+		fx.repo.On("GetByID", fx.ctx, job.ID).Return(job, nil)
+		fx.repo.On("UpdateStatus", fx.ctx, job.ID, activeStatuses, models.JobStatusCancelled).Return(jobsRepo.ErrNotFound)
+
+		err := fx.srv.Cancel(fx.ctx, job.ID)
+
+		assert.Equal(t, ErrInvalidTransition, errors.Cause(err))
+	})
+}
+
+func TestService_Requeue(t *testing.T) {
+	t.Run("when job is failed", func(t *testing.T) {
+		fx := newFixture(t)
+		defer fx.Finish()
+
+		job := fx.buildJob(models.JobStatusFailed)
+		fx.repo.On("GetByID", fx.ctx, job.ID).Return(job, nil)
+		fx.repo.On("Requeue", fx.ctx, job.ID, finishedStatuses).Return(nil)
+
+		err := fx.srv.Requeue(fx.ctx, job.ID)
+
+		assert.NoError(t, err)
+	})
+
+	t.Run("when job is active", func(t *testing.T) {
+		fx := newFixture(t)
+		defer fx.Finish()
+
+		job := fx.buildJob(models.JobStatusNew)
+		fx.repo.On("GetByID", fx.ctx, job.ID).Return(job, nil)
+
+		err := fx.srv.Requeue(fx.ctx, job.ID)
+
+		assert.Equal(t, ErrInvalidTransition, errors.Cause(err))
+	})
+}
+
+func TestService_RetryFailed(t *testing.T) {
+	t.Run("with invalid range", func(t *testing.T) {
+		fx := newFixture(t)
+		defer fx.Finish()
+
+		now := time.Now()
+
+		num, err := fx.srv.RetryFailed(fx.ctx, now, now.Add(-time.Hour))
+
+		assert.Equal(t, ErrInvalidRange, err)
+		assert.Zero(t, num)
+	})
+
+	t.Run("should requeue failed jobs", func(t *testing.T) {
+		fx := newFixture(t)
+		defer fx.Finish()
+
+		to := time.Now()
+		from := to.Add(-time.Hour)
+		fx.repo.On("RequeueFailed", fx.ctx, from, to).Return(int64(3), nil)
+
+		num, err := fx.srv.RetryFailed(fx.ctx, from, to)
+
+		require.NoError(t, err)
+		assert.Equal(t, int64(3), num)
+	})
+}
+
+type fixture struct {
+	t   *testing.T
+	ctx context.Context
+
+	repo      *mocks.Repo
+	exchanges *mocks.ExchangesRepo
+	events    *mocks.EventsRepo
+
+	srv *Service
+}
+
+func newFixture(t *testing.T) *fixture {
+	fx := &fixture{
+		t:         t,
+		ctx:       context.Background(),
+		repo:      &mocks.Repo{},
+		exchanges: &mocks.ExchangesRepo{},
+		events:    &mocks.EventsRepo{},
+	}
+	fx.srv = New(fx.repo, fx.exchanges, fx.events)
+	return fx
+}
+
+func (fx *fixture) Finish() {
+	fx.repo.AssertExpectations(fx.t)
+	fx.exchanges.AssertExpectations(fx.t)
+	fx.events.AssertExpectations(fx.t)
+}
+
+func (fx *fixture) buildJob(status models.JobStatus) models.Job {
+	job, err := models.NewApplicationJob(commonModels.Application{
+		NewApplication: commonModels.NewApplication{
+			FirstName: gofakeit.FirstName(),
+			LastName:  gofakeit.LastName(),
+		},
+		ID:     uuid.NewV4(),
+		Status: commonModels.ApplicationStatusPending,
+	})
+	require.NoError(fx.t, err)
+	job.ID = uuid.NewV4()
+	job.Status = status
+	return job
+}